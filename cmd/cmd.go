@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"crypto/ed25519"
 	"fmt"
 	"net/http"
 	"os"
@@ -12,11 +13,15 @@ import (
 
 	"github.com/vera-byte/vgo-gateway/internal/api"
 	"github.com/vera-byte/vgo-gateway/internal/config"
+	"github.com/vera-byte/vgo-gateway/internal/health"
 	"github.com/vera-byte/vgo-gateway/internal/middleware"
 	"github.com/vera-byte/vgo-gateway/internal/module"
 	"github.com/vera-byte/vgo-gateway/internal/plugin"
-	"github.com/vera-byte/vgo-gateway/modules/example"
-	"github.com/vera-byte/vgo-gateway/modules/iam"
+	"github.com/vera-byte/vgo-gateway/internal/version"
+	// 内置模块在init中向全局模块注册表注册工厂
+	_ "github.com/vera-byte/vgo-gateway/modules/example"
+	_ "github.com/vera-byte/vgo-gateway/modules/iam"
+	"github.com/vera-byte/vgo-gateway/pkg/client"
 
 	"github.com/gin-gonic/gin"
 	"github.com/olekukonko/tablewriter"
@@ -52,7 +57,12 @@ func runServer(cmd *cobra.Command, args []string) {
 	logger, _ := zap.NewProduction()
 	defer logger.Sync()
 
-	logger.Info("Starting VGO Admin Gateway...")
+	buildInfo := version.Get()
+	logger.Info("Starting VGO Admin Gateway...",
+		zap.String("version", buildInfo.Version),
+		zap.String("commit", buildInfo.Commit),
+		zap.String("build_time", buildInfo.BuildTime),
+		zap.String("plugin_api_version", buildInfo.PluginAPIVersion))
 
 	// 加载配置
 	logger.Info("Loading configuration...")
@@ -73,24 +83,45 @@ func runServer(cmd *cobra.Command, args []string) {
 
 	// 设置插件加载器
 	pluginLoader := plugin.NewVKPLoader("plugins", logger)
-	pluginManager.SetLoader(pluginLoader)
-
-	// 注册IAM模块
-	logger.Info("Registering IAM module...")
-	iamFactory := iam.NewIAMModuleFactory()
-	iamModule, err := iamFactory.CreateModule()
+	processOptions, err := processOptionsFromConfig(cfg.Plugins.PluginRuntimeConfig)
 	if err != nil {
-		logger.Fatal("Failed to create IAM module", zap.Error(err))
+		logger.Fatal("Invalid plugin runtime config", zap.Error(err))
+	}
+	pluginLoader.SetProcessOptions(processOptions)
+	for name := range cfg.Plugins.Overrides {
+		opts, err := processOptionsFromConfig(cfg.Plugins.RuntimeFor(name))
+		if err != nil {
+			logger.Fatal("Invalid plugin runtime config", zap.String("plugin", name), zap.Error(err))
+		}
+		pluginLoader.SetPluginProcessOptions(name, opts)
 	}
-	if err := moduleManager.RegisterModule("iam", iamModule); err != nil {
-		logger.Fatal("Failed to register IAM module", zap.Error(err))
+	pluginConfigs := cfg.PluginConfigs()
+	for name, pluginConfig := range pluginConfigs {
+		pluginLoader.SetPluginConfig(name, pluginConfig)
 	}
+	verifier, err := signatureVerifierFromConfig(cfg.Plugins.Signature, logger)
+	if err != nil {
+		logger.Fatal("Invalid plugin signature config", zap.Error(err))
+	}
+	pluginLoader.SetSignatureVerifier(verifier)
+	pluginManager.SetSignatureVerifier(verifier)
+	if cfg.Plugins.Registry.URL != "" {
+		registry, err := plugin.NewRegistryClient(cfg.Plugins.Registry.URL)
+		if err != nil {
+			logger.Fatal("Invalid plugin registry config", zap.Error(err))
+		}
+		pluginManager.SetRegistry(registry)
+		logger.Info("Plugin registry configured", zap.String("url", registry.IndexURL()))
+	}
+	pluginManager.SetMaxDownloadSize(cfg.Plugins.MaxDownloadSize << 20)
+	pluginManager.SetArchiveVersions(cfg.Plugins.ArchiveVersions)
+	pluginManager.SetDrainTimeout(time.Duration(cfg.Plugins.DrainTimeout) * time.Second)
+	pluginManager.SetLoader(pluginLoader)
 
-	// 注册示例模块（可选）
-	logger.Info("Registering Example module...")
-	exampleModule := example.NewExampleModule()
-	if err := moduleManager.RegisterModule("example", exampleModule); err != nil {
-		logger.Fatal("Failed to register Example module", zap.Error(err))
+	// 按配置创建并注册模块，只创建modules中列出且启用的模块
+	logger.Info("Registering modules...", zap.Strings("available", module.DefaultRegistry().ListFactories()))
+	if err := moduleManager.RegisterFromConfig(module.DefaultRegistry(), cfg.Modules); err != nil {
+		logger.Fatal("Failed to register modules", zap.Error(err))
 	}
 
 	// 初始化所有模块
@@ -101,6 +132,24 @@ func runServer(cmd *cobra.Command, args []string) {
 	}
 	logger.Info("All modules initialized successfully")
 
+	// 加载已安装的插件，单个插件失败不影响网关启动
+	logger.Info("Loading installed plugins...", zap.String("dir", vpksDir))
+	report, err := pluginManager.LoadInstalledPlugins()
+	if err != nil {
+		logger.Error("Failed to load installed plugins", zap.Error(err))
+	} else {
+		logger.Info("Installed plugins loaded",
+			zap.Int("loaded", len(report.Loaded)),
+			zap.Int("skipped", len(report.Skipped)),
+			zap.Int("failed", len(report.Failed)))
+	}
+
+	// 按依赖顺序初始化插件
+	logger.Info("Initializing plugins...")
+	if err := pluginManager.InitializeAll(ctx, pluginConfigs); err != nil {
+		logger.Error("Some plugins failed to initialize", zap.Error(err))
+	}
+
 	// 创建Gin引擎
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
@@ -131,26 +180,16 @@ func runServer(cmd *cobra.Command, args []string) {
 		logger.Info("Rate limiter enabled")
 	}
 
-	// 健康检查路由
-	router.GET("/health", func(c *gin.Context) {
-		health := moduleManager.HealthCheck(ctx)
-		if len(health) == 0 {
-			c.JSON(http.StatusOK, gin.H{"status": "healthy", "modules": "none"})
-		} else {
-			allHealthy := true
-			for _, err := range health {
-				if err != nil {
-					allHealthy = false
-					break
-				}
-			}
-			status := "healthy"
-			if !allHealthy {
-				status = "unhealthy"
-			}
-			c.JSON(http.StatusOK, gin.H{"status": status, "modules": health})
-		}
+	// 后台定期检查模块和插件的健康状态
+	healthScheduler := health.NewScheduler(logger, func(kind health.Kind, name string) health.Policy {
+		return healthPolicyFromConfig(cfg.HealthCheckFor(name))
 	})
+	healthScheduler.AddSource(moduleHealthTargets(moduleManager))
+	healthScheduler.AddSource(pluginHealthTargets(pluginManager))
+	healthScheduler.Start()
+
+	// 健康检查路由，返回后台检查缓存的结果，有不健康的模块或插件时返回503
+	router.GET("/health", healthScheduler.Handler())
 
 	// 注册模块路由
 	apiGroup := router.Group("/api/v1")
@@ -159,12 +198,32 @@ func runServer(cmd *cobra.Command, args []string) {
 	}
 	logger.Info("Module routes registered successfully")
 
+	// 插件和模块管理API需要管理员权限
+	iamClient, err := client.NewIAMClient(client.IAMConfig{
+		Endpoint: cfg.IAM.Endpoint,
+		Timeout:  cfg.IAM.Timeout,
+	})
+	if err != nil {
+		logger.Fatal("Failed to create IAM client", zap.Error(err))
+	}
+
 	// 创建并注册插件API处理器
 	logger.Info("Registering plugin API routes...")
 	pluginHandler := api.NewPluginHandler(pluginManager, logger)
-	pluginHandler.RegisterRoutes(router)
+	pluginHandler.RegisterRoutes(router, middleware.AuthMiddleware(iamClient), middleware.RequireRole("admin"))
 	logger.Info("Plugin API routes registered successfully")
 
+	// 创建并注册模块管理API处理器
+	logger.Info("Registering module admin API routes...")
+	moduleHandler := api.NewModuleHandler(moduleManager, logger)
+	moduleHandler.RegisterRoutes(router, middleware.AuthMiddleware(iamClient), middleware.RequireRole("admin"))
+	logger.Info("Module admin API routes registered successfully")
+
+	// 按依赖顺序注册插件路由
+	if err := pluginManager.RegisterAllRoutes(router); err != nil {
+		logger.Error("Some plugin routes failed to register", zap.Error(err))
+	}
+
 	// 创建HTTP服务器
 	srv := &http.Server{
 		Addr:    ":" + cfg.Server.Port,
@@ -188,6 +247,9 @@ func runServer(cmd *cobra.Command, args []string) {
 	<-quit
 	logger.Info("Shutting down server...")
 
+	// 停止健康检查，避免检查正在关闭的模块和插件
+	healthScheduler.Stop()
+
 	// 关闭所有模块
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
@@ -195,6 +257,11 @@ func runServer(cmd *cobra.Command, args []string) {
 		logger.Error("Error shutting down modules", zap.Error(err))
 	}
 
+	// 关闭所有插件（SIGTERM，超时后SIGKILL）
+	if err := pluginManager.ShutdownAll(shutdownCtx); err != nil {
+		logger.Error("Error shutting down plugins", zap.Error(err))
+	}
+
 	// 优雅关闭服务器
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -205,6 +272,141 @@ func runServer(cmd *cobra.Command, args []string) {
 	logger.Info("Server exited")
 }
 
+// processOptionsFromConfig 将插件运行时配置转换为进程运行选项
+// rc: 插件运行时配置
+// 返回值: plugin.ProcessOptions 进程运行选项, error 错误信息
+func processOptionsFromConfig(rc config.PluginRuntimeConfig) (plugin.ProcessOptions, error) {
+	mode, err := plugin.ParseRestartMode(rc.Restart.Policy)
+	if err != nil {
+		return plugin.ProcessOptions{}, err
+	}
+
+	isolation := plugin.IsolationOptions{
+		MemoryLimit:  rc.Isolation.MemoryLimit << 20,
+		CPULimit:     rc.Isolation.CPULimit,
+		MaxOpenFiles: rc.Isolation.MaxOpenFiles,
+		MaxProcesses: rc.Isolation.MaxProcesses,
+		CgroupRoot:   rc.Isolation.CgroupRoot,
+		WorkDir:      rc.Isolation.WorkDir,
+		InheritEnv:   rc.Isolation.InheritEnv,
+		Env:          rc.Isolation.Env,
+		User:         rc.Isolation.User,
+		Group:        rc.Isolation.Group,
+	}
+	if err := isolation.Validate(); err != nil {
+		return plugin.ProcessOptions{}, err
+	}
+
+	return plugin.ProcessOptions{
+		Network:       rc.Network,
+		ReadyTimeout:  time.Duration(rc.ReadyTimeout) * time.Second,
+		StopTimeout:   time.Duration(rc.StopTimeout) * time.Second,
+		LogBufferSize: rc.LogBufferSize,
+		Isolation:     isolation,
+		RestartPolicy: plugin.RestartPolicy{
+			Mode:           mode,
+			MaxRestarts:    rc.Restart.MaxRestarts,
+			InitialBackoff: time.Duration(rc.Restart.InitialBackoff) * time.Second,
+			MaxBackoff:     time.Duration(rc.Restart.MaxBackoff) * time.Second,
+		},
+	}, nil
+}
+
+// healthPolicyFromConfig 将健康检查配置转换为调度策略
+// hc: 健康检查配置
+// 返回值: health.Policy 健康检查策略
+func healthPolicyFromConfig(hc config.HealthCheckConfig) health.Policy {
+	return health.Policy{
+		Enabled:  hc.Enabled,
+		Interval: time.Duration(hc.Interval) * time.Second,
+		Timeout:  time.Duration(hc.Timeout) * time.Second,
+		Retries:  hc.Retries,
+	}
+}
+
+// moduleHealthTargets 列出已启用的模块作为健康检查对象
+// moduleManager: 模块管理器
+// 返回值: health.TargetSource 检查对象来源
+func moduleHealthTargets(moduleManager *module.Manager) health.TargetSource {
+	return func() []health.Target {
+		var targets []health.Target
+		for _, info := range moduleManager.ListModules() {
+			m, exists := moduleManager.GetModule(info.Name)
+			if !info.Enabled || !exists {
+				continue
+			}
+			targets = append(targets, health.Target{
+				Kind: health.KindModule,
+				Name: info.Name,
+				Check: func(ctx context.Context) (map[string]interface{}, error) {
+					return nil, m.HealthCheck(ctx)
+				},
+			})
+		}
+		return targets
+	}
+}
+
+// pluginHealthTargets 列出运行中或降级的插件作为健康检查对象
+// pluginManager: 插件管理器
+// 返回值: health.TargetSource 检查对象来源
+func pluginHealthTargets(pluginManager *plugin.Manager) health.TargetSource {
+	return func() []health.Target {
+		var targets []health.Target
+		for _, name := range pluginManager.ActivePlugins() {
+			targets = append(targets, health.Target{
+				Kind: health.KindPlugin,
+				Name: name,
+				Check: func(ctx context.Context) (map[string]interface{}, error) {
+					return pluginManager.CheckPluginHealth(name)
+				},
+			})
+		}
+		return targets
+	}
+}
+
+// signatureVerifierFromConfig 根据签名配置创建VKP包签名校验器
+// sc: 签名校验配置
+// logger: 日志记录器
+// 返回值: *plugin.SignatureVerifier 签名校验器, error 错误信息
+func signatureVerifierFromConfig(sc config.SignatureConfig, logger *zap.Logger) (*plugin.SignatureVerifier, error) {
+	policy, err := plugin.ParseSignaturePolicy(sc.Policy)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]ed25519.PublicKey, 0, len(sc.TrustedKeys)+len(sc.TrustedKeyFiles))
+	for _, s := range sc.TrustedKeys {
+		key, err := plugin.ParsePublicKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("trusted key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	for _, path := range sc.TrustedKeyFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("trusted key file: %w", err)
+		}
+		key, err := plugin.ParsePublicKey(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("trusted key file %s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+
+	if policy != plugin.SignaturePolicyReject {
+		logger.Warn("Plugin signature enforcement is relaxed, use policy reject in production",
+			zap.String("policy", string(policy)))
+	}
+	logger.Info("Plugin signature verification configured",
+		zap.String("policy", string(policy)),
+		zap.Int("trusted_keys", len(keys)))
+
+	return plugin.NewSignatureVerifier(policy, keys, logger), nil
+}
+
 // printServerInfo 输出服务器信息，包括路由数量和中间件信息
 // router: Gin引擎实例
 // logger: 日志记录器
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vera-byte/vgo-gateway/internal/config"
+	"github.com/vera-byte/vgo-gateway/internal/plugin"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// configCmd 配置管理命令
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the gateway configuration",
+	Long:  `Tools for checking the gateway configuration.`,
+}
+
+// configValidateCmd 插件配置校验命令
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [plugin...]",
+	Short: "Validate plugin configuration against plugin config schemas",
+	Long: `Validate the modules.<plugin> sections of the gateway configuration against
+the config_schema declared by each installed plugin in --vpks-dir, the same
+way the gateway does before initializing a plugin. Missing fields with a
+default are filled in; --print shows the resulting configuration.
+
+With --schema, the metadata of a packager config file (vkp-config.json) is
+used instead of the installed packages, so a plugin's schema can be checked
+before it is built and installed.
+
+The command exits with a non-zero status if any configuration is invalid.`,
+	SilenceUsage: true,
+	RunE:         runConfigValidate,
+}
+
+func init() {
+	configValidateCmd.Flags().String("vpks-dir", filepath.Join("plugins", "vpks"), "plugin package directory")
+	configValidateCmd.Flags().String("schema", "", "validate against the metadata in a vkp-config.json file instead of installed plugins")
+	configValidateCmd.Flags().Bool("print", false, "print the validated configuration with defaults applied")
+	configCmd.AddCommand(configValidateCmd)
+	RootCmd.AddCommand(configCmd)
+}
+
+// runConfigValidate 按插件的配置模式校验网关配置中的插件配置
+// cmd: cobra命令实例
+// args: 命令行参数，指定时只校验这些插件
+// 返回值: error 错误信息，存在无效配置时返回错误
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	vpksDir, _ := cmd.Flags().GetString("vpks-dir")
+	schemaPath, _ := cmd.Flags().GetString("schema")
+	printConfig, _ := cmd.Flags().GetBool("print")
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var targets []plugin.PluginMetadata
+	if schemaPath != "" {
+		metadata, err := readPackagerMetadata(schemaPath)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, *metadata)
+	} else {
+		targets, err = installedPluginMetadata(vpksDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	wanted := make(map[string]bool, len(args))
+	for _, name := range args {
+		wanted[name] = true
+	}
+
+	out := cmd.OutOrStdout()
+	checked, invalid := 0, 0
+	for i := range targets {
+		metadata := &targets[i]
+		if len(wanted) > 0 && !wanted[metadata.Name] {
+			continue
+		}
+		delete(wanted, metadata.Name)
+		checked++
+
+		label := fmt.Sprintf("%s %s", metadata.Name, metadata.Version)
+		if len(metadata.ConfigSchema) == 0 {
+			fmt.Fprintf(out, "%s: no config schema\n", label)
+			continue
+		}
+
+		resolved, err := plugin.ValidateConfig(metadata.Name, metadata, config.StripModuleSettings(cfg.Modules[metadata.Name]))
+		if err != nil {
+			invalid++
+			fmt.Fprintf(out, "%s: invalid\n", label)
+			printConfigErrors(out, err)
+			continue
+		}
+		fmt.Fprintf(out, "%s: ok\n", label)
+		if printConfig {
+			data, _ := json.MarshalIndent(resolved, "  ", "  ")
+			fmt.Fprintf(out, "  %s\n", data)
+		}
+	}
+
+	if len(wanted) > 0 {
+		missing := make([]string, 0, len(wanted))
+		for name := range wanted {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+		return fmt.Errorf("plugin not found: %s", strings.Join(missing, ", "))
+	}
+	if checked == 0 {
+		fmt.Fprintln(out, "no plugins to validate")
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d plugin config(s) invalid", invalid)
+	}
+	return nil
+}
+
+// printConfigErrors 输出配置校验错误，字段错误逐行输出
+// out: 输出
+// err: 校验错误
+func printConfigErrors(out io.Writer, err error) {
+	var validationErr *plugin.ConfigValidationError
+	if !errors.As(err, &validationErr) {
+		fmt.Fprintf(out, "  - %v\n", err)
+		return
+	}
+	for _, field := range validationErr.Fields {
+		fmt.Fprintf(out, "  - %s: %s\n", field.Field, field.Message)
+	}
+}
+
+// installedPluginMetadata 读取已安装插件包清单中的元数据
+// vpksDir: 插件包目录
+// 返回值: []plugin.PluginMetadata 插件元数据列表, error 错误信息
+func installedPluginMetadata(vpksDir string) ([]plugin.PluginMetadata, error) {
+	logger := zap.NewNop()
+	files, err := plugin.NewPluginInstaller(vpksDir, logger).ListInstalledPlugins()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list installed plugins: %w", err)
+	}
+
+	var targets []plugin.PluginMetadata
+	for _, filename := range files {
+		manifest, _, err := plugin.ReadManifestFromVKP(filepath.Join(vpksDir, filename))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest of %s: %w", filename, err)
+		}
+		targets = append(targets, manifest.Plugin)
+	}
+	return targets, nil
+}
+
+// readPackagerMetadata 读取打包配置文件（vkp-config.json）中的插件元数据
+// path: 打包配置文件路径
+// 返回值: *plugin.PluginMetadata 插件元数据, error 错误信息
+func readPackagerMetadata(path string) (*plugin.PluginMetadata, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var packagerConfig struct {
+		Metadata *plugin.PluginMetadata `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &packagerConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if packagerConfig.Metadata == nil || packagerConfig.Metadata.Name == "" {
+		return nil, fmt.Errorf("%s has no plugin metadata", path)
+	}
+	return packagerConfig.Metadata, nil
+}
@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vera-byte/vgo-gateway/internal/config"
+	"github.com/vera-byte/vgo-gateway/internal/plugin"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// pluginCmd 插件管理命令
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Manage VKP plugins",
+	Long:  `Tools for building, signing and managing VKP plugin packages.`,
+}
+
+// keygenCmd 签名密钥生成命令
+var keygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Generate an Ed25519 key pair for signing VKP packages",
+	Long: `Generate an Ed25519 key pair for signing VKP packages.
+The private key is written to --out (PEM, mode 0600) and is used as the
+packager's signing_key. The printed public key goes into
+plugins.signature.trusted_keys of the gateway configuration.`,
+	SilenceUsage: true,
+	RunE:         runKeygen,
+}
+
+// installFileCmd 本地插件包安装命令
+var installFileCmd = &cobra.Command{
+	Use:   "install-file <path.vkp>",
+	Short: "Install a VKP package from a local file",
+	Long: `Install a VKP package from a local file into the plugin directory.
+The package is verified the same way as packages installed through the API
+(manifest, file hashes and signature policy from the gateway configuration).
+Other versions of the same service are moved to the archive directory;
+installing a lower version than the installed one requires --force.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runInstallFile,
+}
+
+// installCmd 从插件仓库按名称安装插件的命令
+var installCmd = &cobra.Command{
+	Use:   "install <name>",
+	Short: "Install a plugin by name from the plugin registry",
+	Long: `Install a plugin from the registry configured in plugins.registry.url
+(or --registry). The highest version that satisfies --version and has a
+package for the current platform is downloaded, checked against the SHA-256
+in the index and verified like any other package.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runInstall,
+}
+
+// indexCmd 插件仓库索引生成命令
+var indexCmd = &cobra.Command{
+	Use:   "index <dir>",
+	Short: "Generate a plugin registry index from a directory of VKP packages",
+	Long: `Generate a registry index (JSON) for the VKP packages in <dir>.
+Package URLs are relative to the index unless --base-url is given, so the
+directory can be published as-is over HTTP or used through a file:// URL.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runIndex,
+}
+
+func init() {
+	keygenCmd.Flags().StringP("out", "o", "vkp-signing.key", "private key output path")
+	installFileCmd.Flags().String("vpks-dir", filepath.Join("plugins", "vpks"), "plugin package directory")
+	installFileCmd.Flags().Bool("force", false, "allow installing a lower version than the installed one")
+	installCmd.Flags().String("vpks-dir", filepath.Join("plugins", "vpks"), "plugin package directory")
+	installCmd.Flags().String("version", "", "version constraint, e.g. ^1.2.0 (default: latest)")
+	installCmd.Flags().String("registry", "", "registry index URL (default: plugins.registry.url)")
+	installCmd.Flags().Bool("force", false, "allow installing a lower version than the installed one")
+	indexCmd.Flags().StringP("out", "o", "", "index output path (default: <dir>/index.json)")
+	indexCmd.Flags().String("base-url", "", "base URL of the packages in the index")
+	pluginCmd.AddCommand(keygenCmd)
+	pluginCmd.AddCommand(installFileCmd)
+	pluginCmd.AddCommand(installCmd)
+	pluginCmd.AddCommand(indexCmd)
+	RootCmd.AddCommand(pluginCmd)
+}
+
+// runKeygen 生成签名密钥
+// cmd: cobra命令实例
+// args: 命令行参数
+// 返回值: error 错误信息
+func runKeygen(cmd *cobra.Command, args []string) error {
+	out, _ := cmd.Flags().GetString("out")
+
+	publicKey, privatePEM, err := plugin.GenerateSigningKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	file, err := os.OpenFile(out, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create private key file: %w", err)
+	}
+	if _, err := file.Write(privatePEM); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+
+	key, err := plugin.ParsePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Private key: %s\n", out)
+	fmt.Printf("Key ID:      %s\n", plugin.KeyID(key))
+	fmt.Printf("Public key:  %s\n", publicKey)
+	return nil
+}
+
+// runInstallFile 从本地文件安装插件
+// cmd: cobra命令实例
+// args: 命令行参数
+// 返回值: error 错误信息
+func runInstallFile(cmd *cobra.Command, args []string) error {
+	vpksDir, _ := cmd.Flags().GetString("vpks-dir")
+	force, _ := cmd.Flags().GetBool("force")
+
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	installer, err := installerFromConfig(cfg, vpksDir, logger)
+	if err != nil {
+		return err
+	}
+
+	localPath, err := installer.InstallFromFile(args[0], plugin.InstallOptions{Force: force})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Installed: %s\n", localPath)
+	return nil
+}
+
+// runInstall 从插件仓库按名称安装插件
+// cmd: cobra命令实例
+// args: 命令行参数
+// 返回值: error 错误信息
+func runInstall(cmd *cobra.Command, args []string) error {
+	vpksDir, _ := cmd.Flags().GetString("vpks-dir")
+	version, _ := cmd.Flags().GetString("version")
+	registryURL, _ := cmd.Flags().GetString("registry")
+	force, _ := cmd.Flags().GetBool("force")
+
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if registryURL == "" {
+		registryURL = cfg.Plugins.Registry.URL
+	}
+	if registryURL == "" {
+		return fmt.Errorf("%w: set plugins.registry.url or --registry", plugin.ErrRegistryNotConfigured)
+	}
+
+	installer, err := installerFromConfig(cfg, vpksDir, logger)
+	if err != nil {
+		return err
+	}
+	registry, err := plugin.NewRegistryClient(registryURL)
+	if err != nil {
+		return err
+	}
+	installer.SetRegistry(registry)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	localPath, err := installer.InstallByName(ctx, args[0], version, plugin.InstallOptions{Force: force})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Installed: %s\n", localPath)
+	return nil
+}
+
+// runIndex 生成插件仓库索引
+// cmd: cobra命令实例
+// args: 命令行参数
+// 返回值: error 错误信息
+func runIndex(cmd *cobra.Command, args []string) error {
+	out, _ := cmd.Flags().GetString("out")
+	baseURL, _ := cmd.Flags().GetString("base-url")
+	if out == "" {
+		out = filepath.Join(args[0], "index.json")
+	}
+
+	index, err := plugin.BuildRegistryIndex(args[0], baseURL)
+	if err != nil {
+		return fmt.Errorf("failed to build index: %w", err)
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+
+	count := 0
+	for _, p := range index.Plugins {
+		count += len(p.Versions)
+	}
+	fmt.Printf("Indexed %d plugin(s), %d version(s): %s\n", len(index.Plugins), count, out)
+	return nil
+}
+
+// installerFromConfig 按网关配置创建插件安装器
+// cfg: 网关配置
+// vpksDir: 插件包目录
+// logger: 日志记录器
+// 返回值: *plugin.PluginInstaller 插件安装器, error 错误信息
+func installerFromConfig(cfg *config.Config, vpksDir string, logger *zap.Logger) (*plugin.PluginInstaller, error) {
+	verifier, err := signatureVerifierFromConfig(cfg.Plugins.Signature, logger)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plugin signature config: %w", err)
+	}
+
+	installer := plugin.NewPluginInstaller(vpksDir, logger)
+	installer.SetSignatureVerifier(verifier)
+	installer.SetMaxDownloadSize(cfg.Plugins.MaxDownloadSize << 20)
+	installer.SetArchiveVersions(cfg.Plugins.ArchiveVersions)
+	return installer, nil
+}
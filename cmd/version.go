@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/vera-byte/vgo-gateway/internal/version"
+
+	"github.com/spf13/cobra"
+)
+
+// versionCmd 版本信息命令
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print build information",
+	Long:  `Print the gateway version, commit, build time and supported plugin API version.`,
+	Run:   runVersion,
+}
+
+func init() {
+	RootCmd.AddCommand(versionCmd)
+}
+
+// runVersion 输出构建信息
+// cmd: cobra命令实例
+// args: 命令行参数
+func runVersion(cmd *cobra.Command, args []string) {
+	fmt.Fprintln(cmd.OutOrStdout(), "vgo-gateway", version.Get().String())
+}
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vera-byte/vgo-gateway/internal/module"
+	"github.com/vera-byte/vgo-gateway/internal/plugin"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// moduleHealthTimeout 模块详情中健康检查的超时时间
+const moduleHealthTimeout = 5 * time.Second
+
+// ModuleHandler 模块管理API处理器
+type ModuleHandler struct {
+	// moduleManager 模块管理器
+	moduleManager *module.Manager
+	
+	// router Gin路由器，用于查询模块注册的路由
+	router *gin.Engine
+	
+	// logger 日志记录器
+	logger *zap.Logger
+}
+
+// NewModuleHandler 创建新的模块管理API处理器
+// moduleManager: 模块管理器
+// logger: 日志记录器
+// 返回: 模块管理API处理器实例
+func NewModuleHandler(moduleManager *module.Manager, logger *zap.Logger) *ModuleHandler {
+	return &ModuleHandler{
+		moduleManager: moduleManager,
+		logger:        logger,
+	}
+}
+
+// ModuleRoute 模块注册的路由
+type ModuleRoute struct {
+	// Method HTTP方法
+	Method string `json:"method"`
+	
+	// Path 路由路径
+	Path string `json:"path"`
+}
+
+// ModuleHealth 模块健康状态
+type ModuleHealth struct {
+	// Status 健康状态: healthy、unhealthy 或 disabled
+	Status string `json:"status"`
+	
+	// Error 健康检查失败的原因
+	Error string `json:"error,omitempty"`
+}
+
+// ModuleDetail 模块详情
+type ModuleDetail struct {
+	// Name 模块名称
+	Name string `json:"name"`
+	
+	// Version 模块版本
+	Version string `json:"version"`
+	
+	// Description 模块描述
+	Description string `json:"description"`
+	
+	// Enabled 是否已启用
+	Enabled bool `json:"enabled"`
+	
+	// Dependencies 依赖的模块
+	Dependencies []string `json:"dependencies,omitempty"`
+	
+	// LoadOrder 加载顺序
+	LoadOrder int `json:"load_order"`
+	
+	// Config 生效的模块配置，敏感配置项已脱敏
+	Config interface{} `json:"config,omitempty"`
+	
+	// Health 健康状态
+	Health ModuleHealth `json:"health"`
+	
+	// Routes 模块注册的路由
+	Routes []ModuleRoute `json:"routes"`
+}
+
+// ListModulesResponse 列出模块响应
+type ListModulesResponse struct {
+	// Success 是否成功
+	Success bool `json:"success"`
+	
+	// Message 消息
+	Message string `json:"message"`
+	
+	// Modules 模块列表
+	Modules []module.ModuleInfo `json:"modules"`
+}
+
+// ModuleDetailResponse 模块详情响应
+type ModuleDetailResponse struct {
+	// Success 是否成功
+	Success bool `json:"success"`
+	
+	// Message 消息
+	Message string `json:"message"`
+	
+	// Module 模块详情
+	Module *ModuleDetail `json:"module,omitempty"`
+}
+
+// ListModules 列出所有模块
+// c: Gin上下文
+func (h *ModuleHandler) ListModules(c *gin.Context) {
+	c.JSON(http.StatusOK, ListModulesResponse{
+		Success: true,
+		Message: "获取模块列表成功",
+		Modules: h.moduleManager.ListModules(),
+	})
+}
+
+// GetModule 获取模块详情，包括脱敏后的配置、健康状态和路由
+// c: Gin上下文
+func (h *ModuleHandler) GetModule(c *gin.Context) {
+	detail, ok := h.moduleDetail(c.Request.Context(), c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, ModuleDetailResponse{
+			Success: false,
+			Message: "模块不存在: " + c.Param("name"),
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, ModuleDetailResponse{
+		Success: true,
+		Message: "获取模块详情成功",
+		Module:  detail,
+	})
+}
+
+// EnableModule 启用模块
+// c: Gin上下文
+func (h *ModuleHandler) EnableModule(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	
+	h.respondModuleAction(c, "启用", h.moduleManager.EnableModule(ctx, c.Param("name")))
+}
+
+// DisableModule 停用模块
+// c: Gin上下文
+func (h *ModuleHandler) DisableModule(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	
+	h.respondModuleAction(c, "停用", h.moduleManager.DisableModule(ctx, c.Param("name")))
+}
+
+// respondModuleAction 返回启用或停用操作的结果，成功时附带模块详情
+// c: Gin上下文
+// action: 操作名称
+// err: 操作的错误
+func (h *ModuleHandler) respondModuleAction(c *gin.Context, action string, err error) {
+	name := c.Param("name")
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, module.ErrModuleNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, module.ErrDependencyConflict):
+			status = http.StatusConflict
+		}
+		h.logger.Error(action+"模块失败", 
+			zap.String("name", name),
+			zap.Error(err))
+		c.JSON(status, ModuleDetailResponse{
+			Success: false,
+			Message: action + "模块失败: " + err.Error(),
+		})
+		return
+	}
+	
+	detail, _ := h.moduleDetail(c.Request.Context(), name)
+	c.JSON(http.StatusOK, ModuleDetailResponse{
+		Success: true,
+		Message: "模块" + action + "成功",
+		Module:  detail,
+	})
+}
+
+// moduleDetail 汇总模块详情
+// ctx: 上下文
+// name: 模块名称
+// 返回: 模块详情和模块是否存在
+func (h *ModuleHandler) moduleDetail(ctx context.Context, name string) (*ModuleDetail, bool) {
+	info, ok := h.moduleManager.GetModuleInfo(name)
+	if !ok {
+		return nil, false
+	}
+	
+	detail := &ModuleDetail{
+		Name:         info.Name,
+		Version:      info.Version,
+		Description:  info.Description,
+		Enabled:      info.Enabled,
+		Dependencies: info.Dependencies,
+		LoadOrder:    info.LoadOrder,
+		Config:       plugin.RedactSecrets(info.Config),
+		Health:       ModuleHealth{Status: "disabled"},
+		Routes:       h.moduleRoutes(name),
+	}
+	if info.Enabled {
+		detail.Health = ModuleHealth{Status: "healthy"}
+		if m, exists := h.moduleManager.GetModule(name); exists {
+			healthCtx, cancel := context.WithTimeout(ctx, moduleHealthTimeout)
+			defer cancel()
+			if err := m.HealthCheck(healthCtx); err != nil {
+				detail.Health = ModuleHealth{Status: "unhealthy", Error: err.Error()}
+			}
+		}
+	}
+	return detail, true
+}
+
+// moduleRoutes 列出模块注册的路由，按路径和方法排序
+// name: 模块名称
+// 返回: 路由列表
+func (h *ModuleHandler) moduleRoutes(name string) []ModuleRoute {
+	routes := []ModuleRoute{}
+	prefix := h.moduleManager.RoutePrefix(name)
+	if prefix == "" || h.router == nil {
+		return routes
+	}
+	
+	for _, route := range h.router.Routes() {
+		if route.Path == prefix || strings.HasPrefix(route.Path, prefix+"/") {
+			routes = append(routes, ModuleRoute{Method: route.Method, Path: route.Path})
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// RegisterRoutes 注册模块管理API路由
+// router: Gin路由器
+// auth: 管理员认证中间件
+func (h *ModuleHandler) RegisterRoutes(router *gin.Engine, auth ...gin.HandlerFunc) {
+	h.router = router
+	
+	api := router.Group("/api/v1/admin/modules", auth...)
+	{
+		// 列出模块
+		api.GET("", h.ListModules)
+		
+		// 获取模块详情
+		api.GET("/:name", h.GetModule)
+		
+		// 启用和停用模块
+		api.POST("/:name/enable", h.EnableModule)
+		api.POST("/:name/disable", h.DisableModule)
+	}
+}
@@ -2,7 +2,10 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/vera-byte/vgo-gateway/internal/plugin"
@@ -31,9 +34,22 @@ func NewPluginHandler(pluginManager *plugin.Manager, logger *zap.Logger) *Plugin
 }
 
 // InstallPluginRequest 安装插件请求
+// 指定URL时从URL下载，指定Name时从插件仓库按名称和版本范围安装，两者只能指定一个
 type InstallPluginRequest struct {
 	// URL 插件下载URL
-	URL string `json:"url" binding:"required"`
+	URL string `json:"url"`
+	
+	// Name 插件名称，从插件仓库安装
+	Name string `json:"name"`
+	
+	// Version 版本范围（如 ^1.2.0），仅与Name一起使用，为空表示最新版本
+	Version string `json:"version"`
+	
+	// SHA256 插件包的SHA-256校验和（十六进制，可选）
+	SHA256 string `json:"sha256"`
+	
+	// Force 是否允许安装低于已安装版本的插件
+	Force bool `json:"force"`
 	
 	// AutoLoad 是否自动加载插件
 	AutoLoad bool `json:"auto_load"`
@@ -49,6 +65,9 @@ type InstallPluginResponse struct {
 	
 	// PluginName 插件名称（仅在自动加载时返回）
 	PluginName string `json:"plugin_name,omitempty"`
+	
+	// Errors 插件配置的字段错误（仅在配置不满足插件的配置模式时返回）
+	Errors []plugin.ConfigFieldError `json:"errors,omitempty"`
 }
 
 // InstallPlugin 安装插件
@@ -63,38 +82,253 @@ func (h *PluginHandler) InstallPlugin(c *gin.Context) {
 		return
 	}
 	
+	if (req.URL == "") == (req.Name == "") {
+		c.JSON(http.StatusBadRequest, InstallPluginResponse{
+			Success: false,
+			Message: "无效的请求参数: url和name必须且只能指定一个",
+		})
+		return
+	}
+	if req.Version != "" && req.Name == "" {
+		c.JSON(http.StatusBadRequest, InstallPluginResponse{
+			Success: false,
+			Message: "无效的请求参数: version只能与name一起使用",
+		})
+		return
+	}
+	if _, err := plugin.ParseVersionConstraint(req.Version); err != nil {
+		c.JSON(http.StatusBadRequest, InstallPluginResponse{
+			Success: false,
+			Message: "无效的请求参数: " + err.Error(),
+		})
+		return
+	}
+	
 	h.logger.Info("收到插件安装请求", 
 		zap.String("url", req.URL),
+		zap.String("name", req.Name),
+		zap.String("version", req.Version),
+		zap.String("sha256", req.SHA256),
+		zap.Bool("force", req.Force),
 		zap.Bool("auto_load", req.AutoLoad))
 	
 	// 创建带超时的上下文
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
 	defer cancel()
 	
+	opts := plugin.InstallOptions{SHA256: req.SHA256, Force: req.Force}
 	if req.AutoLoad {
 		// 安装并加载插件
-		if err := h.pluginManager.InstallAndLoadPluginFromURL(ctx, req.URL); err != nil {
+		var name string
+		var err error
+		if req.Name != "" {
+			name, err = h.pluginManager.InstallAndLoadPluginByName(ctx, req.Name, req.Version, opts)
+		} else {
+			name, err = h.pluginManager.InstallAndLoadPluginFromURL(ctx, req.URL, opts)
+		}
+		if err != nil {
 			h.logger.Error("安装并加载插件失败", 
 				zap.String("url", req.URL),
+				zap.String("name", req.Name),
 				zap.Error(err))
-			c.JSON(http.StatusInternalServerError, InstallPluginResponse{
+			c.JSON(pluginErrorStatus(err), InstallPluginResponse{
 				Success: false,
 				Message: "安装并加载插件失败: " + err.Error(),
+				Errors:  configFieldErrors(err),
 			})
 			return
 		}
 		
 		c.JSON(http.StatusOK, InstallPluginResponse{
-			Success: true,
-			Message: "插件安装并加载成功",
+			Success:    true,
+			Message:    "插件安装并加载成功",
+			PluginName: name,
 		})
 	} else {
 		// 仅安装插件
-		if err := h.pluginManager.InstallPluginFromURL(ctx, req.URL); err != nil {
+		var err error
+		if req.Name != "" {
+			_, err = h.pluginManager.InstallPluginByName(ctx, req.Name, req.Version, opts)
+		} else {
+			err = h.pluginManager.InstallPluginFromURL(ctx, req.URL, opts)
+		}
+		if err != nil {
 			h.logger.Error("安装插件失败", 
 				zap.String("url", req.URL),
+				zap.String("name", req.Name),
 				zap.Error(err))
-			c.JSON(http.StatusInternalServerError, InstallPluginResponse{
+			c.JSON(pluginErrorStatus(err), InstallPluginResponse{
+				Success: false,
+				Message: "安装插件失败: " + err.Error(),
+			})
+			return
+		}
+		
+		c.JSON(http.StatusOK, InstallPluginResponse{
+			Success: true,
+			Message: "插件安装成功",
+		})
+	}
+}
+
+// uploadFormOverhead 上传请求中除插件包外multipart表单的额外大小
+const uploadFormOverhead = 1 << 20
+
+// pluginErrorStatus 根据插件操作错误选择HTTP状态码
+// err: 安装、回滚或生命周期操作的错误
+// 返回: HTTP状态码
+func pluginErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, plugin.ErrPluginNotFound),
+		errors.Is(err, plugin.ErrNoPreviousVersion),
+		errors.Is(err, plugin.ErrNoMatchingVersion):
+		return http.StatusNotFound
+	case errors.Is(err, plugin.ErrDowngradeRefused),
+		errors.Is(err, plugin.ErrPluginInUse),
+		errors.Is(err, plugin.ErrInvalidStateTransition),
+		errors.Is(err, plugin.ErrRouteConflict):
+		return http.StatusConflict
+	case errors.Is(err, plugin.ErrIncompatiblePlugin),
+		errors.Is(err, plugin.ErrUnsatisfiedDependency),
+		errors.Is(err, plugin.ErrDependencyCycle),
+		errors.Is(err, plugin.ErrInvalidConfig),
+		errors.Is(err, plugin.ErrSignatureRejected),
+		errors.Is(err, plugin.ErrChecksumMismatch),
+		errors.Is(err, plugin.ErrPackageMismatch):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, plugin.ErrInvalidChecksum),
+		errors.Is(err, plugin.ErrInvalidPackageName):
+		return http.StatusBadRequest
+	case errors.Is(err, plugin.ErrPackageTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, plugin.ErrRegistryUnavailable):
+		return http.StatusBadGateway
+	case errors.Is(err, plugin.ErrRegistryNotConfigured):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// configFieldErrors 提取插件配置校验的字段错误
+// err: 插件操作的错误
+// 返回: 字段错误，不是配置校验错误时为nil
+func configFieldErrors(err error) []plugin.ConfigFieldError {
+	var validationErr *plugin.ConfigValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Fields
+	}
+	return nil
+}
+
+// parseFormBool 解析表单中的布尔字段
+// c: Gin上下文
+// key: 字段名
+// 返回: 字段值和错误信息，字段为空时返回false
+func parseFormBool(c *gin.Context, key string) (bool, error) {
+	value := c.PostForm(key)
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("无效的%s参数: %s", key, value)
+	}
+	return parsed, nil
+}
+
+// UploadPlugin 上传并安装插件
+// multipart表单字段file为.vkp文件，auto_load为true时安装后立即加载，force为true时允许降级
+// c: Gin上下文
+func (h *PluginHandler) UploadPlugin(c *gin.Context) {
+	maxSize := h.pluginManager.MaxPackageSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+uploadFormOverhead)
+	
+	autoLoad, err := parseFormBool(c, "auto_load")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, InstallPluginResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	force, err := parseFormBool(c, "force")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, InstallPluginResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+	
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		status := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, InstallPluginResponse{
+			Success: false,
+			Message: "无效的上传文件: " + err.Error(),
+		})
+		return
+	}
+	if fileHeader.Size > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, InstallPluginResponse{
+			Success: false,
+			Message: "插件文件超过大小上限: " + strconv.FormatInt(maxSize, 10),
+		})
+		return
+	}
+	
+	h.logger.Info("收到插件上传请求", 
+		zap.String("filename", fileHeader.Filename),
+		zap.Int64("size", fileHeader.Size),
+		zap.Bool("force", force),
+		zap.Bool("auto_load", autoLoad))
+	
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, InstallPluginResponse{
+			Success: false,
+			Message: "读取上传文件失败: " + err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+	
+	opts := plugin.InstallOptions{Force: force}
+	if autoLoad {
+		// 安装并加载插件
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+		defer cancel()
+		
+		name, err := h.pluginManager.InstallAndLoadPluginFromReader(ctx, fileHeader.Filename, file, opts)
+		if err != nil {
+			h.logger.Error("安装并加载插件失败", 
+				zap.String("filename", fileHeader.Filename),
+				zap.Error(err))
+			c.JSON(pluginErrorStatus(err), InstallPluginResponse{
+				Success: false,
+				Message: "安装并加载插件失败: " + err.Error(),
+				Errors:  configFieldErrors(err),
+			})
+			return
+		}
+		
+		c.JSON(http.StatusOK, InstallPluginResponse{
+			Success:    true,
+			Message:    "插件安装并加载成功",
+			PluginName: name,
+		})
+	} else {
+		// 仅安装插件
+		if _, err := h.pluginManager.InstallPluginFromReader(fileHeader.Filename, file, opts); err != nil {
+			h.logger.Error("安装插件失败", 
+				zap.String("filename", fileHeader.Filename),
+				zap.Error(err))
+			c.JSON(pluginErrorStatus(err), InstallPluginResponse{
 				Success: false,
 				Message: "安装插件失败: " + err.Error(),
 			})
@@ -140,6 +374,41 @@ func (h *PluginHandler) ListInstalledPlugins(c *gin.Context) {
 	})
 }
 
+// AvailablePluginsResponse 列出插件仓库中可用插件响应
+type AvailablePluginsResponse struct {
+	// Success 是否成功
+	Success bool `json:"success"`
+	
+	// Message 消息
+	Message string `json:"message"`
+	
+	// Plugins 可用插件列表
+	Plugins []plugin.AvailablePlugin `json:"plugins"`
+}
+
+// ListAvailablePlugins 列出插件仓库中适用于当前平台的插件
+// c: Gin上下文
+func (h *PluginHandler) ListAvailablePlugins(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	
+	plugins, err := h.pluginManager.AvailablePlugins(ctx)
+	if err != nil {
+		h.logger.Error("获取可用插件列表失败", zap.Error(err))
+		c.JSON(pluginErrorStatus(err), AvailablePluginsResponse{
+			Success: false,
+			Message: "获取可用插件列表失败: " + err.Error(),
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, AvailablePluginsResponse{
+		Success: true,
+		Message: "获取可用插件列表成功",
+		Plugins: plugins,
+	})
+}
+
 // RemovePluginRequest 移除插件请求
 type RemovePluginRequest struct {
 	// Filename 插件文件名
@@ -186,18 +455,249 @@ func (h *PluginHandler) RemovePlugin(c *gin.Context) {
 	})
 }
 
+// defaultPluginLogsLimit 默认返回的插件日志条数
+const defaultPluginLogsLimit = 100
+
+// PluginLogsResponse 插件日志响应
+type PluginLogsResponse struct {
+	// Success 是否成功
+	Success bool `json:"success"`
+	
+	// Message 消息
+	Message string `json:"message"`
+	
+	// Plugin 插件名称
+	Plugin string `json:"plugin,omitempty"`
+	
+	// Logs 日志条目，按时间顺序排列
+	Logs []plugin.LogEntry `json:"logs"`
+}
+
+// GetPluginLogs 获取插件最近的运行日志
+// 查询参数limit指定最大条数，默认100，0表示缓冲区内全部日志
+// c: Gin上下文
+func (h *PluginHandler) GetPluginLogs(c *gin.Context) {
+	name := c.Param("name")
+	
+	limit := defaultPluginLogsLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, PluginLogsResponse{
+				Success: false,
+				Message: "无效的limit参数: " + value,
+			})
+			return
+		}
+		limit = parsed
+	}
+	
+	if _, exists := h.pluginManager.GetPlugin(name); !exists {
+		c.JSON(http.StatusNotFound, PluginLogsResponse{
+			Success: false,
+			Message: "插件不存在: " + name,
+		})
+		return
+	}
+	
+	logs, err := h.pluginManager.GetPluginLogs(name, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, PluginLogsResponse{
+			Success: false,
+			Message: "获取插件日志失败: " + err.Error(),
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, PluginLogsResponse{
+		Success: true,
+		Message: "获取插件日志成功",
+		Plugin:  name,
+		Logs:    logs,
+	})
+}
+
+// RollbackPluginResponse 回滚插件响应
+type RollbackPluginResponse struct {
+	// Success 是否成功
+	Success bool `json:"success"`
+	
+	// Message 消息
+	Message string `json:"message"`
+	
+	// Plugin 插件名称
+	Plugin string `json:"plugin,omitempty"`
+	
+	// Version 回滚后的版本
+	Version string `json:"version,omitempty"`
+}
+
+// RollbackPlugin 将插件回滚到上一版本并重新加载
+// 用于新版本未通过就绪检查或上线后出现问题的情况
+// c: Gin上下文
+func (h *PluginHandler) RollbackPlugin(c *gin.Context) {
+	name := c.Param("name")
+	
+	h.logger.Info("收到插件回滚请求", zap.String("name", name))
+	
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+	
+	p, err := h.pluginManager.RollbackPlugin(ctx, name)
+	if err != nil {
+		h.logger.Error("回滚插件失败", 
+			zap.String("name", name),
+			zap.Error(err))
+		c.JSON(pluginErrorStatus(err), RollbackPluginResponse{
+			Success: false,
+			Message: "回滚插件失败: " + err.Error(),
+			Plugin:  name,
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, RollbackPluginResponse{
+		Success: true,
+		Message: "插件回滚成功",
+		Plugin:  p.GetName(),
+		Version: p.GetVersion(),
+	})
+}
+
+// PluginDetailResponse 插件详情和生命周期操作响应
+type PluginDetailResponse struct {
+	// Success 是否成功
+	Success bool `json:"success"`
+	
+	// Message 消息
+	Message string `json:"message"`
+	
+	// Plugin 插件详情，包括状态、元数据和健康状态
+	Plugin *plugin.PluginDetail `json:"plugin,omitempty"`
+	
+	// Errors 插件配置的字段错误（仅在配置不满足插件的配置模式时返回）
+	Errors []plugin.ConfigFieldError `json:"errors,omitempty"`
+}
+
+// GetPlugin 获取插件的生命周期状态、元数据和健康状态
+// c: Gin上下文
+func (h *PluginHandler) GetPlugin(c *gin.Context) {
+	name := c.Param("name")
+	
+	detail, err := h.pluginManager.PluginDetail(name)
+	if err != nil {
+		c.JSON(pluginErrorStatus(err), PluginDetailResponse{
+			Success: false,
+			Message: "获取插件详情失败: " + err.Error(),
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, PluginDetailResponse{
+		Success: true,
+		Message: "获取插件详情成功",
+		Plugin:  detail,
+	})
+}
+
+// StartPlugin 启动插件
+// 已停止或失败的插件重新初始化，未加载的插件从已安装的包加载
+// c: Gin上下文
+func (h *PluginHandler) StartPlugin(c *gin.Context) {
+	h.changeState(c, "启动", func(ctx context.Context, name string) error {
+		_, err := h.pluginManager.StartPlugin(ctx, name)
+		return err
+	})
+}
+
+// StopPlugin 停止插件，插件保持加载，可再次启动
+// c: Gin上下文
+func (h *PluginHandler) StopPlugin(c *gin.Context) {
+	h.changeState(c, "停止", h.pluginManager.StopPlugin)
+}
+
+// RestartPlugin 重启插件
+// c: Gin上下文
+func (h *PluginHandler) RestartPlugin(c *gin.Context) {
+	h.changeState(c, "重启", h.pluginManager.RestartPlugin)
+}
+
+// changeState 执行插件生命周期操作并返回操作后的插件详情
+// c: Gin上下文
+// action: 操作名称，用于日志和响应消息
+// apply: 生命周期操作
+func (h *PluginHandler) changeState(c *gin.Context, action string, apply func(ctx context.Context, name string) error) {
+	name := c.Param("name")
+	
+	h.logger.Info("收到插件"+action+"请求", zap.String("name", name))
+	
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+	
+	if err := apply(ctx, name); err != nil {
+		h.logger.Error(action+"插件失败", 
+			zap.String("name", name),
+			zap.Error(err))
+		detail, _ := h.pluginManager.PluginDetail(name)
+		c.JSON(pluginErrorStatus(err), PluginDetailResponse{
+			Success: false,
+			Message: action + "插件失败: " + err.Error(),
+			Plugin:  detail,
+			Errors:  configFieldErrors(err),
+		})
+		return
+	}
+	
+	detail, err := h.pluginManager.PluginDetail(name)
+	if err != nil {
+		c.JSON(pluginErrorStatus(err), PluginDetailResponse{
+			Success: false,
+			Message: "获取插件详情失败: " + err.Error(),
+		})
+		return
+	}
+	
+	c.JSON(http.StatusOK, PluginDetailResponse{
+		Success: true,
+		Message: "插件" + action + "成功",
+		Plugin:  detail,
+	})
+}
+
 // RegisterRoutes 注册插件API路由
+// 安装插件会在网关主机上运行插件代码，日志和详情可能包含敏感信息，所有路由都需要由auth限制访问
 // router: Gin路由器
-func (h *PluginHandler) RegisterRoutes(router *gin.Engine) {
-	api := router.Group("/api/v1/plugins")
+// auth: 认证和授权中间件
+func (h *PluginHandler) RegisterRoutes(router *gin.Engine, auth ...gin.HandlerFunc) {
+	api := router.Group("/api/v1/plugins", auth...)
 	{
 		// 安装插件
 		api.POST("/install", h.InstallPlugin)
 		
+		// 上传并安装插件
+		api.POST("/upload", h.UploadPlugin)
+		
 		// 列出已安装的插件
 		api.GET("/installed", h.ListInstalledPlugins)
 		
+		// 列出插件仓库中可用的插件
+		api.GET("/available", h.ListAvailablePlugins)
+		
 		// 移除插件
 		api.DELETE("/remove", h.RemovePlugin)
+		
+		// 获取插件运行日志
+		api.GET("/:name/logs", h.GetPluginLogs)
+		
+		// 回滚插件到上一版本
+		api.POST("/:name/rollback", h.RollbackPlugin)
+		
+		// 获取插件状态、元数据和健康状态
+		api.GET("/:name", h.GetPlugin)
+		
+		// 启动、停止和重启插件
+		api.POST("/:name/start", h.StartPlugin)
+		api.POST("/:name/stop", h.StopPlugin)
+		api.POST("/:name/restart", h.RestartPlugin)
 	}
 }
\ No newline at end of file
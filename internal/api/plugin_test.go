@@ -0,0 +1,371 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vera-byte/vgo-gateway/internal/plugin"
+	"go.uber.org/zap"
+)
+
+// testPlugin 只提供元数据和日志的测试插件
+type testPlugin struct {
+	// metadata 插件元数据
+	metadata plugin.PluginMetadata
+
+	// logs 插件日志，nil表示不提供日志
+	logs *plugin.LogBuffer
+}
+
+func (p *testPlugin) GetName() string        { return p.metadata.Name }
+func (p *testPlugin) GetVersion() string     { return p.metadata.Version }
+func (p *testPlugin) GetDescription() string { return "" }
+func (p *testPlugin) Initialize(ctx context.Context, logger *zap.Logger, config interface{}) error {
+	return nil
+}
+func (p *testPlugin) RegisterRoutes(router *gin.Engine) error           { return nil }
+func (p *testPlugin) Health() (map[string]interface{}, error)           { return nil, nil }
+func (p *testPlugin) Shutdown(ctx context.Context) error                { return nil }
+func (p *testPlugin) GetMetadata() *plugin.PluginMetadata               { return &p.metadata }
+func (p *testPlugin) CanRunStandalone() bool                            { return false }
+func (p *testPlugin) RunStandalone(ctx context.Context, port int) error { return nil }
+
+// loggingTestPlugin 提供运行日志的测试插件
+type loggingTestPlugin struct {
+	testPlugin
+}
+
+func (p *loggingTestPlugin) Logs(limit int) []plugin.LogEntry { return p.logs.Entries(limit) }
+
+// newTestPluginRouter 创建注册了插件API路由的路由器
+// 返回: 路由器和插件管理器
+func newTestPluginRouter(t *testing.T) (*gin.Engine, *plugin.Manager) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	manager := plugin.NewManager(zap.NewNop(), t.TempDir())
+	router := gin.New()
+	NewPluginHandler(manager, zap.NewNop()).RegisterRoutes(router)
+	return router, manager
+}
+
+// serve 向路由器发送请求
+// 返回: 响应记录
+func serve(router http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetPluginLogs(t *testing.T) {
+	router, manager := newTestPluginRouter(t)
+	logs := plugin.NewLogBuffer(10)
+	for _, message := range []string{"a", "b", "c"} {
+		logs.Add(plugin.LogEntry{Stream: plugin.StreamStdout, Message: message})
+	}
+	for _, p := range []plugin.Plugin{
+		&loggingTestPlugin{testPlugin{metadata: plugin.PluginMetadata{Name: "logged", Version: "1.0.0"}, logs: logs}},
+		&testPlugin{metadata: plugin.PluginMetadata{Name: "silent", Version: "1.0.0"}},
+	} {
+		if err := manager.RegisterPlugin(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		target       string
+		wantStatus   int
+		wantMessages []string
+	}{
+		{name: "default limit", target: "/api/v1/plugins/logged/logs", wantStatus: http.StatusOK, wantMessages: []string{"a", "b", "c"}},
+		{name: "limit", target: "/api/v1/plugins/logged/logs?limit=2", wantStatus: http.StatusOK, wantMessages: []string{"b", "c"}},
+		{name: "zero limit returns all", target: "/api/v1/plugins/logged/logs?limit=0", wantStatus: http.StatusOK, wantMessages: []string{"a", "b", "c"}},
+		{name: "invalid limit", target: "/api/v1/plugins/logged/logs?limit=-1", wantStatus: http.StatusBadRequest},
+		{name: "no logs", target: "/api/v1/plugins/silent/logs", wantStatus: http.StatusBadRequest},
+		{name: "unknown plugin", target: "/api/v1/plugins/missing/logs", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+
+			var resp PluginLogsResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Success != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("success = %v", resp.Success)
+			}
+			var messages []string
+			for _, entry := range resp.Logs {
+				messages = append(messages, entry.Message)
+			}
+			if len(messages) != len(tt.wantMessages) {
+				t.Fatalf("logs = %q, want %q", messages, tt.wantMessages)
+			}
+			for i := range messages {
+				if messages[i] != tt.wantMessages[i] {
+					t.Fatalf("logs = %q, want %q", messages, tt.wantMessages)
+				}
+			}
+		})
+	}
+}
+
+// emptySHA256 空内容的SHA-256
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func TestInstallPluginDownloadErrors(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not a real package"))
+	}))
+	defer upstream.Close()
+	packageURL := upstream.URL + "/demo_linux_amd64_v1.0.0.vkp"
+
+	tests := []struct {
+		name       string
+		body       string
+		maxSize    int64
+		wantStatus int
+	}{
+		{name: "malformed request", body: `{"url":`, wantStatus: http.StatusBadRequest},
+		{name: "url and name", body: `{"url":"` + packageURL + `","name":"demo"}`, wantStatus: http.StatusBadRequest},
+		{name: "malformed checksum", body: `{"url":"` + packageURL + `","sha256":"abc"}`, wantStatus: http.StatusBadRequest},
+		{name: "malformed filename", body: `{"url":"` + upstream.URL + `/demo.vkp"}`, wantStatus: http.StatusBadRequest},
+		{name: "checksum mismatch", body: `{"url":"` + packageURL + `","sha256":"` + emptySHA256 + `"}`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "too large", body: `{"url":"` + packageURL + `"}`, maxSize: 4, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, manager := newTestPluginRouter(t)
+			manager.SetMaxDownloadSize(tt.maxSize)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/plugins/install", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := serve(router, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+
+			var resp InstallPluginResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Success {
+				t.Fatalf("response = %s, %v", w.Body.String(), err)
+			}
+			installed, err := manager.ListInstalledPlugins()
+			if err != nil || len(installed) != 0 {
+				t.Fatalf("installed = %q, %v", installed, err)
+			}
+		})
+	}
+}
+
+// testPackage 生成只包含入口文件的VKP包
+// name: 插件名称
+// version: 插件版本
+// 返回: 包内容和符合命名格式的文件名
+func testPackage(t *testing.T, name, version string) ([]byte, string) {
+	t.Helper()
+
+	entry := filepath.Join(t.TempDir(), "plugin")
+	if err := os.WriteFile(entry, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := []plugin.PackageFile{{Source: entry, Path: "plugin"}}
+	platform := plugin.CurrentPlatform()
+	manifest, err := plugin.BuildManifest(&plugin.PluginMetadata{Name: name, Version: version}, "plugin", platform, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := plugin.WriteVKP(&buf, manifest, files, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), name + "_" + platform.OS + "_" + platform.Arch + "_v" + version + ".vkp"
+}
+
+// uploadRequest 创建上传插件的multipart请求
+// filename: 文件名，为空时不包含file字段
+// data: 文件内容
+// fields: 其他表单字段
+// 返回: HTTP请求
+func uploadRequest(t *testing.T, filename string, data []byte, fields map[string]string) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for key, value := range fields {
+		form.WriteField(key, value)
+	}
+	if filename != "" {
+		part, err := form.CreateFormFile("file", filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(data)
+	}
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/plugins/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestUploadPlugin(t *testing.T) {
+	data, filename := testPackage(t, "demo", "1.0.0")
+	platform := plugin.CurrentPlatform()
+
+	tests := []struct {
+		name          string
+		filename      string
+		data          []byte
+		fields        map[string]string
+		maxSize       int64
+		wantStatus    int
+		wantInstalled []string
+	}{
+		{name: "install", filename: filename, data: data, wantStatus: http.StatusOK, wantInstalled: []string{filename}},
+		{name: "missing file", wantStatus: http.StatusBadRequest},
+		{name: "invalid auto_load", filename: filename, data: data, fields: map[string]string{"auto_load": "maybe"}, wantStatus: http.StatusBadRequest},
+		{name: "invalid force", filename: filename, data: data, fields: map[string]string{"force": "2"}, wantStatus: http.StatusBadRequest},
+		{name: "malformed filename", filename: "demo.vkp", data: data, wantStatus: http.StatusBadRequest},
+		{name: "path in filename", filename: "../" + filename, data: data, wantStatus: http.StatusOK, wantInstalled: []string{filename}},
+		{
+			name:       "filename disagrees with manifest",
+			filename:   "other_" + platform.OS + "_" + platform.Arch + "_v1.0.0.vkp",
+			data:       data,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{name: "file over limit", filename: filename, data: data, maxSize: int64(len(data) - 1), wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "body over limit", filename: filename, data: bytes.Repeat([]byte("x"), 2<<20), maxSize: 1024, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, manager := newTestPluginRouter(t)
+			manager.SetMaxDownloadSize(tt.maxSize)
+
+			w := serve(router, uploadRequest(t, tt.filename, tt.data, tt.fields))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			var resp InstallPluginResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Success != (tt.wantStatus == http.StatusOK) {
+				t.Fatalf("response = %s, %v", w.Body.String(), err)
+			}
+
+			installed, err := manager.ListInstalledPlugins()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, path := range installed {
+				names = append(names, filepath.Base(path))
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantInstalled, ",") {
+				t.Fatalf("installed = %q, want %q", names, tt.wantInstalled)
+			}
+		})
+	}
+}
+
+func TestPluginRoutesRequireAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	deny := func(c *gin.Context) { c.AbortWithStatus(http.StatusUnauthorized) }
+	NewPluginHandler(plugin.NewManager(zap.NewNop(), t.TempDir()), zap.NewNop()).RegisterRoutes(router, deny)
+
+	data, filename := testPackage(t, "demo", "1.0.0")
+	for _, req := range []*http.Request{
+		uploadRequest(t, filename, data, nil),
+		httptest.NewRequest(http.MethodPost, "/api/v1/plugins/install", strings.NewReader(`{"url":"http://example.com/`+filename+`"}`)),
+		httptest.NewRequest(http.MethodGet, "/api/v1/plugins/installed", nil),
+		httptest.NewRequest(http.MethodDelete, "/api/v1/plugins/remove?filename="+filename, nil),
+	} {
+		if w := serve(router, req); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s status = %d, want %d", req.Method, req.URL.Path, w.Code, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestUploadDowngradeAndRollback(t *testing.T) {
+	router, manager := newTestPluginRouter(t)
+	manager.SetLoader(plugin.NewVKPLoader(t.TempDir(), zap.NewNop()))
+
+	newer, newerName := testPackage(t, "demo", "1.1.0")
+	older, olderName := testPackage(t, "demo", "1.0.0")
+	rollback := func() *httptest.ResponseRecorder {
+		return serve(router, httptest.NewRequest(http.MethodPost, "/api/v1/plugins/demo/rollback", nil))
+	}
+
+	// 没有历史版本时回滚返回404
+	if w := rollback(); w.Code != http.StatusNotFound {
+		t.Fatalf("rollback without archive: status = %d, want 404: %s", w.Code, w.Body.String())
+	}
+
+	steps := []struct {
+		name       string
+		filename   string
+		data       []byte
+		fields     map[string]string
+		wantStatus int
+	}{
+		{name: "install newer", filename: newerName, data: newer, wantStatus: http.StatusOK},
+		{name: "downgrade refused", filename: olderName, data: older, wantStatus: http.StatusConflict},
+		{name: "forced downgrade", filename: olderName, data: older, fields: map[string]string{"force": "true"}, wantStatus: http.StatusOK},
+	}
+	for _, step := range steps {
+		if w := serve(router, uploadRequest(t, step.filename, step.data, step.fields)); w.Code != step.wantStatus {
+			t.Fatalf("%s: status = %d, want %d: %s", step.name, w.Code, step.wantStatus, w.Body.String())
+		}
+	}
+
+	installed, err := manager.ListInstalledPlugins()
+	if err != nil || len(installed) != 1 || filepath.Base(installed[0]) != olderName {
+		t.Fatalf("installed = %q, %v, want %s", installed, err, olderName)
+	}
+}
+
+// testLoader 按包内清单创建testPlugin的加载器，不启动子进程
+type testLoader struct{}
+
+func (testLoader) LoadPlugin(path string) (plugin.Plugin, error) {
+	manifest, _, err := plugin.ReadManifestFromVKP(path)
+	if err != nil {
+		return nil, err
+	}
+	return &testPlugin{metadata: manifest.Plugin}, nil
+}
+func (testLoader) UnloadPlugin(name string) error              { return nil }
+func (testLoader) ListPlugins() []string                       { return nil }
+func (testLoader) GetPlugin(name string) (plugin.Plugin, bool) { return nil, false }
+
+func TestUploadPluginShadowedByStaticRoute(t *testing.T) {
+	router, manager := newTestPluginRouter(t)
+	manager.SetLoader(testLoader{})
+	if err := manager.RegisterAllRoutes(router); err != nil {
+		t.Fatal(err)
+	}
+
+	// 名为plugins的插件会被插件管理API的静态路由遮蔽
+	data, filename := testPackage(t, "plugins", "1.0.0")
+	w := serve(router, uploadRequest(t, filename, data, map[string]string{"auto_load": "true"}))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409: %s", w.Code, w.Body.String())
+	}
+
+	data, filename = testPackage(t, "demo", "1.0.0")
+	if w := serve(router, uploadRequest(t, filename, data, map[string]string{"auto_load": "true"})); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+}
@@ -6,12 +6,14 @@ import (
 
 // Config 应用配置结构
 type Config struct {
-	Server    ServerConfig           `mapstructure:"server" json:"server"`
-	IAM       IAMConfig              `mapstructure:"iam" json:"iam"`
-	JWT       JWTConfig              `mapstructure:"jwt" json:"jwt"`
-	Log       LogConfig              `mapstructure:"log" json:"log"`
-	RateLimit RateLimitConfig        `mapstructure:"ratelimit" json:"ratelimit"`
-	Modules   map[string]interface{} `mapstructure:"modules" json:"modules"`
+	Server      ServerConfig           `mapstructure:"server" json:"server"`
+	IAM         IAMConfig              `mapstructure:"iam" json:"iam"`
+	JWT         JWTConfig              `mapstructure:"jwt" json:"jwt"`
+	Log         LogConfig              `mapstructure:"log" json:"log"`
+	RateLimit   RateLimitConfig        `mapstructure:"ratelimit" json:"ratelimit"`
+	Plugins     PluginsConfig          `mapstructure:"plugins" json:"plugins"`
+	HealthCheck HealthCheckConfig      `mapstructure:"health_check" json:"health_check"` // 模块和插件的后台健康检查配置
+	Modules     map[string]interface{} `mapstructure:"modules" json:"modules"`
 }
 
 // ServerConfig 服务器配置
@@ -50,6 +52,184 @@ type RateLimitConfig struct {
 	Expiration int    `mapstructure:"expiration" json:"expiration"` // 过期时间（秒）
 }
 
+// PluginsConfig 插件配置
+type PluginsConfig struct {
+	PluginRuntimeConfig `mapstructure:",squash"`
+	Overrides           map[string]PluginRuntimeConfig `mapstructure:"overrides" json:"overrides,omitempty"`       // 按插件名称覆盖的运行时配置
+	Signature           SignatureConfig                `mapstructure:"signature" json:"signature"`                 // VKP包签名校验配置
+	Registry            RegistryConfig                 `mapstructure:"registry" json:"registry"`                   // 插件仓库配置
+	MaxDownloadSize     int64                          `mapstructure:"max_download_size" json:"max_download_size"` // 安装插件包的大小上限（MiB），适用于URL下载、上传和本地文件
+	ArchiveVersions     int                            `mapstructure:"archive_versions" json:"archive_versions"`   // 每个服务保留的历史版本数，用于回滚，0表示不保留
+	DrainTimeout        int                            `mapstructure:"drain_timeout" json:"drain_timeout"`         // 卸载或升级插件时等待进行中请求完成的时间（秒）
+}
+
+// SignatureConfig VKP包签名校验配置
+type SignatureConfig struct {
+	Policy          string   `mapstructure:"policy" json:"policy"`                       // reject、warn 或 allow-unsigned
+	TrustedKeys     []string `mapstructure:"trusted_keys" json:"trusted_keys"`           // 受信任的Ed25519公钥（base64或PEM）
+	TrustedKeyFiles []string `mapstructure:"trusted_key_files" json:"trusted_key_files"` // 受信任的公钥文件路径
+}
+
+// RegistryConfig 插件仓库配置
+type RegistryConfig struct {
+	URL string `mapstructure:"url" json:"url"` // 仓库索引地址（http、https或file），为空时不能按名称安装插件
+}
+
+// PluginRuntimeConfig 插件运行时配置
+type PluginRuntimeConfig struct {
+	Network       string          `mapstructure:"network" json:"network"`                 // 与插件子进程通信方式: tcp 或 unix
+	ReadyTimeout  int             `mapstructure:"ready_timeout" json:"ready_timeout"`     // 等待插件就绪的超时时间（秒）
+	StopTimeout   int             `mapstructure:"stop_timeout" json:"stop_timeout"`       // 等待插件优雅退出的时间（秒），超时后强制结束
+	LogBufferSize int             `mapstructure:"log_buffer_size" json:"log_buffer_size"` // 内存中保留的插件日志条数
+	Restart       RestartConfig   `mapstructure:"restart" json:"restart"`
+	Isolation     IsolationConfig `mapstructure:"isolation" json:"isolation"`
+}
+
+// RestartConfig 插件进程重启策略配置
+type RestartConfig struct {
+	Policy         string `mapstructure:"policy" json:"policy"`                   // never、on-failure 或 always
+	MaxRestarts    int    `mapstructure:"max_restarts" json:"max_restarts"`       // 最大连续重启次数，0或负数表示不限制
+	InitialBackoff int    `mapstructure:"initial_backoff" json:"initial_backoff"` // 首次重启前等待时间（秒）
+	MaxBackoff     int    `mapstructure:"max_backoff" json:"max_backoff"`         // 指数退避上限（秒）
+}
+
+// IsolationConfig 插件进程资源限制和隔离配置
+type IsolationConfig struct {
+	MemoryLimit  int64    `mapstructure:"memory_limit" json:"memory_limit"`     // 内存上限（MiB），0表示不限制
+	CPULimit     float64  `mapstructure:"cpu_limit" json:"cpu_limit"`           // CPU上限（核数），0表示不限制，需要cgroup v2
+	MaxOpenFiles uint64   `mapstructure:"max_open_files" json:"max_open_files"` // 打开文件数上限，0表示不限制
+	MaxProcesses uint64   `mapstructure:"max_processes" json:"max_processes"`   // 进程和线程数上限，0表示不限制
+	CgroupRoot   string   `mapstructure:"cgroup_root" json:"cgroup_root"`       // 网关可写的cgroup v2目录，为空时只使用rlimit
+	WorkDir      string   `mapstructure:"work_dir" json:"work_dir"`             // 工作目录的根目录，插件以其下的<插件名>目录为工作目录
+	InheritEnv   bool     `mapstructure:"inherit_env" json:"inherit_env"`       // 是否继承网关的全部环境变量
+	Env          []string `mapstructure:"env" json:"env"`                       // 额外传给插件的环境变量，NAME 或 NAME=value
+	User         string   `mapstructure:"user" json:"user"`                     // 运行插件进程的用户（名称或uid）
+	Group        string   `mapstructure:"group" json:"group"`                   // 运行插件进程的用户组（名称或gid）
+}
+
+// RuntimeFor 获取指定插件的运行时配置
+// 覆盖项中的非零值优先于全局配置
+// name: 插件名称
+// 返回值: PluginRuntimeConfig 合并后的运行时配置
+func (c PluginsConfig) RuntimeFor(name string) PluginRuntimeConfig {
+	merged := c.PluginRuntimeConfig
+	override, ok := c.Overrides[name]
+	if !ok {
+		return merged
+	}
+
+	if override.Network != "" {
+		merged.Network = override.Network
+	}
+	if override.ReadyTimeout > 0 {
+		merged.ReadyTimeout = override.ReadyTimeout
+	}
+	if override.StopTimeout > 0 {
+		merged.StopTimeout = override.StopTimeout
+	}
+	if override.LogBufferSize > 0 {
+		merged.LogBufferSize = override.LogBufferSize
+	}
+	if override.Restart.Policy != "" {
+		merged.Restart.Policy = override.Restart.Policy
+	}
+	if override.Restart.MaxRestarts != 0 {
+		merged.Restart.MaxRestarts = override.Restart.MaxRestarts
+	}
+	if override.Restart.InitialBackoff > 0 {
+		merged.Restart.InitialBackoff = override.Restart.InitialBackoff
+	}
+	if override.Restart.MaxBackoff > 0 {
+		merged.Restart.MaxBackoff = override.Restart.MaxBackoff
+	}
+
+	isolation := override.Isolation
+	if isolation.MemoryLimit > 0 {
+		merged.Isolation.MemoryLimit = isolation.MemoryLimit
+	}
+	if isolation.CPULimit > 0 {
+		merged.Isolation.CPULimit = isolation.CPULimit
+	}
+	if isolation.MaxOpenFiles > 0 {
+		merged.Isolation.MaxOpenFiles = isolation.MaxOpenFiles
+	}
+	if isolation.MaxProcesses > 0 {
+		merged.Isolation.MaxProcesses = isolation.MaxProcesses
+	}
+	if isolation.CgroupRoot != "" {
+		merged.Isolation.CgroupRoot = isolation.CgroupRoot
+	}
+	if isolation.WorkDir != "" {
+		merged.Isolation.WorkDir = isolation.WorkDir
+	}
+	if isolation.InheritEnv {
+		merged.Isolation.InheritEnv = true
+	}
+	if len(isolation.Env) > 0 {
+		merged.Isolation.Env = append(append([]string(nil), merged.Isolation.Env...), isolation.Env...)
+	}
+	if isolation.User != "" {
+		merged.Isolation.User = isolation.User
+	}
+	if isolation.Group != "" {
+		merged.Isolation.Group = isolation.Group
+	}
+	return merged
+}
+
+// PluginConfigs 获取传给插件的配置
+// modules 中的配置项去掉enabled、dependencies、load_order和health_check等由网关解释的配置项
+// 返回值: map[string]interface{} 按插件名称索引的配置
+func (c *Config) PluginConfigs() map[string]interface{} {
+	configs := make(map[string]interface{}, len(c.Modules))
+	for name, raw := range c.Modules {
+		configs[name] = StripModuleSettings(raw)
+	}
+	return configs
+}
+
+// HealthCheckFor 获取指定模块或插件的健康检查配置
+// modules.<名称>.health_check 中出现的配置项覆盖全局配置
+// name: 模块或插件名称
+// 返回值: HealthCheckConfig 合并后的健康检查配置
+func (c *Config) HealthCheckFor(name string) HealthCheckConfig {
+	merged := c.HealthCheck
+	moduleConfig, _ := c.Modules[name].(map[string]interface{})
+	override, ok := moduleConfig["health_check"].(map[string]interface{})
+	if !ok {
+		return merged
+	}
+
+	if enabled, ok := override["enabled"].(bool); ok {
+		merged.Enabled = enabled
+	}
+	if interval, ok := intValue(override["interval"]); ok && interval > 0 {
+		merged.Interval = interval
+	}
+	if timeout, ok := intValue(override["timeout"]); ok && timeout > 0 {
+		merged.Timeout = timeout
+	}
+	if retries, ok := intValue(override["retries"]); ok && retries >= 0 {
+		merged.Retries = retries
+	}
+	return merged
+}
+
+// intValue 读取配置中的整数，JSON配置中的数字为float64
+// v: 配置值
+// 返回值: int 整数值, bool 是否为数字
+func intValue(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
+
 // Load 加载配置文件
 // 返回值: *Config 配置对象, error 错误信息
 func Load() (*Config, error) {
@@ -73,6 +253,23 @@ func Load() (*Config, error) {
 	viper.SetDefault("ratelimit.rate", 100)
 	viper.SetDefault("ratelimit.burst", 200)
 	viper.SetDefault("ratelimit.expiration", 60)
+	viper.SetDefault("plugins.network", "tcp")
+	viper.SetDefault("plugins.ready_timeout", 30)
+	viper.SetDefault("plugins.stop_timeout", 10)
+	viper.SetDefault("plugins.log_buffer_size", 1000)
+	viper.SetDefault("plugins.restart.policy", "on-failure")
+	viper.SetDefault("plugins.restart.max_restarts", 5)
+	viper.SetDefault("plugins.restart.initial_backoff", 1)
+	viper.SetDefault("plugins.restart.max_backoff", 30)
+	viper.SetDefault("plugins.signature.policy", "warn")
+	viper.SetDefault("plugins.max_download_size", 512)
+	viper.SetDefault("plugins.archive_versions", 3)
+	viper.SetDefault("plugins.drain_timeout", 30)
+	viper.SetDefault("plugins.isolation.work_dir", "plugins/data")
+	viper.SetDefault("health_check.enabled", true)
+	viper.SetDefault("health_check.interval", 30)
+	viper.SetDefault("health_check.timeout", 10)
+	viper.SetDefault("health_check.retries", 3)
 
 	// 读取环境变量
 	viper.AutomaticEnv()
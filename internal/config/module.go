@@ -37,19 +37,51 @@ type ModuleConfig struct {
 	HealthCheck *HealthCheckConfig `json:"health_check,omitempty" yaml:"health_check,omitempty"`
 }
 
+// moduleSettingKeys modules.<名称> 中由网关解释的配置项，与模块或插件自身的配置写在同一层时不属于后者
+var moduleSettingKeys = map[string]bool{
+	"enabled":      true,
+	"dependencies": true,
+	"load_order":   true,
+	"health_check": true,
+}
+
+// IsModuleSettingKey 判断 modules.<名称> 中的配置项是否由网关解释
+// key: 配置项名称
+// 返回: 是否由网关解释
+func IsModuleSettingKey(key string) bool {
+	return moduleSettingKeys[key]
+}
+
+// StripModuleSettings 去掉 modules.<名称> 中由网关解释的配置项，剩余部分为模块或插件自身的配置
+// raw: 配置项
+// 返回: 配置项是映射时返回去掉这些配置项的副本，否则原样返回
+func StripModuleSettings(raw interface{}) interface{} {
+	configMap, ok := raw.(map[string]interface{})
+	if !ok {
+		return raw
+	}
+	stripped := make(map[string]interface{}, len(configMap))
+	for key, value := range configMap {
+		if !IsModuleSettingKey(key) {
+			stripped[key] = value
+		}
+	}
+	return stripped
+}
+
 // HealthCheckConfig 健康检查配置
 type HealthCheckConfig struct {
 	// Enabled 是否启用健康检查
-	Enabled bool `json:"enabled" yaml:"enabled"`
+	Enabled bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
 	
 	// Interval 检查间隔（秒）
-	Interval int `json:"interval" yaml:"interval"`
+	Interval int `mapstructure:"interval" json:"interval" yaml:"interval"`
 	
 	// Timeout 超时时间（秒）
-	Timeout int `json:"timeout" yaml:"timeout"`
+	Timeout int `mapstructure:"timeout" json:"timeout" yaml:"timeout"`
 	
-	// Retries 重试次数
-	Retries int `json:"retries" yaml:"retries"`
+	// Retries 连续失败多少次后判定为不健康
+	Retries int `mapstructure:"retries" json:"retries" yaml:"retries"`
 }
 
 // ModuleConfigManager 模块配置管理器
@@ -0,0 +1,89 @@
+// Package depgraph 提供模块和插件共用的依赖排序
+package depgraph
+
+import (
+	"sort"
+	"strings"
+)
+
+// Sort 按依赖关系排序，被依赖的节点排在前面
+// 同时就绪的节点按less排序，保证顺序稳定；调用方需先确认所有依赖都在names中
+// names: 节点列表
+// edges: 节点到其依赖的映射
+// less: 就绪节点的排序规则
+// 返回: 排序结果和依赖环（形如 "a -> b -> a"，没有环时为空）；存在环时排序结果只包含可以排序的部分
+func Sort(names []string, edges map[string][]string, less func(a, b string) bool) ([]string, string) {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+
+	// dependents 记录每个节点被哪些节点依赖，pending 记录尚未就绪的依赖数
+	dependents := make(map[string][]string, len(sorted))
+	pending := make(map[string]int, len(sorted))
+	for _, name := range sorted {
+		for _, dep := range edges[name] {
+			dependents[dep] = append(dependents[dep], name)
+			pending[name]++
+		}
+	}
+
+	var ready []string
+	for _, name := range sorted {
+		if pending[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	order := make([]string, 0, len(sorted))
+	for len(ready) > 0 {
+		sort.SliceStable(ready, func(i, j int) bool {
+			return less(ready[i], ready[j])
+		})
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+
+		for _, dependent := range dependents[name] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(order) < len(sorted) {
+		return order, findCycle(sorted, edges, pending)
+	}
+	return order, ""
+}
+
+// findCycle 在未能排序的节点中找出一条依赖环
+// names: 按名称排序的节点列表
+// edges: 节点到其依赖的映射
+// pending: 剩余依赖数，大于0的节点位于环上或依赖环
+// 返回: 形如 "a -> b -> a" 的依赖环
+func findCycle(names []string, edges map[string][]string, pending map[string]int) string {
+	var start string
+	for _, name := range names {
+		if pending[name] > 0 {
+			start = name
+			break
+		}
+	}
+
+	// 沿着未就绪的依赖前进，必然会回到已访问过的节点
+	visited := make(map[string]int)
+	var path []string
+	for name := start; ; {
+		if i, ok := visited[name]; ok {
+			return strings.Join(append(path[i:], name), " -> ")
+		}
+		visited[name] = len(path)
+		path = append(path, name)
+		for _, dep := range edges[name] {
+			if pending[dep] > 0 {
+				name = dep
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Kind 检查对象的类型
+type Kind string
+
+const (
+	// KindModule 内置模块
+	KindModule Kind = "module"
+
+	// KindPlugin 插件
+	KindPlugin Kind = "plugin"
+)
+
+// Status 健康状态
+type Status string
+
+const (
+	// StatusUnknown 尚未得出结论（未完成检查，或失败次数未达到阈值）
+	StatusUnknown Status = "unknown"
+
+	// StatusHealthy 最近一次检查成功
+	StatusHealthy Status = "healthy"
+
+	// StatusUnhealthy 连续失败次数达到阈值
+	StatusUnhealthy Status = "unhealthy"
+)
+
+const (
+	// schedulerTick 调度器检查到期任务的间隔，也是检查间隔的精度
+	schedulerTick = time.Second
+
+	// defaultInterval 未配置时的检查间隔
+	defaultInterval = 30 * time.Second
+
+	// defaultTimeout 未配置时单次检查的超时时间
+	defaultTimeout = 10 * time.Second
+)
+
+// Policy 健康检查策略
+type Policy struct {
+	// Enabled 是否检查该对象，未启用的对象不出现在结果中
+	Enabled bool
+
+	// Interval 检查间隔
+	Interval time.Duration
+
+	// Timeout 单次检查的超时时间
+	Timeout time.Duration
+
+	// Retries 连续失败多少次后判定为不健康，小于1时按1处理
+	Retries int
+}
+
+// CheckFunc 执行一次健康检查
+// ctx: 带超时的上下文
+// 返回: 检查对象提供的详细信息和错误信息
+type CheckFunc func(ctx context.Context) (map[string]interface{}, error)
+
+// Target 检查对象
+type Target struct {
+	// Kind 对象类型
+	Kind Kind
+
+	// Name 对象名称
+	Name string
+
+	// Check 检查函数
+	Check CheckFunc
+}
+
+// TargetSource 列出当前需要检查的对象
+// 每次调度都会调用，以便跟随模块启停、插件安装和卸载
+type TargetSource func() []Target
+
+// PolicyFunc 返回检查对象的健康检查策略
+type PolicyFunc func(kind Kind, name string) Policy
+
+// Result 缓存的检查结果
+type Result struct {
+	// Status 健康状态
+	Status Status `json:"status"`
+
+	// Error 最近一次检查失败的原因，检查成功后清空
+	Error string `json:"error,omitempty"`
+
+	// Details 最近一次检查返回的详细信息
+	Details map[string]interface{} `json:"details,omitempty"`
+
+	// ConsecutiveFailures 连续失败次数
+	ConsecutiveFailures int `json:"consecutive_failures"`
+
+	// LastCheck 最近一次检查完成的时间
+	LastCheck *time.Time `json:"last_check,omitempty"`
+
+	// LastSuccess 最近一次检查成功的时间
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+
+	// LastFailure 最近一次检查失败的时间
+	LastFailure *time.Time `json:"last_failure,omitempty"`
+
+	// Duration 最近一次检查的耗时
+	Duration string `json:"duration,omitempty"`
+}
+
+// Report 全部检查对象的健康状态
+type Report struct {
+	// Status 整体状态：有不健康的对象时为unhealthy，有未得出结论的对象时为unknown，否则为healthy
+	Status Status `json:"status"`
+
+	// Modules 各模块的检查结果
+	Modules map[string]Result `json:"modules"`
+
+	// Plugins 各插件的检查结果
+	Plugins map[string]Result `json:"plugins"`
+}
+
+// targetKey 检查对象的唯一标识
+type targetKey struct {
+	kind Kind
+	name string
+}
+
+// entry 检查对象的调度状态和缓存结果
+type entry struct {
+	result  Result
+	nextRun time.Time
+	running bool // 检查函数尚未返回，超时后仍保持为true
+}
+
+// Scheduler 在后台定期执行健康检查并缓存结果
+type Scheduler struct {
+	sources []TargetSource
+	policy  PolicyFunc
+	logger  *zap.Logger
+
+	mu      sync.RWMutex
+	entries map[targetKey]*entry
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	checks sync.WaitGroup
+}
+
+// NewScheduler 创建健康检查调度器
+// logger: 日志记录器
+// policy: 按对象返回健康检查策略
+// 返回: 调度器实例
+func NewScheduler(logger *zap.Logger, policy PolicyFunc) *Scheduler {
+	return &Scheduler{
+		policy:  policy,
+		logger:  logger,
+		entries: make(map[targetKey]*entry),
+	}
+}
+
+// AddSource 添加检查对象来源，需在Start之前调用
+// source: 检查对象来源
+func (s *Scheduler) AddSource(source TargetSource) {
+	s.sources = append(s.sources, source)
+}
+
+// Start 启动调度器，立即检查所有对象，之后按各自的间隔检查
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go s.run(ctx)
+	s.logger.Info("Health check scheduler started")
+}
+
+// Stop 停止调度器，等待进行中的检查结束（最长为检查的超时时间）
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+	s.checks.Wait()
+	s.logger.Info("Health check scheduler stopped")
+}
+
+// Report 返回缓存的检查结果，不执行检查
+// 返回: 全部检查对象的健康状态
+func (s *Scheduler) Report() Report {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	report := Report{
+		Status:  StatusHealthy,
+		Modules: make(map[string]Result),
+		Plugins: make(map[string]Result),
+	}
+	for key, e := range s.entries {
+		switch key.kind {
+		case KindModule:
+			report.Modules[key.name] = e.result
+		case KindPlugin:
+			report.Plugins[key.name] = e.result
+		}
+
+		switch e.result.Status {
+		case StatusUnhealthy:
+			report.Status = StatusUnhealthy
+		case StatusUnknown:
+			if report.Status == StatusHealthy {
+				report.Status = StatusUnknown
+			}
+		}
+	}
+	return report
+}
+
+// Handler 返回健康检查路由的处理器
+// 返回缓存的检查结果，有不健康的模块或插件时返回503
+// 返回: Gin处理函数
+func (s *Scheduler) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := s.Report()
+		status := http.StatusOK
+		if report.Status == StatusUnhealthy {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	}
+}
+
+// run 调度循环
+// ctx: 调度器的上下文，Stop时取消
+func (s *Scheduler) run(ctx context.Context) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	for {
+		s.schedule(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// schedule 同步检查对象列表，并启动到期且没有在进行中的检查
+// 已消失或停用检查的对象从缓存中删除
+// ctx: 调度器的上下文
+// now: 当前时间
+func (s *Scheduler) schedule(ctx context.Context, now time.Time) {
+	targets := make(map[targetKey]Target)
+	for _, source := range s.sources {
+		for _, target := range source() {
+			targets[targetKey{kind: target.Kind, name: target.Name}] = target
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.entries {
+		if _, exists := targets[key]; !exists {
+			delete(s.entries, key)
+		}
+	}
+
+	for key, target := range targets {
+		policy := normalizePolicy(s.policy(target.Kind, target.Name))
+		if !policy.Enabled {
+			delete(s.entries, key)
+			continue
+		}
+
+		e, exists := s.entries[key]
+		if !exists {
+			e = &entry{result: Result{Status: StatusUnknown}}
+			s.entries[key] = e
+		}
+		if e.running || now.Before(e.nextRun) {
+			continue
+		}
+
+		e.running = true
+		e.nextRun = now.Add(policy.Interval)
+		s.checks.Add(1)
+		go s.check(ctx, key, e, target.Check, policy)
+	}
+}
+
+// check 执行一次检查并记录结果
+// ctx: 调度器的上下文
+// key: 检查对象标识
+// e: 检查开始时的缓存项
+// check: 检查函数
+// policy: 健康检查策略
+func (s *Scheduler) check(ctx context.Context, key targetKey, e *entry, check CheckFunc, policy Policy) {
+	defer s.checks.Done()
+
+	checkCtx, cancel := context.WithTimeout(ctx, policy.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := runCheck(checkCtx, check, func() {
+		s.finish(e)
+	})
+	if ctx.Err() != nil {
+		// 调度器已停止，结果不再有意义
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("health check timed out after %s", policy.Timeout)
+	}
+
+	s.record(key, e, policy, start, details, err)
+}
+
+// runCheck 执行检查函数，超时后立即返回
+// 不响应上下文的检查函数（如插件的Health）会在后台继续运行直到返回
+// ctx: 带超时的上下文
+// check: 检查函数
+// finished: 检查函数返回后调用，超时时也会等到检查函数真正返回
+// 返回: 详细信息和错误信息
+func runCheck(ctx context.Context, check CheckFunc, finished func()) (map[string]interface{}, error) {
+	type outcome struct {
+		details map[string]interface{}
+		err     error
+	}
+
+	done := make(chan outcome, 1)
+	go func() {
+		details, err := check(ctx)
+		finished()
+		done <- outcome{details: details, err: err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.details, o.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// record 更新缓存结果，状态变化时记录日志
+// 不清除running，由finish在检查函数返回后清除
+// key: 检查对象标识
+// e: 检查开始时的缓存项，期间对象被删除或重新加入时丢弃结果
+// policy: 健康检查策略
+// start: 检查开始时间
+// details: 检查返回的详细信息
+// checkErr: 检查错误
+func (s *Scheduler) record(key targetKey, e *entry, policy Policy, start time.Time, details map[string]interface{}, checkErr error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.entries[key] != e {
+		return
+	}
+
+	now := time.Now()
+	result := &e.result
+	previous := result.Status
+	result.LastCheck = &now
+	result.Duration = now.Sub(start).Round(time.Millisecond).String()
+	result.Details = details
+
+	if checkErr == nil {
+		result.Status = StatusHealthy
+		result.Error = ""
+		result.ConsecutiveFailures = 0
+		result.LastSuccess = &now
+	} else {
+		result.Error = checkErr.Error()
+		result.ConsecutiveFailures++
+		result.LastFailure = &now
+		if result.ConsecutiveFailures >= policy.Retries {
+			result.Status = StatusUnhealthy
+		}
+	}
+
+	if result.Status == previous {
+		if checkErr != nil {
+			s.logger.Debug("Health check failed",
+				zap.String("kind", string(key.kind)),
+				zap.String("name", key.name),
+				zap.Int("consecutive_failures", result.ConsecutiveFailures),
+				zap.Error(checkErr))
+		}
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("kind", string(key.kind)),
+		zap.String("name", key.name),
+		zap.String("from", string(previous)),
+		zap.String("to", string(result.Status)),
+	}
+	if result.Status == StatusUnhealthy {
+		s.logger.Warn("Health status changed",
+			append(fields,
+				zap.Int("consecutive_failures", result.ConsecutiveFailures),
+				zap.Error(checkErr))...)
+		return
+	}
+	s.logger.Info("Health status changed", fields...)
+}
+
+// finish 检查函数返回后允许再次调度该对象
+// 超时的检查仍在运行时不会启动新的检查，避免无响应的对象累积检查协程
+// e: 检查开始时的缓存项
+func (s *Scheduler) finish(e *entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.running = false
+}
+
+// normalizePolicy 为未配置的间隔和超时时间使用默认值，失败阈值至少为1
+// policy: 健康检查策略
+// 返回: 补全后的策略
+func normalizePolicy(policy Policy) Policy {
+	if policy.Interval <= 0 {
+		policy.Interval = defaultInterval
+	}
+	if policy.Timeout <= 0 {
+		policy.Timeout = defaultTimeout
+	}
+	if policy.Retries < 1 {
+		policy.Retries = 1
+	}
+	return policy
+}
@@ -0,0 +1,310 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// fakeCheck 可由测试控制结果的检查函数
+type fakeCheck struct {
+	mu    sync.Mutex
+	calls int
+	err   error
+
+	// block 不为nil时检查函数在其关闭前不返回，也不响应上下文
+	block chan struct{}
+}
+
+func (f *fakeCheck) setErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func (f *fakeCheck) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func (f *fakeCheck) check(ctx context.Context) (map[string]interface{}, error) {
+	f.mu.Lock()
+	f.calls++
+	err, block := f.err, f.block
+	f.mu.Unlock()
+
+	if block != nil {
+		<-block
+	}
+	return map[string]interface{}{"calls": f.callCount()}, err
+}
+
+// newTestScheduler 创建只检查给定对象的调度器
+// targets: 返回当前检查对象的函数
+// policy: 所有对象使用的策略
+// 返回: 调度器
+func newTestScheduler(targets func() []Target, policy Policy) *Scheduler {
+	s := NewScheduler(zap.NewNop(), func(Kind, string) Policy { return policy })
+	s.AddSource(targets)
+	return s
+}
+
+// step 在now执行一次调度，并等待已返回的检查记录结果
+func step(s *Scheduler, now time.Time) {
+	s.schedule(context.Background(), now)
+	s.checks.Wait()
+}
+
+// isRunning 对象的检查函数是否尚未返回
+func isRunning(s *Scheduler, kind Kind, name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, exists := s.entries[targetKey{kind: kind, name: name}]
+	return exists && e.running
+}
+
+func TestSchedulerRetryThreshold(t *testing.T) {
+	check := &fakeCheck{}
+	s := newTestScheduler(func() []Target {
+		return []Target{{Kind: KindModule, Name: "iam", Check: check.check}}
+	}, Policy{Enabled: true, Interval: time.Minute, Timeout: time.Second, Retries: 3})
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	down := errors.New("connection refused")
+	steps := []struct {
+		name         string
+		at           time.Duration
+		err          error
+		wantCalls    int
+		wantStatus   Status
+		wantFailures int
+	}{
+		{name: "first check", at: 0, wantCalls: 1, wantStatus: StatusHealthy},
+		{name: "before interval", at: 30 * time.Second, err: down, wantCalls: 1, wantStatus: StatusHealthy},
+		{name: "first failure", at: time.Minute, err: down, wantCalls: 2, wantStatus: StatusHealthy, wantFailures: 1},
+		{name: "second failure", at: 2 * time.Minute, err: down, wantCalls: 3, wantStatus: StatusHealthy, wantFailures: 2},
+		{name: "threshold reached", at: 3 * time.Minute, err: down, wantCalls: 4, wantStatus: StatusUnhealthy, wantFailures: 3},
+		{name: "still failing", at: 4 * time.Minute, err: down, wantCalls: 5, wantStatus: StatusUnhealthy, wantFailures: 4},
+		{name: "recovered", at: 5 * time.Minute, wantCalls: 6, wantStatus: StatusHealthy},
+	}
+	for _, tt := range steps {
+		check.setErr(tt.err)
+		step(s, start.Add(tt.at))
+
+		result := s.Report().Modules["iam"]
+		if got := check.callCount(); got != tt.wantCalls {
+			t.Fatalf("%s: %d checks, want %d", tt.name, got, tt.wantCalls)
+		}
+		if result.Status != tt.wantStatus || result.ConsecutiveFailures != tt.wantFailures {
+			t.Fatalf("%s: result = %s with %d failures, want %s with %d", tt.name, result.Status, result.ConsecutiveFailures, tt.wantStatus, tt.wantFailures)
+		}
+		if (result.Error != "") != (tt.wantFailures > 0) {
+			t.Fatalf("%s: error = %q", tt.name, result.Error)
+		}
+	}
+}
+
+func TestSchedulerUnknownUntilFirstResult(t *testing.T) {
+	check := &fakeCheck{}
+	s := newTestScheduler(func() []Target {
+		return []Target{{Kind: KindPlugin, Name: "demo", Check: check.check}}
+	}, Policy{Enabled: true, Retries: 2})
+
+	check.setErr(errors.New("starting"))
+	step(s, time.Now())
+	report := s.Report()
+	if report.Status != StatusUnknown || report.Plugins["demo"].Status != StatusUnknown {
+		t.Fatalf("report after one failure below the threshold = %+v", report)
+	}
+}
+
+func TestSchedulerTimeoutKeepsRunning(t *testing.T) {
+	check := &fakeCheck{block: make(chan struct{})}
+	s := newTestScheduler(func() []Target {
+		return []Target{{Kind: KindPlugin, Name: "stuck", Check: check.check}}
+	}, Policy{Enabled: true, Interval: time.Second, Timeout: 20 * time.Millisecond, Retries: 1})
+
+	start := time.Now()
+	step(s, start)
+	result := s.Report().Plugins["stuck"]
+	if result.Status != StatusUnhealthy || !strings.Contains(result.Error, "timed out after 20ms") {
+		t.Fatalf("result after timeout = %+v", result)
+	}
+
+	// 超时的检查函数仍在运行，到期也不启动新的检查
+	if !isRunning(s, KindPlugin, "stuck") {
+		t.Fatal("timed-out check no longer marked as running")
+	}
+	step(s, start.Add(time.Minute))
+	if got := check.callCount(); got != 1 {
+		t.Fatalf("%d checks started while the first was still running", got)
+	}
+
+	// 检查函数返回后恢复调度
+	check.mu.Lock()
+	close(check.block)
+	check.block = nil
+	check.mu.Unlock()
+	deadline := time.Now().Add(time.Second)
+	for isRunning(s, KindPlugin, "stuck") {
+		if time.Now().After(deadline) {
+			t.Fatal("check still marked as running after it returned")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	step(s, start.Add(2*time.Minute))
+	if got := check.callCount(); got != 2 {
+		t.Fatalf("%d checks, want 2 after the stuck check returned", got)
+	}
+	if result := s.Report().Plugins["stuck"]; result.Status != StatusHealthy {
+		t.Fatalf("result after recovery = %+v", result)
+	}
+}
+
+func TestSchedulerDropsRemovedTargets(t *testing.T) {
+	var mu sync.Mutex
+	targets := map[string]bool{"iam": true, "audit": true}
+	disabled := map[string]bool{}
+	check := &fakeCheck{}
+
+	s := NewScheduler(zap.NewNop(), func(kind Kind, name string) Policy {
+		mu.Lock()
+		defer mu.Unlock()
+		return Policy{Enabled: !disabled[name], Interval: time.Second}
+	})
+	s.AddSource(func() []Target {
+		mu.Lock()
+		defer mu.Unlock()
+		var list []Target
+		for name := range targets {
+			list = append(list, Target{Kind: KindModule, Name: name, Check: check.check})
+		}
+		return list
+	})
+	names := func() []string {
+		var list []string
+		for _, name := range []string{"audit", "iam"} {
+			if _, ok := s.Report().Modules[name]; ok {
+				list = append(list, name)
+			}
+		}
+		return list
+	}
+
+	start := time.Now()
+	step(s, start)
+	if got := names(); len(got) != 2 {
+		t.Fatalf("modules = %q, want audit and iam", got)
+	}
+
+	mu.Lock()
+	delete(targets, "audit")
+	mu.Unlock()
+	step(s, start.Add(time.Minute))
+	if got := names(); len(got) != 1 || got[0] != "iam" {
+		t.Fatalf("modules after removing audit = %q", got)
+	}
+
+	mu.Lock()
+	disabled["iam"] = true
+	mu.Unlock()
+	step(s, start.Add(2*time.Minute))
+	if got := names(); len(got) != 0 {
+		t.Fatalf("modules after disabling checks for iam = %q", got)
+	}
+	if report := s.Report(); report.Status != StatusHealthy {
+		t.Fatalf("empty report status = %s", report.Status)
+	}
+}
+
+func TestSchedulerDiscardsResultOfRemovedTarget(t *testing.T) {
+	var mu sync.Mutex
+	present := true
+	check := &fakeCheck{block: make(chan struct{})}
+	s := newTestScheduler(func() []Target {
+		mu.Lock()
+		defer mu.Unlock()
+		if !present {
+			return nil
+		}
+		return []Target{{Kind: KindPlugin, Name: "demo", Check: check.check}}
+	}, Policy{Enabled: true, Timeout: time.Second})
+
+	start := time.Now()
+	s.schedule(context.Background(), start)
+	mu.Lock()
+	present = false
+	mu.Unlock()
+	s.schedule(context.Background(), start.Add(time.Minute))
+
+	// 检查期间对象被删除，检查返回后不会重新加入缓存
+	close(check.block)
+	s.checks.Wait()
+	if result, exists := s.Report().Plugins["demo"]; exists {
+		t.Fatalf("removed plugin reappeared with %+v", result)
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	check := &fakeCheck{}
+	s := newTestScheduler(func() []Target {
+		return []Target{
+			{Kind: KindModule, Name: "iam", Check: (&fakeCheck{}).check},
+			{Kind: KindPlugin, Name: "demo", Check: check.check},
+		}
+	}, Policy{Enabled: true, Interval: time.Second, Retries: 1})
+	router := gin.New()
+	router.GET("/health", s.Handler())
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		want       Status
+	}{
+		{name: "healthy", wantStatus: http.StatusOK, want: StatusHealthy},
+		{name: "unhealthy plugin", err: errors.New("down"), wantStatus: http.StatusServiceUnavailable, want: StatusUnhealthy},
+		{name: "recovered", wantStatus: http.StatusOK, want: StatusHealthy},
+	}
+	start := time.Now()
+	for i, tt := range tests {
+		check.setErr(tt.err)
+		step(s, start.Add(time.Duration(i)*time.Minute))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+		if w.Code != tt.wantStatus {
+			t.Fatalf("%s: status = %d, want %d", tt.name, w.Code, tt.wantStatus)
+		}
+		var report Report
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+		if report.Status != tt.want || report.Modules["iam"].Status != StatusHealthy || report.Plugins["demo"].Status != tt.want {
+			t.Fatalf("%s: report = %+v", tt.name, report)
+		}
+	}
+}
+
+func TestNormalizePolicy(t *testing.T) {
+	got := normalizePolicy(Policy{Enabled: true, Retries: -1})
+	want := Policy{Enabled: true, Interval: defaultInterval, Timeout: defaultTimeout, Retries: 1}
+	if got != want {
+		t.Fatalf("normalizePolicy() = %+v, want %+v", got, want)
+	}
+	custom := Policy{Enabled: true, Interval: time.Second, Timeout: time.Millisecond, Retries: 5}
+	if got := normalizePolicy(custom); got != custom {
+		t.Fatalf("normalizePolicy(%+v) = %+v", custom, got)
+	}
+}
@@ -3,6 +3,8 @@ package module
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -61,10 +63,29 @@ type ModuleFactory interface {
 type ModuleRegistry struct {
 	factories map[string]ModuleFactory
 	logger    *zap.Logger
+	mu        sync.RWMutex
+}
+
+// defaultRegistry 全局模块注册表，模块包在init中向其注册工厂
+var defaultRegistry = NewModuleRegistry(nil)
+
+// Register 向全局模块注册表注册模块工厂
+// 供模块包在init中调用，模块类型重复时panic
+// 参数: factory 模块工厂
+func Register(factory ModuleFactory) {
+	if err := defaultRegistry.RegisterFactory(factory.ModuleType(), factory); err != nil {
+		panic(err)
+	}
+}
+
+// DefaultRegistry 获取全局模块注册表
+// 返回值: *ModuleRegistry 全局注册表
+func DefaultRegistry() *ModuleRegistry {
+	return defaultRegistry
 }
 
 // NewModuleRegistry 创建新的模块注册表
-// 参数: logger 日志器
+// 参数: logger 日志器，可为nil
 // 返回值: *ModuleRegistry 注册表实例
 func NewModuleRegistry(logger *zap.Logger) *ModuleRegistry {
 	return &ModuleRegistry{
@@ -77,20 +98,38 @@ func NewModuleRegistry(logger *zap.Logger) *ModuleRegistry {
 // 参数: moduleType 模块类型, factory 模块工厂
 // 返回值: error 错误信息
 func (r *ModuleRegistry) RegisterFactory(moduleType string, factory ModuleFactory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
 	if _, exists := r.factories[moduleType]; exists {
 		return fmt.Errorf("module factory %s already registered", moduleType)
 	}
 	
 	r.factories[moduleType] = factory
-	r.logger.Info("Module factory registered", zap.String("type", moduleType))
+	if r.logger != nil {
+		r.logger.Info("Module factory registered", zap.String("type", moduleType))
+	}
 	return nil
 }
 
+// HasFactory 是否注册了指定类型的模块工厂
+// 参数: moduleType 模块类型
+// 返回值: bool 是否已注册
+func (r *ModuleRegistry) HasFactory(moduleType string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	
+	_, exists := r.factories[moduleType]
+	return exists
+}
+
 // CreateModule 创建模块实例
 // 参数: moduleType 模块类型
 // 返回值: BaseModule 模块实例, error 错误信息
 func (r *ModuleRegistry) CreateModule(moduleType string) (BaseModule, error) {
+	r.mu.RLock()
 	factory, exists := r.factories[moduleType]
+	r.mu.RUnlock()
 	if !exists {
 		return nil, fmt.Errorf("module factory %s not found", moduleType)
 	}
@@ -99,11 +138,15 @@ func (r *ModuleRegistry) CreateModule(moduleType string) (BaseModule, error) {
 }
 
 // ListFactories 列出所有注册的工厂
-// 返回值: []string 工厂类型列表
+// 返回值: []string 工厂类型列表，按类型排序
 func (r *ModuleRegistry) ListFactories() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	
 	types := make([]string, 0, len(r.factories))
 	for moduleType := range r.factories {
 		types = append(types, moduleType)
 	}
+	sort.Strings(types)
 	return types
 }
\ No newline at end of file
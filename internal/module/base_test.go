@@ -0,0 +1,171 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// stubFactory 创建stubModule的测试模块工厂
+type stubFactory struct {
+	moduleType string
+	recorder   *recorder
+
+	// err CreateModule返回的错误
+	err error
+}
+
+func (f *stubFactory) CreateModule() (BaseModule, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.recorder.add("create " + f.moduleType)
+	return newStubModule(f.moduleType, f.recorder), nil
+}
+
+func (f *stubFactory) ModuleType() string { return f.moduleType }
+
+func TestModuleRegistry(t *testing.T) {
+	rec := &recorder{}
+	registry := NewModuleRegistry(zap.NewNop())
+	for _, moduleType := range []string{"iam", "audit"} {
+		if err := registry.RegisterFactory(moduleType, &stubFactory{moduleType: moduleType, recorder: rec}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := registry.RegisterFactory("iam", &stubFactory{moduleType: "iam", recorder: rec}); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Fatalf("duplicate RegisterFactory() error = %v", err)
+	}
+	if got, want := registry.ListFactories(), []string{"audit", "iam"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ListFactories() = %q, want %q", got, want)
+	}
+	if !registry.HasFactory("iam") || registry.HasFactory("billing") {
+		t.Fatal("HasFactory() reported the wrong factories")
+	}
+
+	created, err := registry.CreateModule("iam")
+	if err != nil || created.Name() != "iam" {
+		t.Fatalf("CreateModule(iam) = %v, %v", created, err)
+	}
+	if _, err := registry.CreateModule("billing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("CreateModule(billing) error = %v", err)
+	}
+}
+
+func TestRegisterPanicsOnDuplicateType(t *testing.T) {
+	// 使用新的全局注册表，保证测试可重复运行
+	saved := defaultRegistry
+	defaultRegistry = NewModuleRegistry(nil)
+	t.Cleanup(func() { defaultRegistry = saved })
+
+	factory := &stubFactory{moduleType: "test-register-duplicate", recorder: &recorder{}}
+	Register(factory)
+	if !DefaultRegistry().HasFactory(factory.moduleType) {
+		t.Fatal("Register() did not add the factory to the default registry")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering the same module type twice did not panic")
+		}
+	}()
+	Register(factory)
+}
+
+func TestRegisterFromConfig(t *testing.T) {
+	createErr := errors.New("bad credentials")
+	tests := []struct {
+		name        string
+		configs     map[string]interface{}
+		failType    string
+		preRegister string
+		wantModules []string
+		wantErr     string
+	}{
+		{
+			name:        "only configured modules",
+			configs:     map[string]interface{}{"iam": map[string]interface{}{}},
+			wantModules: []string{"iam"},
+		},
+		{
+			name: "disabled and plugin entries skipped",
+			configs: map[string]interface{}{
+				"iam":         map[string]interface{}{"enabled": true},
+				"audit":       map[string]interface{}{"enabled": false},
+				"demo-plugin": map[string]interface{}{"enabled": true},
+			},
+			wantModules: []string{"iam"},
+		},
+		{
+			name:        "enabled unless disabled",
+			configs:     map[string]interface{}{"iam": nil, "audit": map[string]interface{}{"config": map[string]interface{}{"level": "info"}}},
+			wantModules: []string{"audit", "iam"},
+		},
+		{
+			name:     "factory error",
+			configs:  map[string]interface{}{"iam": nil, "audit": nil},
+			failType: "iam",
+			wantErr:  "failed to create module iam: bad credentials",
+		},
+		{
+			name:        "already registered",
+			configs:     map[string]interface{}{"iam": nil},
+			preRegister: "iam",
+			wantErr:     "module iam already registered",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{}
+			registry := NewModuleRegistry(nil)
+			for _, moduleType := range []string{"iam", "audit"} {
+				factory := &stubFactory{moduleType: moduleType, recorder: rec}
+				if moduleType == tt.failType {
+					factory.err = createErr
+				}
+				if err := registry.RegisterFactory(moduleType, factory); err != nil {
+					t.Fatal(err)
+				}
+			}
+			m := NewManager(zap.NewNop())
+			if tt.preRegister != "" {
+				m.RegisterModule(tt.preRegister, newStubModule(tt.preRegister, rec))
+			}
+
+			err := m.RegisterFromConfig(registry, tt.configs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RegisterFromConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RegisterFromConfig() error: %v", err)
+			}
+
+			var names []string
+			for _, info := range m.ListModules() {
+				names = append(names, info.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantModules) {
+				t.Fatalf("registered modules = %q, want %q", names, tt.wantModules)
+			}
+			// 只为注册的模块调用工厂
+			var created []string
+			for _, name := range tt.wantModules {
+				created = append(created, "create "+name)
+			}
+			if got := rec.list(); !reflect.DeepEqual(got, created) {
+				t.Fatalf("factory calls = %q, want %q", got, created)
+			}
+			if err := m.InitializeAll(context.Background(), tt.configs); err != nil {
+				t.Fatalf("InitializeAll() error: %v", err)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package module
 
+import "github.com/vera-byte/vgo-gateway/internal/config"
+
 // Module 模块接口定义 (为了向后兼容，继承BaseModule)
 type Module interface {
 	BaseModule
@@ -7,15 +9,63 @@ type Module interface {
 
 // ModuleInfo 模块信息
 type ModuleInfo struct {
-	Name        string                 `json:"name"`
-	Version     string                 `json:"version"`
-	Description string                 `json:"description"`
-	Enabled     bool                   `json:"enabled"`
-	Config      map[string]interface{} `json:"config,omitempty"`
+	Name         string                 `json:"name"`
+	Version      string                 `json:"version"`
+	Description  string                 `json:"description"`
+	Enabled      bool                   `json:"enabled"`
+	Dependencies []string               `json:"dependencies,omitempty"`
+	LoadOrder    int                    `json:"load_order"`
+	Config       map[string]interface{} `json:"config,omitempty"`
 }
 
-// ModuleConfig 模块配置
-type ModuleConfig struct {
-	Enabled bool                   `mapstructure:"enabled" json:"enabled"`
-	Config  map[string]interface{} `mapstructure:"config" json:"config,omitempty"`
+// ModuleConfig 模块配置，与配置文件中的模块配置使用同一结构
+type ModuleConfig = config.ModuleConfig
+
+// ParseModuleConfig 解析 modules.<模块名> 配置项
+// 支持 {enabled, config} 形式，以及enabled与模块配置写在同一层的形式（此时去掉网关解释的配置项后整个配置项传给模块）；
+// 未设置enabled时视为启用；dependencies、load_order和health_check与enabled写在同一层，两种形式都可以使用
+// raw: 配置项
+// 返回值: ModuleConfig 模块配置
+func ParseModuleConfig(raw interface{}) ModuleConfig {
+	parsed := ModuleConfig{Enabled: true, Config: make(map[string]interface{})}
+	configMap, ok := raw.(map[string]interface{})
+	if !ok {
+		return parsed
+	}
+
+	if enabled, ok := configMap["enabled"].(bool); ok {
+		parsed.Enabled = enabled
+	}
+	switch deps := configMap["dependencies"].(type) {
+	case []string:
+		parsed.Dependencies = deps
+	case []interface{}:
+		for _, dep := range deps {
+			if name, ok := dep.(string); ok {
+				parsed.Dependencies = append(parsed.Dependencies, name)
+			}
+		}
+	}
+	// 从JSON读取的配置中数字为float64
+	switch order := configMap["load_order"].(type) {
+	case int:
+		parsed.LoadOrder = order
+	case int64:
+		parsed.LoadOrder = int(order)
+	case float64:
+		parsed.LoadOrder = int(order)
+	}
+	parsed.Config = config.StripModuleSettings(configMap).(map[string]interface{})
+	if inner, ok := configMap["config"].(map[string]interface{}); ok {
+		nested := true
+		for key := range configMap {
+			if key != "config" && !config.IsModuleSettingKey(key) {
+				nested = false
+			}
+		}
+		if nested {
+			parsed.Config = inner
+		}
+	}
+	return parsed
 }
\ No newline at end of file
@@ -2,19 +2,92 @@ package module
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vera-byte/vgo-gateway/pkg/model"
 	"go.uber.org/zap"
 )
 
+// ErrModuleNotFound 模块未注册
+var ErrModuleNotFound = errors.New("module not found")
+
 // Manager 模块管理器
 type Manager struct {
-	modules map[string]Module
-	logger  *zap.Logger
-	mu      sync.RWMutex
+	modules   map[string]Module
+	settings  map[string]ModuleConfig    // 初始化时使用的模块配置，包括依赖和加载顺序，启用模块时重新使用
+	disabled  map[string]bool            // 已停用的模块
+	basePaths map[string]string          // 模块路由组的路径前缀
+	inflight  map[string]*requestTracker // 模块正在处理的请求，停用时等待其完成后再关闭模块
+	logger    *zap.Logger
+	mu        sync.RWMutex // 保护上述映射，不在模块初始化或关闭期间持有
+	ops       sync.Mutex   // 串行化模块的初始化、启用、停用和关闭
+}
+
+// moduleEntry 按顺序排列的模块快照，供在锁外调用模块方法
+type moduleEntry struct {
+	name   string
+	module Module
+	config map[string]interface{}
+}
+
+// requestTracker 模块正在处理的请求计数
+// 每次停用模块时换用新的计数器，停用前放行的请求在原计数器上完成；
+// 等待超时后不会遗留goroutine，重新启用后的请求也不会计入上一次停用等待的计数
+type requestTracker struct {
+	mu      sync.Mutex
+	active  int           // 进行中的请求数
+	retired bool          // 是否已停止接受新请求
+	idle    chan struct{} // 停止接受新请求且没有进行中的请求时关闭
+}
+
+// newRequestTracker 创建请求计数器
+// 返回值: *requestTracker 计数器实例
+func newRequestTracker() *requestTracker {
+	return &requestTracker{idle: make(chan struct{})}
+}
+
+// acquire 开始处理一个请求
+func (t *requestTracker) acquire() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active++
+}
+
+// release 结束处理一个请求
+func (t *requestTracker) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active--
+	if t.retired && t.active == 0 {
+		close(t.idle)
+	}
+}
+
+// drain 停止接受新请求并等待进行中的请求完成
+// ctx: 上下文，结束时不再等待
+// 返回值: error ctx结束时的错误
+func (t *requestTracker) drain(ctx context.Context) error {
+	t.mu.Lock()
+	if !t.retired {
+		t.retired = true
+		if t.active == 0 {
+			close(t.idle)
+		}
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-t.idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // NewManager 创建新的模块管理器
@@ -22,8 +95,12 @@ type Manager struct {
 // 返回值: *Manager 管理器实例
 func NewManager(logger *zap.Logger) *Manager {
 	return &Manager{
-		modules: make(map[string]Module),
-		logger:  logger,
+		modules:   make(map[string]Module),
+		settings:  make(map[string]ModuleConfig),
+		disabled:  make(map[string]bool),
+		basePaths: make(map[string]string),
+		inflight:  make(map[string]*requestTracker),
+		logger:    logger,
 	}
 }
 
@@ -40,10 +117,46 @@ func (m *Manager) RegisterModule(name string, module Module) error {
 	}
 
 	m.modules[name] = module
+	m.inflight[name] = newRequestTracker()
 	m.logger.Info("Module registered", zap.String("name", name))
 	return nil
 }
 
+// RegisterFromConfig 按配置从模块注册表创建并注册模块
+// 只创建configs中列出且启用的模块，配置项名称即模块类型；
+// 没有对应模块工厂的配置项视为插件配置，跳过
+// registry: 模块注册表
+// configs: 模块配置，按模块名称索引
+// 返回值: error 错误信息
+func (m *Manager) RegisterFromConfig(registry *ModuleRegistry, configs map[string]interface{}) error {
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !registry.HasFactory(name) {
+			m.logger.Debug("No module factory registered, skipping config entry", zap.String("name", name))
+			continue
+		}
+		if !ParseModuleConfig(configs[name]).Enabled {
+			m.logger.Info("Module disabled by config", zap.String("name", name))
+			continue
+		}
+
+		module, err := registry.CreateModule(name)
+		if err != nil {
+			return fmt.Errorf("failed to create module %s: %w", name, err)
+		}
+		if err := m.RegisterModule(name, module); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // UnregisterModule 注销模块
 // name: 模块名称
 // 返回值: error 错误信息
@@ -52,49 +165,107 @@ func (m *Manager) UnregisterModule(name string) error {
 	defer m.mu.Unlock()
 
 	if _, exists := m.modules[name]; !exists {
-		return fmt.Errorf("module %s not found", name)
+		return fmt.Errorf("%w: %s", ErrModuleNotFound, name)
 	}
 
 	delete(m.modules, name)
+	delete(m.settings, name)
+	delete(m.disabled, name)
+	delete(m.inflight, name)
 	m.logger.Info("Module unregistered", zap.String("name", name))
 	return nil
 }
 
-// InitializeAll 初始化所有模块
+// InitializeAll 按依赖关系和加载顺序初始化所有模块
+// 依赖缺失或存在循环依赖时不初始化任何模块；某个模块初始化失败时按相反顺序关闭已初始化的模块
+// 在锁内确定初始化顺序，初始化期间不持有管理器的锁，模块初始化时可以查询管理器
 // ctx: 上下文
 // configs: 模块配置
 // 返回值: error 错误信息
 func (m *Manager) InitializeAll(ctx context.Context, configs map[string]interface{}) error {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.ops.Lock()
+	defer m.ops.Unlock()
 
-	for name, module := range m.modules {
-		config := make(map[string]interface{})
-		if moduleConfig, exists := configs[name]; exists {
-			if configMap, ok := moduleConfig.(map[string]interface{}); ok {
-				config = configMap
+	m.mu.Lock()
+	for name := range m.modules {
+		m.settings[name] = ParseModuleConfig(configs[name])
+	}
+	order, err := resolveOrder(m.names(), m.settings)
+	entries := m.entries(order, false)
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for i, entry := range entries {
+		if err := entry.module.Initialize(ctx, entry.config, m.logger); err != nil {
+			m.logger.Error("Failed to initialize module, shutting down initialized modules",
+				zap.String("name", entry.name),
+				zap.Strings("initialized", order[:i]),
+				zap.Error(err))
+			if shutdownErr := m.shutdownModules(ctx, entries[:i]); shutdownErr != nil {
+				m.logger.Error("Failed to roll back module initialization", zap.Error(shutdownErr))
 			}
+			return fmt.Errorf("failed to initialize module %s: %w", entry.name, err)
 		}
+		m.logger.Info("Module initialized", zap.String("name", entry.name))
+	}
+
+	return nil
+}
 
-		if err := module.Initialize(ctx, config, m.logger); err != nil {
-			return fmt.Errorf("failed to initialize module %s: %w", name, err)
+// entries 按给定顺序取出模块快照
+// 调用方需持有锁
+// names: 模块名称列表
+// skipDisabled: 是否跳过已停用的模块
+// 返回值: []moduleEntry 模块快照
+func (m *Manager) entries(names []string, skipDisabled bool) []moduleEntry {
+	entries := make([]moduleEntry, 0, len(names))
+	for _, name := range names {
+		if skipDisabled && m.disabled[name] {
+			continue
 		}
-		m.logger.Info("Module initialized", zap.String("name", name))
+		entries = append(entries, moduleEntry{name: name, module: m.modules[name], config: m.settings[name].Config})
 	}
+	return entries
+}
 
-	return nil
+// names 列出已注册的模块名称，按名称排序
+// 调用方需持有锁
+// 返回值: []string 模块名称列表
+func (m *Manager) names() []string {
+	names := make([]string, 0, len(m.modules))
+	for name := range m.modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// orderedNames 按初始化顺序列出模块名称，依赖关系无效时按名称排序
+// 调用方需持有锁
+// 返回值: []string 模块名称列表
+func (m *Manager) orderedNames() []string {
+	names := m.names()
+	if order, err := resolveOrder(names, m.settings); err == nil {
+		return order
+	}
+	return names
 }
 
-// RegisterRoutes 注册所有模块的路由
+// RegisterRoutes 按初始化顺序注册所有模块的路由
+// 已停用模块的路由返回503
 // router: Gin路由组
 // logger: 日志记录器
 // 返回值: error 错误信息
 func (m *Manager) RegisterRoutes(router *gin.RouterGroup, logger *zap.Logger) error {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	for name, module := range m.modules {
-		moduleGroup := router.Group("/" + name)
+	for _, name := range m.orderedNames() {
+		module := m.modules[name]
+		moduleGroup := router.Group("/"+name, m.enabledGuard(name))
+		m.basePaths[name] = moduleGroup.BasePath()
 		if err := module.RegisterRoutes(moduleGroup, logger); err != nil {
 			return fmt.Errorf("failed to register routes for module %s: %w", name, err)
 		}
@@ -104,6 +275,34 @@ func (m *Manager) RegisterRoutes(router *gin.RouterGroup, logger *zap.Logger) er
 	return nil
 }
 
+// enabledGuard 拒绝已停用模块的请求，并记录已放行的请求，停用模块时等待其完成
+// name: 模块名称
+// 返回值: gin.HandlerFunc 中间件函数
+func (m *Manager) enabledGuard(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 检查状态和计数在同一次加锁中完成，停用模块翻转状态后不会再有新的请求计入
+		m.mu.RLock()
+		_, exists := m.modules[name]
+		inflight := m.inflight[name]
+		enabled := exists && !m.disabled[name]
+		if enabled {
+			inflight.acquire()
+		}
+		m.mu.RUnlock()
+
+		if !enabled {
+			c.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+				Code:    http.StatusServiceUnavailable,
+				Message: fmt.Sprintf("Module %s is disabled", name),
+			})
+			c.Abort()
+			return
+		}
+		defer inflight.release()
+		c.Next()
+	}
+}
+
 // GetModule 获取指定模块
 // name: 模块名称
 // 返回值: Module 模块实例, bool 是否存在
@@ -115,25 +314,176 @@ func (m *Manager) GetModule(name string) (Module, bool) {
 	return module, exists
 }
 
-// ListModules 列出所有模块
-// 返回值: []ModuleInfo 模块信息列表
+// ListModules 列出所有模块，按名称排序
+// 返回值: []ModuleInfo 模块信息列表（不含配置）
 func (m *Manager) ListModules() []ModuleInfo {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	var modules []ModuleInfo
+	modules := make([]ModuleInfo, 0, len(m.modules))
 	for name, module := range m.modules {
 		modules = append(modules, ModuleInfo{
-			Name:        name,
-			Version:     module.Version(),
-			Description: module.Description(),
+			Name:         name,
+			Version:      module.Version(),
+			Description:  module.Description(),
+			Enabled:      !m.disabled[name],
+			Dependencies: m.settings[name].Dependencies,
+			LoadOrder:    m.settings[name].LoadOrder,
 		})
 	}
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Name < modules[j].Name
+	})
 
 	return modules
 }
 
-// HealthCheck 检查所有模块的健康状态
+// GetModuleInfo 获取指定模块的信息
+// name: 模块名称
+// 返回值: ModuleInfo 模块信息（含初始化时使用的配置，未脱敏）, bool 是否存在
+func (m *Manager) GetModuleInfo(name string) (ModuleInfo, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	module, exists := m.modules[name]
+	if !exists {
+		return ModuleInfo{}, false
+	}
+	return ModuleInfo{
+		Name:         name,
+		Version:      module.Version(),
+		Description:  module.Description(),
+		Enabled:      !m.disabled[name],
+		Dependencies: m.settings[name].Dependencies,
+		LoadOrder:    m.settings[name].LoadOrder,
+		Config:       m.settings[name].Config,
+	}, true
+}
+
+// RoutePrefix 获取模块路由的路径前缀
+// name: 模块名称
+// 返回值: string 路径前缀（如 /api/v1/iam），路由未注册时为空
+func (m *Manager) RoutePrefix(name string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.basePaths[name]
+}
+
+// IsEnabled 模块是否已启用
+// name: 模块名称
+// 返回值: bool 已注册且未停用时为true
+func (m *Manager) IsEnabled(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, exists := m.modules[name]
+	return exists && !m.disabled[name]
+}
+
+// EnableModule 启用已停用的模块
+// 使用上次初始化时的配置重新初始化模块，初始化完成后模块路由才恢复服务；模块已启用时不做任何操作
+// 初始化期间不持有管理器的锁，其他模块的请求不受影响
+// 依赖的模块已停用时返回ErrDependencyConflict
+// ctx: 上下文
+// name: 模块名称
+// 返回值: error 错误信息
+func (m *Manager) EnableModule(ctx context.Context, name string) error {
+	m.ops.Lock()
+	defer m.ops.Unlock()
+
+	m.mu.RLock()
+	module, exists := m.modules[name]
+	disabled := m.disabled[name]
+	settings := m.settings[name]
+	var conflict error
+	for _, dep := range settings.Dependencies {
+		if m.disabled[dep] {
+			conflict = fmt.Errorf("%w: module %s depends on disabled module %s", ErrDependencyConflict, name, dep)
+			break
+		}
+	}
+	m.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrModuleNotFound, name)
+	}
+	if !disabled {
+		return nil
+	}
+	if conflict != nil {
+		return conflict
+	}
+
+	config := settings.Config
+	if config == nil {
+		config = make(map[string]interface{})
+	}
+	if err := module.Initialize(ctx, config, m.logger); err != nil {
+		return fmt.Errorf("failed to initialize module %s: %w", name, err)
+	}
+
+	m.mu.Lock()
+	delete(m.disabled, name)
+	m.mu.Unlock()
+	m.logger.Info("Module enabled", zap.String("name", name))
+	return nil
+}
+
+// DisableModule 停用模块
+// 模块路由立即开始返回503，等待进行中的请求完成（ctx结束时不再等待）后关闭模块；模块已停用时不做任何操作
+// 关闭期间不持有管理器的锁，其他模块的请求不受影响
+// 仍有已启用的模块依赖该模块时返回ErrDependencyConflict
+// ctx: 上下文
+// name: 模块名称
+// 返回值: error 错误信息
+func (m *Manager) DisableModule(ctx context.Context, name string) error {
+	m.ops.Lock()
+	defer m.ops.Unlock()
+
+	m.mu.Lock()
+	module, exists := m.modules[name]
+	if !exists {
+		m.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrModuleNotFound, name)
+	}
+	if m.disabled[name] {
+		m.mu.Unlock()
+		return nil
+	}
+	var dependents []string
+	for _, other := range m.names() {
+		if m.disabled[other] {
+			continue
+		}
+		for _, dep := range m.settings[other].Dependencies {
+			if dep == name {
+				dependents = append(dependents, other)
+			}
+		}
+	}
+	if len(dependents) > 0 {
+		m.mu.Unlock()
+		return fmt.Errorf("%w: module %s is required by %s", ErrDependencyConflict, name, strings.Join(dependents, ", "))
+	}
+	m.disabled[name] = true
+	inflight := m.inflight[name]
+	m.inflight[name] = newRequestTracker()
+	m.mu.Unlock()
+
+	if err := inflight.drain(ctx); err != nil {
+		m.logger.Warn("Timed out waiting for module requests, shutting down anyway", zap.String("name", name), zap.Error(err))
+	}
+
+	if err := module.Shutdown(ctx); err != nil {
+		m.logger.Error("Failed to shutdown module", zap.String("name", name), zap.Error(err))
+		return fmt.Errorf("failed to shutdown module %s: %w", name, err)
+	}
+	m.logger.Info("Module disabled", zap.String("name", name))
+	return nil
+}
+
+// HealthCheck 检查所有已启用模块的健康状态
 // ctx: 上下文
 // 返回值: map[string]error 模块健康状态
 func (m *Manager) HealthCheck(ctx context.Context) map[string]error {
@@ -142,21 +492,39 @@ func (m *Manager) HealthCheck(ctx context.Context) map[string]error {
 
 	health := make(map[string]error)
 	for name, module := range m.modules {
+		if m.disabled[name] {
+			continue
+		}
 		health[name] = module.HealthCheck(ctx)
 	}
 
 	return health
 }
 
-// ShutdownAll 关闭所有模块
+// ShutdownAll 按初始化的相反顺序关闭所有已启用的模块
+// 关闭期间不持有管理器的锁
 // ctx: 上下文
 // 返回值: error 错误信息
 func (m *Manager) ShutdownAll(ctx context.Context) error {
+	m.ops.Lock()
+	defer m.ops.Unlock()
+
 	m.mu.RLock()
-	defer m.mu.RUnlock()
+	entries := m.entries(m.orderedNames(), true)
+	m.mu.RUnlock()
+
+	return m.shutdownModules(ctx, entries)
+}
 
+// shutdownModules 按相反顺序关闭模块
+// 不需要持有锁
+// ctx: 上下文
+// entries: 按初始化顺序排列的模块快照
+// 返回值: error 错误信息
+func (m *Manager) shutdownModules(ctx context.Context, entries []moduleEntry) error {
 	var errors []string
-	for name, module := range m.modules {
+	for i := len(entries) - 1; i >= 0; i-- {
+		name, module := entries[i].name, entries[i].module
 		if err := module.Shutdown(ctx); err != nil {
 			errors = append(errors, fmt.Sprintf("module %s: %v", name, err))
 			m.logger.Error("Failed to shutdown module", zap.String("name", name), zap.Error(err))
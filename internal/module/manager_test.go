@@ -0,0 +1,378 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// recorder 按调用顺序记录模块事件
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+// stubModule 记录初始化和关闭顺序的测试模块
+// 路由 GET /ping 立即返回，GET /wait 在release关闭前不返回
+type stubModule struct {
+	name     string
+	recorder *recorder
+
+	// initErr Initialize返回的错误
+	initErr error
+
+	// onInit、onShutdown 在Initialize、Shutdown中调用，可为nil
+	onInit     func()
+	onShutdown func()
+
+	// entered 请求进入/wait时写入，release关闭后/wait返回
+	entered chan struct{}
+	release chan struct{}
+}
+
+// newStubModule 创建测试模块
+// 返回值: *stubModule 测试模块
+func newStubModule(name string, rec *recorder) *stubModule {
+	return &stubModule{name: name, recorder: rec, entered: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (s *stubModule) Name() string        { return s.name }
+func (s *stubModule) Version() string     { return "1.0.0" }
+func (s *stubModule) Description() string { return "" }
+
+func (s *stubModule) Initialize(ctx context.Context, config interface{}, logger *zap.Logger) error {
+	if s.onInit != nil {
+		s.onInit()
+	}
+	if s.initErr != nil {
+		s.recorder.add("fail " + s.name)
+		return s.initErr
+	}
+	s.recorder.add("init " + s.name)
+	return nil
+}
+
+func (s *stubModule) RegisterRoutes(router *gin.RouterGroup, logger *zap.Logger) error {
+	router.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, s.name) })
+	router.GET("/wait", func(c *gin.Context) {
+		s.entered <- struct{}{}
+		<-s.release
+		c.String(http.StatusOK, "done")
+	})
+	return nil
+}
+
+func (s *stubModule) HealthCheck(ctx context.Context) error { return nil }
+
+func (s *stubModule) Shutdown(ctx context.Context) error {
+	if s.onShutdown != nil {
+		s.onShutdown()
+	}
+	s.recorder.add("shutdown " + s.name)
+	return nil
+}
+
+// newRoutedModule 创建注册并初始化了单个模块、挂载了模块路由的管理器
+// 返回值: 管理器、路由器和测试模块
+func newRoutedModule(t *testing.T, name string) (*Manager, *gin.Engine, *stubModule, *recorder) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	rec := &recorder{}
+	stub := newStubModule(name, rec)
+	m := NewManager(zap.NewNop())
+	if err := m.RegisterModule(name, stub); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.InitializeAll(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	if err := m.RegisterRoutes(router.Group("/api/v1"), zap.NewNop()); err != nil {
+		t.Fatal(err)
+	}
+	return m, router, stub, rec
+}
+
+// get 向路由器发送GET请求
+// 返回值: *httptest.ResponseRecorder 响应记录
+func get(router http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+// within 在限定时间内执行f，超时（如死锁）时使测试失败
+func within(t *testing.T, timeout time.Duration, what string, f func()) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatalf("%s did not return within %v", what, timeout)
+	}
+}
+
+func TestModuleCallsDoNotHoldManagerLock(t *testing.T) {
+	rec := &recorder{}
+	m := NewManager(zap.NewNop())
+	stub := newStubModule("iam", rec)
+	// 模块初始化和关闭时查询管理器，持有锁时会死锁
+	stub.onInit = func() { m.IsEnabled("iam") }
+	stub.onShutdown = func() { m.ListModules() }
+	if err := m.RegisterModule("iam", stub); err != nil {
+		t.Fatal(err)
+	}
+
+	within(t, time.Second, "InitializeAll", func() {
+		if err := m.InitializeAll(context.Background(), nil); err != nil {
+			t.Error(err)
+		}
+	})
+	within(t, time.Second, "ShutdownAll", func() {
+		if err := m.ShutdownAll(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+	if got, want := rec.list(), []string{"init iam", "shutdown iam"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %q, want %q", got, want)
+	}
+}
+
+func TestDisabledModuleReturns503(t *testing.T) {
+	m, router, _, rec := newRoutedModule(t, "iam")
+	ctx := context.Background()
+
+	steps := []struct {
+		name       string
+		apply      func() error
+		wantStatus int
+	}{
+		{name: "enabled", apply: func() error { return nil }, wantStatus: http.StatusOK},
+		{name: "disabled", apply: func() error { return m.DisableModule(ctx, "iam") }, wantStatus: http.StatusServiceUnavailable},
+		{name: "disabled again", apply: func() error { return m.DisableModule(ctx, "iam") }, wantStatus: http.StatusServiceUnavailable},
+		{name: "re-enabled", apply: func() error { return m.EnableModule(ctx, "iam") }, wantStatus: http.StatusOK},
+		{name: "enabled again", apply: func() error { return m.EnableModule(ctx, "iam") }, wantStatus: http.StatusOK},
+	}
+	for _, step := range steps {
+		if err := step.apply(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if w := get(router, "/api/v1/iam/ping"); w.Code != step.wantStatus {
+			t.Fatalf("%s: status = %d, want %d", step.name, w.Code, step.wantStatus)
+		}
+	}
+	// 重复的停用和启用不会再次关闭或初始化模块
+	if got, want := rec.list(), []string{"init iam", "shutdown iam", "init iam"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %q, want %q", got, want)
+	}
+
+	if err := m.DisableModule(ctx, "missing"); !errors.Is(err, ErrModuleNotFound) {
+		t.Fatalf("DisableModule(missing) error = %v", err)
+	}
+}
+
+func TestDisableModuleDrainsInFlightRequests(t *testing.T) {
+	m, router, stub, rec := newRoutedModule(t, "iam")
+
+	inflight := make(chan *httptest.ResponseRecorder, 1)
+	go func() { inflight <- get(router, "/api/v1/iam/wait") }()
+	<-stub.entered
+
+	disabled := make(chan error, 1)
+	go func() { disabled <- m.DisableModule(context.Background(), "iam") }()
+
+	// 停用后新请求立即返回503，进行中的请求完成前不关闭模块
+	deadline := time.Now().Add(time.Second)
+	for get(router, "/api/v1/iam/ping").Code != http.StatusServiceUnavailable {
+		if time.Now().After(deadline) {
+			t.Fatal("requests were not rejected after disabling")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case err := <-disabled:
+		t.Fatalf("DisableModule() returned %v while a request was in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if got := rec.list(); len(got) != 1 {
+		t.Fatalf("module shut down with a request in flight: %q", got)
+	}
+
+	close(stub.release)
+	if w := <-inflight; w.Code != http.StatusOK {
+		t.Fatalf("in-flight request status = %d", w.Code)
+	}
+	if err := <-disabled; err != nil {
+		t.Fatalf("DisableModule() error: %v", err)
+	}
+	if got, want := rec.list(), []string{"init iam", "shutdown iam"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %q, want %q", got, want)
+	}
+}
+
+func TestEnableAfterTimedOutDisable(t *testing.T) {
+	m, router, stub, rec := newRoutedModule(t, "iam")
+
+	inflight := make(chan *httptest.ResponseRecorder, 1)
+	go func() { inflight <- get(router, "/api/v1/iam/wait") }()
+	<-stub.entered
+
+	// 请求未完成时停用超时，模块仍被关闭
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := m.DisableModule(ctx, "iam"); err != nil {
+		t.Fatalf("DisableModule() error: %v", err)
+	}
+	if err := m.EnableModule(context.Background(), "iam"); err != nil {
+		t.Fatalf("EnableModule() error: %v", err)
+	}
+	if w := get(router, "/api/v1/iam/ping"); w.Code != http.StatusOK {
+		t.Fatalf("status after re-enable = %d", w.Code)
+	}
+
+	// 上一次停用遗留的请求在重新启用后完成，不影响新的计数
+	close(stub.release)
+	if w := <-inflight; w.Code != http.StatusOK {
+		t.Fatalf("in-flight request status = %d", w.Code)
+	}
+	within(t, time.Second, "second DisableModule", func() {
+		if err := m.DisableModule(context.Background(), "iam"); err != nil {
+			t.Error(err)
+		}
+	})
+	if got, want := rec.list(), []string{"init iam", "shutdown iam", "init iam", "shutdown iam"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %q, want %q", got, want)
+	}
+}
+
+func TestInitializeAllOrderAndRollback(t *testing.T) {
+	initErr := errors.New("connection refused")
+	configs := map[string]interface{}{
+		"api":   map[string]interface{}{"dependencies": []interface{}{"iam"}, "load_order": -10},
+		"iam":   map[string]interface{}{"dependencies": []interface{}{"db"}},
+		"db":    map[string]interface{}{"load_order": 5},
+		"audit": map[string]interface{}{},
+	}
+	tests := []struct {
+		name       string
+		configs    map[string]interface{}
+		fail       string
+		wantErr    error
+		wantEvents []string
+	}{
+		{
+			name:       "dependency order",
+			configs:    configs,
+			wantEvents: []string{"init audit", "init db", "init iam", "init api"},
+		},
+		{
+			name:       "rollback in reverse order",
+			configs:    configs,
+			fail:       "iam",
+			wantErr:    initErr,
+			wantEvents: []string{"init audit", "init db", "fail iam", "shutdown db", "shutdown audit"},
+		},
+		{
+			name:       "first module fails",
+			configs:    configs,
+			fail:       "audit",
+			wantErr:    initErr,
+			wantEvents: []string{"fail audit"},
+		},
+		{
+			name:    "missing dependency initializes nothing",
+			configs: map[string]interface{}{"api": map[string]interface{}{"dependencies": []interface{}{"billing"}}},
+			wantErr: ErrMissingDependency,
+		},
+		{
+			name: "cycle initializes nothing",
+			configs: map[string]interface{}{
+				"db":  map[string]interface{}{"dependencies": []interface{}{"iam"}},
+				"iam": map[string]interface{}{"dependencies": []interface{}{"db"}},
+			},
+			wantErr: ErrDependencyCycle,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{}
+			m := NewManager(zap.NewNop())
+			for _, name := range []string{"api", "audit", "db", "iam"} {
+				stub := newStubModule(name, rec)
+				if name == tt.fail {
+					stub.initErr = initErr
+				}
+				if err := m.RegisterModule(name, stub); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := m.InitializeAll(context.Background(), tt.configs)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("InitializeAll() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := rec.list(); !reflect.DeepEqual(got, tt.wantEvents) {
+				t.Fatalf("events = %q, want %q", got, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestShutdownAllReverseOrder(t *testing.T) {
+	rec := &recorder{}
+	m := NewManager(zap.NewNop())
+	for _, name := range []string{"api", "audit", "db", "iam"} {
+		if err := m.RegisterModule(name, newStubModule(name, rec)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configs := map[string]interface{}{
+		"api": map[string]interface{}{"dependencies": []interface{}{"iam"}},
+		"iam": map[string]interface{}{"dependencies": []interface{}{"db"}},
+	}
+	ctx := context.Background()
+	if err := m.InitializeAll(ctx, configs); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DisableModule(ctx, "audit"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DisableModule(ctx, "iam"); !errors.Is(err, ErrDependencyConflict) {
+		t.Fatalf("DisableModule(iam) error = %v, want ErrDependencyConflict", err)
+	}
+
+	if err := m.ShutdownAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// 已停用的模块不再关闭
+	want := []string{"init audit", "init db", "init iam", "init api", "shutdown audit", "shutdown api", "shutdown iam", "shutdown db"}
+	if got := rec.list(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %q, want %q", got, want)
+	}
+}
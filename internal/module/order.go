@@ -0,0 +1,52 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vera-byte/vgo-gateway/internal/depgraph"
+)
+
+var (
+	// ErrMissingDependency 模块依赖的模块未注册（未列出、已停用或没有对应的模块工厂）
+	ErrMissingDependency = errors.New("missing module dependency")
+
+	// ErrDependencyCycle 模块之间存在循环依赖
+	ErrDependencyCycle = errors.New("module dependency cycle")
+
+	// ErrDependencyConflict 启用或停用模块会破坏依赖关系
+	ErrDependencyConflict = errors.New("module dependency conflict")
+)
+
+// resolveOrder 计算模块的初始化顺序
+// 被依赖的模块排在前面，其余按LoadOrder从小到大、再按名称排序，保证顺序稳定
+// names: 模块名称列表
+// settings: 模块配置，提供依赖和加载顺序
+// 返回值: []string 模块名称列表, error 依赖缺失或存在循环依赖时的错误
+func resolveOrder(names []string, settings map[string]ModuleConfig) ([]string, error) {
+	registered := make(map[string]bool, len(names))
+	for _, name := range names {
+		registered[name] = true
+	}
+
+	edges := make(map[string][]string, len(names))
+	for _, name := range names {
+		for _, dep := range settings[name].Dependencies {
+			if !registered[dep] {
+				return nil, fmt.Errorf("%w: module %s depends on %s, which is not enabled", ErrMissingDependency, name, dep)
+			}
+			edges[name] = append(edges[name], dep)
+		}
+	}
+
+	order, cycle := depgraph.Sort(names, edges, func(a, b string) bool {
+		if settings[a].LoadOrder != settings[b].LoadOrder {
+			return settings[a].LoadOrder < settings[b].LoadOrder
+		}
+		return a < b
+	})
+	if cycle != "" {
+		return nil, fmt.Errorf("%w: %s", ErrDependencyCycle, cycle)
+	}
+	return order, nil
+}
\ No newline at end of file
@@ -0,0 +1,85 @@
+package module
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResolveOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		names    []string
+		settings map[string]ModuleConfig
+		want     []string
+		wantErr  error
+	}{
+		{
+			name:  "by name",
+			names: []string{"c", "a", "b"},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name:  "by load order then name",
+			names: []string{"a", "b", "c", "d"},
+			settings: map[string]ModuleConfig{
+				"a": {LoadOrder: 10},
+				"b": {LoadOrder: -1},
+				"d": {LoadOrder: 10},
+			},
+			want: []string{"b", "c", "a", "d"},
+		},
+		{
+			name:  "dependencies before load order",
+			names: []string{"api", "iam", "db", "audit"},
+			settings: map[string]ModuleConfig{
+				"api":   {LoadOrder: -10, Dependencies: []string{"iam"}},
+				"iam":   {LoadOrder: 5, Dependencies: []string{"db"}},
+				"db":    {LoadOrder: 20},
+				"audit": {LoadOrder: 1},
+			},
+			want: []string{"audit", "db", "iam", "api"},
+		},
+		{
+			name:  "shared dependency",
+			names: []string{"b", "a", "base"},
+			settings: map[string]ModuleConfig{
+				"a": {Dependencies: []string{"base"}},
+				"b": {Dependencies: []string{"base"}},
+			},
+			want: []string{"base", "a", "b"},
+		},
+		{
+			name:     "missing dependency",
+			names:    []string{"api"},
+			settings: map[string]ModuleConfig{"api": {Dependencies: []string{"iam"}}},
+			wantErr:  ErrMissingDependency,
+		},
+		{
+			name:  "cycle",
+			names: []string{"a", "b", "c"},
+			settings: map[string]ModuleConfig{
+				"a": {Dependencies: []string{"b"}},
+				"b": {Dependencies: []string{"a"}},
+			},
+			wantErr: ErrDependencyCycle,
+		},
+		{
+			name:     "self dependency",
+			names:    []string{"a"},
+			settings: map[string]ModuleConfig{"a": {Dependencies: []string{"a"}}},
+			wantErr:  ErrDependencyCycle,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := resolveOrder(tt.names, tt.settings)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("resolveOrder() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(order, tt.want) {
+				t.Fatalf("resolveOrder() = %q, want %q", order, tt.want)
+			}
+		})
+	}
+}
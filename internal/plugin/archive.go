@@ -0,0 +1,256 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// archiveDirName vpks目录下存放历史版本的子目录
+const archiveDirName = "archive"
+
+var (
+	// ErrDowngradeRefused 安装的版本低于已安装版本且未指定强制安装
+	ErrDowngradeRefused = errors.New("plugin downgrade refused")
+
+	// ErrNoPreviousVersion 没有可回滚的历史版本
+	ErrNoPreviousVersion = errors.New("no previous plugin version")
+)
+
+// SemVer 解析插件文件名中的语义化版本
+// 返回: 版本和错误信息
+func (p *PluginInfo) SemVer() (SemVer, error) {
+	return ParseSemVer(p.Version)
+}
+
+// SetArchiveVersions 设置每个服务保留的历史版本数
+// n: 历史版本数，0表示不保留（不支持回滚），负数使用默认值
+func (i *PluginInstaller) SetArchiveVersions(n int) {
+	if n < 0 {
+		n = DefaultArchiveVersions
+	}
+	i.archiveVersions = n
+}
+
+// archiveDir 返回归档目录路径
+// 返回: 归档目录路径
+func (i *PluginInstaller) archiveDir() string {
+	return filepath.Join(i.vpksDir, archiveDirName)
+}
+
+// checkUpgrade 检查安装是否为降级
+// 已安装的同一服务存在更高版本且未强制安装时返回ErrDowngradeRefused
+// 版本不是语义化版本时不做限制
+// info: 由校验通过的清单得到的插件信息
+// force: 是否强制安装
+// 返回: 错误信息
+func (i *PluginInstaller) checkUpgrade(info *PluginInfo, force bool) error {
+	newVersion, err := info.SemVer()
+	if err != nil {
+		i.logger.Warn("插件版本不是语义化版本，跳过降级检查",
+			zap.String("filename", info.Filename),
+			zap.String("version", info.Version))
+		return nil
+	}
+
+	existingPlugins, err := i.findPluginsByService(info.ServiceName)
+	if err != nil {
+		return nil
+	}
+	for _, existing := range existingPlugins {
+		existingVersion, err := existing.SemVer()
+		if err != nil || existingVersion.Compare(newVersion) <= 0 {
+			continue
+		}
+		if force {
+			i.logger.Warn("强制安装低于已安装版本的插件",
+				zap.String("service", info.ServiceName),
+				zap.String("installed_version", existing.Version),
+				zap.String("new_version", info.Version))
+			continue
+		}
+		return fmt.Errorf("%w: 已安装版本 %s 高于 %s，如需降级请使用强制安装", ErrDowngradeRefused, existing.Version, info.Version)
+	}
+	return nil
+}
+
+// archivePlugin 将已安装的插件移入归档目录
+// 不保留历史版本时直接删除
+// filename: 插件文件名
+// 返回: 错误信息
+func (i *PluginInstaller) archivePlugin(filename string) error {
+	if i.archiveVersions == 0 {
+		return i.RemovePlugin(filename)
+	}
+
+	if err := os.MkdirAll(i.archiveDir(), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Join(i.vpksDir, filename), filepath.Join(i.archiveDir(), filename)); err != nil {
+		return err
+	}
+
+	i.logger.Info("插件已归档", zap.String("filename", filename))
+	return nil
+}
+
+// listArchived 列出指定服务的归档版本
+// 按版本从高到低排序，无法解析版本的排在最后
+// serviceName: 服务名称
+// 返回: 插件信息列表和错误信息
+func (i *PluginInstaller) listArchived(serviceName string) ([]*PluginInfo, error) {
+	files, err := filepath.Glob(filepath.Join(i.archiveDir(), "*.vkp"))
+	if err != nil {
+		return nil, err
+	}
+
+	var archived []*PluginInfo
+	for _, file := range files {
+		info, err := i.parsePluginInfo(filepath.Base(file))
+		if err != nil || info.ServiceName != serviceName {
+			continue
+		}
+		archived = append(archived, info)
+	}
+
+	sort.SliceStable(archived, func(a, b int) bool {
+		va, errA := archived[a].SemVer()
+		vb, errB := archived[b].SemVer()
+		switch {
+		case errA != nil || errB != nil:
+			return errA == nil && errB != nil
+		default:
+			return va.Compare(vb) > 0
+		}
+	})
+	return archived, nil
+}
+
+// pruneArchive 删除超出保留数量的归档版本
+// serviceName: 服务名称
+func (i *PluginInstaller) pruneArchive(serviceName string) {
+	archived, err := i.listArchived(serviceName)
+	if err != nil {
+		i.logger.Warn("列出归档插件失败", zap.String("service", serviceName), zap.Error(err))
+		return
+	}
+	if len(archived) <= i.archiveVersions {
+		return
+	}
+
+	for _, info := range archived[i.archiveVersions:] {
+		if err := os.Remove(filepath.Join(i.archiveDir(), info.Filename)); err != nil {
+			i.logger.Warn("删除过期归档插件失败",
+				zap.String("filename", info.Filename),
+				zap.Error(err))
+			continue
+		}
+		i.logger.Info("已删除过期归档插件", zap.String("filename", info.Filename))
+	}
+}
+
+// findInstalledPackage 根据插件名称查找已安装的插件包
+// 插件名称取自包内清单，与文件名中的服务名称可能不同
+// name: 插件名称
+// 返回: 插件文件名（未找到时为空）和错误信息
+func (i *PluginInstaller) findInstalledPackage(name string) (string, error) {
+	plugins, err := i.ListInstalledPlugins()
+	if err != nil {
+		return "", err
+	}
+
+	for _, filename := range plugins {
+		manifest, _, err := ReadManifestFromVKP(filepath.Join(i.vpksDir, filename))
+		if err != nil {
+			continue
+		}
+		if manifest.Plugin.Name == name {
+			return filename, nil
+		}
+	}
+	return "", nil
+}
+
+// rollbackTarget 查找回滚的目标版本
+// 归档中低于当前版本的最高版本，当前版本无法解析时取归档中的最高版本
+// name: 插件名称（未找到已安装的包时按服务名称处理）
+// 返回: 当前插件文件名（未安装时为空）、目标版本和错误信息
+func (i *PluginInstaller) rollbackTarget(name string) (string, *PluginInfo, error) {
+	current, err := i.findInstalledPackage(name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	serviceName := name
+	var currentVersion *SemVer
+	if current != "" {
+		info, err := i.parsePluginInfo(current)
+		if err != nil {
+			return "", nil, fmt.Errorf("无法解析已安装插件的版本: %w", err)
+		}
+		serviceName = info.ServiceName
+		if v, err := info.SemVer(); err == nil {
+			currentVersion = &v
+		}
+	}
+
+	archived, err := i.listArchived(serviceName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var previous *PluginInfo
+	for _, info := range archived {
+		if info.Filename == current {
+			continue
+		}
+		if currentVersion != nil {
+			v, err := info.SemVer()
+			if err != nil || v.Compare(*currentVersion) >= 0 {
+				continue
+			}
+		}
+		previous = info
+		break
+	}
+	if previous == nil {
+		return "", nil, fmt.Errorf("%w: %s", ErrNoPreviousVersion, name)
+	}
+	return current, previous, nil
+}
+
+// Rollback 回滚插件到上一版本
+// 当前版本移入归档目录，归档中低于当前版本的最高版本恢复到vpks目录
+// name: 插件名称（未找到已安装的包时按服务名称处理）
+// 返回: 恢复的插件文件路径和错误信息
+func (i *PluginInstaller) Rollback(name string) (string, error) {
+	current, previous, err := i.rollbackTarget(name)
+	if err != nil {
+		return "", err
+	}
+
+	// 先恢复上一版本再归档当前版本，任一步失败都不会丢失已安装的包
+	localPath := filepath.Join(i.vpksDir, previous.Filename)
+	if err := os.Rename(filepath.Join(i.archiveDir(), previous.Filename), localPath); err != nil {
+		return "", fmt.Errorf("恢复历史版本失败: %w", err)
+	}
+	if current != "" {
+		if err := os.MkdirAll(i.archiveDir(), 0755); err != nil {
+			return "", err
+		}
+		if err := os.Rename(filepath.Join(i.vpksDir, current), filepath.Join(i.archiveDir(), current)); err != nil {
+			return "", fmt.Errorf("归档当前版本失败: %w", err)
+		}
+	}
+
+	i.logger.Info("插件已回滚",
+		zap.String("name", name),
+		zap.String("from", current),
+		zap.String("to", previous.Filename))
+
+	return localPath, nil
+}
@@ -0,0 +1,192 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// packageFiles 返回目录中的插件包文件名（按名称排序）
+func packageFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.vkp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// demoPackage 返回测试插件demo指定版本的包文件名
+func demoPackage(version string) string {
+	platform := CurrentPlatform()
+	return "demo_" + platform.OS + "_" + platform.Arch + "_v" + version + ".vkp"
+}
+
+func TestInstallRefusesDowngrade(t *testing.T) {
+	packages := t.TempDir()
+	for _, version := range []string{"1.0.0", "1.1.0", "1.0.5", "1.2.0-rc.1", "1.2.0"} {
+		writeTestPackage(t, packages, "demo", version, CurrentPlatform(), nil)
+	}
+
+	vpksDir := t.TempDir()
+	installer := NewPluginInstaller(vpksDir, zap.NewNop())
+	steps := []struct {
+		version       string
+		force         bool
+		wantErr       error
+		wantInstalled string
+	}{
+		{version: "1.0.0", wantInstalled: "1.0.0"},
+		{version: "1.1.0", wantInstalled: "1.1.0"},
+		{version: "1.0.5", wantErr: ErrDowngradeRefused, wantInstalled: "1.1.0"},
+		{version: "1.0.5", force: true, wantInstalled: "1.0.5"},
+		{version: "1.2.0", wantInstalled: "1.2.0"},
+		{version: "1.2.0-rc.1", wantErr: ErrDowngradeRefused, wantInstalled: "1.2.0"},
+		{version: "1.2.0", wantInstalled: "1.2.0"},
+	}
+	for _, step := range steps {
+		_, err := installer.InstallFromFile(filepath.Join(packages, demoPackage(step.version)), InstallOptions{Force: step.force})
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("install %s (force %v) error = %v, want %v", step.version, step.force, err, step.wantErr)
+		}
+		if got := packageFiles(t, vpksDir); !reflect.DeepEqual(got, []string{demoPackage(step.wantInstalled)}) {
+			t.Fatalf("after installing %s: installed = %q, want %s", step.version, got, step.wantInstalled)
+		}
+	}
+}
+
+func TestInstallArchivesAndRollsBack(t *testing.T) {
+	packages := t.TempDir()
+	for _, version := range []string{"1.0.0", "1.1.0", "1.2.0", "1.3.0"} {
+		writeTestPackage(t, packages, "demo", version, CurrentPlatform(), nil)
+	}
+
+	vpksDir := t.TempDir()
+	archiveDir := filepath.Join(vpksDir, archiveDirName)
+	installer := NewPluginInstaller(vpksDir, zap.NewNop())
+	installer.SetArchiveVersions(2)
+	for _, version := range []string{"1.0.0", "1.1.0", "1.2.0", "1.3.0"} {
+		if _, err := installer.InstallFromFile(filepath.Join(packages, demoPackage(version)), InstallOptions{}); err != nil {
+			t.Fatalf("install %s: %v", version, err)
+		}
+	}
+	// 只保留最近的两个历史版本
+	if got, want := packageFiles(t, archiveDir), []string{demoPackage("1.1.0"), demoPackage("1.2.0")}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("archive = %q, want %q", got, want)
+	}
+
+	rollbacks := []struct {
+		wantInstalled string
+		wantArchive   []string
+		wantErr       error
+	}{
+		{wantInstalled: "1.2.0", wantArchive: []string{demoPackage("1.1.0"), demoPackage("1.3.0")}},
+		{wantInstalled: "1.1.0", wantArchive: []string{demoPackage("1.2.0"), demoPackage("1.3.0")}},
+		{wantInstalled: "1.1.0", wantArchive: []string{demoPackage("1.2.0"), demoPackage("1.3.0")}, wantErr: ErrNoPreviousVersion},
+	}
+	for _, step := range rollbacks {
+		path, err := installer.Rollback("demo")
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("Rollback() error = %v, want %v", err, step.wantErr)
+		}
+		if err == nil && filepath.Base(path) != demoPackage(step.wantInstalled) {
+			t.Fatalf("Rollback() = %s, want %s", path, demoPackage(step.wantInstalled))
+		}
+		if got := packageFiles(t, vpksDir); !reflect.DeepEqual(got, []string{demoPackage(step.wantInstalled)}) {
+			t.Fatalf("installed = %q, want %s", got, step.wantInstalled)
+		}
+		if got := packageFiles(t, archiveDir); !reflect.DeepEqual(got, step.wantArchive) {
+			t.Fatalf("archive = %q, want %q", got, step.wantArchive)
+		}
+	}
+}
+
+func TestInstallWithoutArchive(t *testing.T) {
+	packages := t.TempDir()
+	vpksDir := t.TempDir()
+	installer := NewPluginInstaller(vpksDir, zap.NewNop())
+	installer.SetArchiveVersions(0)
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		path, _ := writeTestPackage(t, packages, "demo", version, CurrentPlatform(), nil)
+		if _, err := installer.InstallFromFile(path, InstallOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(vpksDir, archiveDirName)); !os.IsNotExist(err) {
+		t.Fatalf("archive dir should not exist: %v", err)
+	}
+	if _, err := installer.Rollback("demo"); !errors.Is(err, ErrNoPreviousVersion) {
+		t.Fatalf("Rollback() error = %v, want %v", err, ErrNoPreviousVersion)
+	}
+}
+
+func TestManagerUpgradeAndRollback(t *testing.T) {
+	packages := t.TempDir()
+	for _, version := range []string{"0.9.0", "1.0.0", "1.1.0", "2.0.0"} {
+		writeTestPackage(t, packages, "demo", version, CurrentPlatform(), nil)
+	}
+	install := func(m *Manager, version string) error {
+		file, err := os.Open(filepath.Join(packages, demoPackage(version)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		_, err = m.InstallAndLoadPluginFromReader(context.Background(), demoPackage(version), file, InstallOptions{})
+		return err
+	}
+	running := func(m *Manager) string {
+		p, ok := m.GetPlugin("demo")
+		if !ok {
+			return ""
+		}
+		if state, _ := m.states.state("demo"); state != StateRunning {
+			t.Fatalf("demo %s is %s, want running", p.GetVersion(), state)
+		}
+		return p.GetVersion()
+	}
+
+	m := NewManager(zap.NewNop(), t.TempDir())
+	m.SetLoader(newTestLoader("demo@2.0.0"))
+
+	steps := []struct {
+		name        string
+		apply       func() error
+		wantErr     error
+		wantMsg     string
+		wantRunning string
+	}{
+		{name: "install", apply: func() error { return install(m, "1.0.0") }, wantRunning: "1.0.0"},
+		{name: "upgrade", apply: func() error { return install(m, "1.1.0") }, wantRunning: "1.1.0"},
+		{name: "downgrade refused", apply: func() error { return install(m, "0.9.0") }, wantErr: ErrDowngradeRefused, wantRunning: "1.1.0"},
+		{name: "failed upgrade rolls back", apply: func() error { return install(m, "2.0.0") }, wantMsg: "已回滚到 " + demoPackage("1.1.0"), wantRunning: "1.1.0"},
+		{name: "rollback", apply: func() error { _, err := m.RollbackPlugin(context.Background(), "demo"); return err }, wantRunning: "1.0.0"},
+		{name: "nothing to roll back", apply: func() error { _, err := m.RollbackPlugin(context.Background(), "demo"); return err }, wantErr: ErrNoPreviousVersion, wantRunning: "1.0.0"},
+	}
+	for _, step := range steps {
+		err := step.apply()
+		if step.wantMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), step.wantMsg) {
+				t.Fatalf("%s: error = %v, want %q", step.name, err, step.wantMsg)
+			}
+		} else if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.wantErr)
+		}
+		if got := running(m); got != step.wantRunning {
+			t.Fatalf("%s: running %q, want %q", step.name, got, step.wantRunning)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vera-byte/vgo-gateway/internal/version"
+)
+
+// ErrIncompatiblePlugin 插件要求的网关版本或API版本与当前网关不兼容
+var ErrIncompatiblePlugin = errors.New("incompatible plugin")
+
+// CheckCompatibility 检查插件与当前网关的兼容性
+// api_version 的主版本号必须与网关的插件API版本一致，为空时视为旧版插件不做限制
+// min_gateway_version 必须不高于当前网关版本，开发构建或网关版本无法解析时不检查
+// metadata: 插件元数据
+// 返回: 不兼容时返回包装了ErrIncompatiblePlugin的错误
+func CheckCompatibility(metadata *PluginMetadata) error {
+	if metadata == nil {
+		return nil
+	}
+
+	if metadata.APIVersion != "" {
+		pluginMajor, err := parseAPIMajor(metadata.APIVersion)
+		if err != nil {
+			return fmt.Errorf("%w: 插件 %s 的API版本 %q 无效", ErrIncompatiblePlugin, metadata.Name, metadata.APIVersion)
+		}
+		gatewayMajor, _ := parseAPIMajor(version.PluginAPIVersion)
+		if pluginMajor != gatewayMajor {
+			return fmt.Errorf("%w: 插件 %s 使用API版本 %s，网关支持的API版本为 %s",
+				ErrIncompatiblePlugin, metadata.Name, metadata.APIVersion, version.PluginAPIVersion)
+		}
+	}
+
+	if metadata.MinGatewayVersion != "" {
+		required, err := ParseSemVer(metadata.MinGatewayVersion)
+		if err != nil {
+			return fmt.Errorf("%w: 插件 %s 的最低网关版本 %q 无效", ErrIncompatiblePlugin, metadata.Name, metadata.MinGatewayVersion)
+		}
+		if version.IsDevelopment() {
+			return nil
+		}
+		current, err := ParseSemVer(version.Version)
+		if err != nil {
+			return nil
+		}
+		if current.Compare(required) < 0 {
+			return fmt.Errorf("%w: 插件 %s 要求网关版本不低于 %s，当前网关版本为 %s",
+				ErrIncompatiblePlugin, metadata.Name, metadata.MinGatewayVersion, version.Version)
+		}
+	}
+
+	return nil
+}
+
+// parseAPIMajor 解析API版本的主版本号
+// 支持 v1、1、v1.2 等形式
+// s: API版本
+// 返回: 主版本号和错误信息
+func parseAPIMajor(s string) (uint64, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		s = s[:i]
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
@@ -0,0 +1,75 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vera-byte/vgo-gateway/internal/version"
+	"go.uber.org/zap"
+)
+
+// setGatewayVersion 在测试期间替换网关版本
+func setGatewayVersion(t *testing.T, v string) {
+	t.Helper()
+
+	previous := version.Version
+	version.Version = v
+	t.Cleanup(func() { version.Version = previous })
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	tests := []struct {
+		name           string
+		gatewayVersion string
+		apiVersion     string
+		minGateway     string
+		wantErr        bool
+	}{
+		{name: "no requirements", gatewayVersion: "v1.0.0"},
+		{name: "same api major", gatewayVersion: "v1.0.0", apiVersion: "v1"},
+		{name: "api minor ignored", gatewayVersion: "v1.0.0", apiVersion: "1.7"},
+		{name: "api major mismatch", gatewayVersion: "v1.0.0", apiVersion: "v2", wantErr: true},
+		{name: "invalid api version", gatewayVersion: "v1.0.0", apiVersion: "latest", wantErr: true},
+		{name: "gateway newer", gatewayVersion: "v1.4.0", minGateway: "1.3.0"},
+		{name: "gateway equal", gatewayVersion: "v1.3.0", minGateway: "v1.3.0"},
+		{name: "gateway older", gatewayVersion: "v1.2.9", minGateway: "1.3.0", wantErr: true},
+		{name: "gateway prerelease older", gatewayVersion: "v1.3.0-rc.1", minGateway: "1.3.0", wantErr: true},
+		{name: "invalid min gateway", gatewayVersion: "v1.4.0", minGateway: "soon", wantErr: true},
+		{name: "development build", gatewayVersion: "dev", minGateway: "9.0.0"},
+		{name: "empty gateway version", gatewayVersion: "", minGateway: "9.0.0"},
+		{name: "unparsable gateway version", gatewayVersion: "nightly", minGateway: "9.0.0"},
+		{name: "development build checks api", gatewayVersion: "dev", apiVersion: "v2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setGatewayVersion(t, tt.gatewayVersion)
+			err := CheckCompatibility(&PluginMetadata{Name: "demo", APIVersion: tt.apiVersion, MinGatewayVersion: tt.minGateway})
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("CheckCompatibility() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrIncompatiblePlugin) {
+				t.Fatalf("CheckCompatibility() error = %v, want ErrIncompatiblePlugin", err)
+			}
+		})
+	}
+
+	if err := CheckCompatibility(nil); err != nil {
+		t.Fatalf("CheckCompatibility(nil) error: %v", err)
+	}
+}
+
+func TestInstallRejectsIncompatiblePlugin(t *testing.T) {
+	setGatewayVersion(t, "v1.0.0")
+
+	metadata := PluginMetadata{Name: "demo", Version: "1.0.0", MinGatewayVersion: "2.0.0"}
+	path, _ := writeTestPackageMetadata(t, t.TempDir(), metadata, CurrentPlatform(), nil)
+
+	vpksDir := t.TempDir()
+	installer := NewPluginInstaller(vpksDir, zap.NewNop())
+	if _, err := installer.InstallFromFile(path, InstallOptions{}); !errors.Is(err, ErrIncompatiblePlugin) {
+		t.Fatalf("InstallFromFile() error = %v, want ErrIncompatiblePlugin", err)
+	}
+	if got := vpksEntries(t, vpksDir); len(got) != 0 {
+		t.Fatalf("incompatible install left %q in the vpks dir", got)
+	}
+}
@@ -0,0 +1,269 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vera-byte/vgo-gateway/internal/depgraph"
+)
+
+var (
+	// ErrUnsatisfiedDependency 插件依赖的插件未加载或版本不满足范围
+	ErrUnsatisfiedDependency = errors.New("unsatisfied plugin dependency")
+
+	// ErrDependencyCycle 插件之间存在循环依赖
+	ErrDependencyCycle = errors.New("plugin dependency cycle")
+
+	// ErrPluginInUse 插件被其他已加载的插件依赖
+	ErrPluginInUse = errors.New("plugin is required by other plugins")
+)
+
+// Dependency 插件依赖声明
+// 元数据 dependencies 中的每一项形如 "name" 或 "name <范围>"，如 "iam >=1.2.0 <2.0.0"、"iam ^1.2.0"
+type Dependency struct {
+	// Name 依赖的插件名称
+	Name string
+
+	// Constraint 版本范围，为空表示任意版本
+	Constraint VersionConstraint
+}
+
+// String 返回依赖声明的文本形式
+// 返回: 依赖声明
+func (d Dependency) String() string {
+	if d.Constraint.IsAny() {
+		return d.Name
+	}
+	return d.Name + " " + d.Constraint.String()
+}
+
+// VersionConstraint 版本范围，由多个比较条件组成，版本需满足全部条件
+type VersionConstraint struct {
+	// raw 原始范围文本
+	raw string
+
+	// comparators 比较条件
+	comparators []versionComparator
+}
+
+// versionComparator 单个版本比较条件
+type versionComparator struct {
+	// op 比较运算符：=、>、>=、<、<=
+	op string
+
+	// version 比较的版本
+	version SemVer
+}
+
+// ParseDependency 解析依赖声明
+// s: 依赖声明，如 "iam >=1.2.0 <2.0.0"
+// 返回: 依赖和错误信息
+func ParseDependency(s string) (Dependency, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return Dependency{}, fmt.Errorf("empty dependency")
+	}
+
+	constraint, err := ParseVersionConstraint(strings.Join(fields[1:], " "))
+	if err != nil {
+		return Dependency{}, fmt.Errorf("invalid dependency %q: %w", s, err)
+	}
+	return Dependency{Name: fields[0], Constraint: constraint}, nil
+}
+
+// ParseDependencies 解析依赖声明列表
+// 同一插件不能重复声明
+// deps: 依赖声明列表
+// 返回: 依赖列表和错误信息
+func ParseDependencies(deps []string) ([]Dependency, error) {
+	result := make([]Dependency, 0, len(deps))
+	seen := make(map[string]bool, len(deps))
+	for _, s := range deps {
+		dep, err := ParseDependency(s)
+		if err != nil {
+			return nil, err
+		}
+		if seen[dep.Name] {
+			return nil, fmt.Errorf("duplicate dependency %s", dep.Name)
+		}
+		seen[dep.Name] = true
+		result = append(result, dep)
+	}
+	return result, nil
+}
+
+// ParseVersionConstraint 解析版本范围
+// 支持以空格或逗号分隔的多个条件：=、>、>=、<、<=、^（兼容版本）、~（同一次版本）
+// 不带运算符的版本表示精确匹配，空字符串或"*"表示任意版本
+// s: 版本范围
+// 返回: 版本范围和错误信息
+func ParseVersionConstraint(s string) (VersionConstraint, error) {
+	s = strings.TrimSpace(s)
+	constraint := VersionConstraint{raw: s}
+	if s == "" || s == "*" {
+		constraint.raw = ""
+		return constraint, nil
+	}
+
+	tokens := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		// 允许运算符与版本之间有空格，如 ">= 1.2.0"
+		if strings.TrimLeft(token, "=<>^~") == "" && i+1 < len(tokens) {
+			i++
+			token += tokens[i]
+		}
+
+		comparators, err := parseComparator(token)
+		if err != nil {
+			return VersionConstraint{}, err
+		}
+		constraint.comparators = append(constraint.comparators, comparators...)
+	}
+	return constraint, nil
+}
+
+// parseComparator 解析单个比较条件，^和~展开为上下界
+// token: 比较条件
+// 返回: 比较条件列表和错误信息
+func parseComparator(token string) ([]versionComparator, error) {
+	op := token[:len(token)-len(strings.TrimLeft(token, "=<>^~"))]
+	switch op {
+	case "", "=", ">", ">=", "<", "<=", "^", "~":
+	default:
+		return nil, fmt.Errorf("invalid version operator %q", op)
+	}
+	v, err := ParseSemVer(token[len(op):])
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "", "=":
+		return []versionComparator{{op: "=", version: v}}, nil
+	case ">", ">=", "<", "<=":
+		return []versionComparator{{op: op, version: v}}, nil
+	case "^":
+		upper := SemVer{Major: v.Major + 1}
+		switch {
+		case v.Major == 0 && v.Minor > 0:
+			upper = SemVer{Minor: v.Minor + 1}
+		case v.Major == 0:
+			upper = SemVer{Patch: v.Patch + 1}
+		}
+		return []versionComparator{{op: ">=", version: v}, {op: "<", version: upper}}, nil
+	default: // "~"
+		upper := SemVer{Major: v.Major, Minor: v.Minor + 1}
+		return []versionComparator{{op: ">=", version: v}, {op: "<", version: upper}}, nil
+	}
+}
+
+// IsAny 是否为任意版本
+// 返回: 是否没有版本限制
+func (c VersionConstraint) IsAny() bool {
+	return len(c.comparators) == 0
+}
+
+// Allows 版本是否满足范围
+// v: 版本
+// 返回: 是否满足
+func (c VersionConstraint) Allows(v SemVer) bool {
+	for _, cmp := range c.comparators {
+		result := v.Compare(cmp.version)
+		var ok bool
+		switch cmp.op {
+		case "=":
+			ok = result == 0
+		case ">":
+			ok = result > 0
+		case ">=":
+			ok = result >= 0
+		case "<":
+			ok = result < 0
+		case "<=":
+			ok = result <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// String 返回版本范围的原始文本
+// 返回: 版本范围
+func (c VersionConstraint) String() string {
+	return c.raw
+}
+
+// check 检查被依赖插件的版本是否满足范围
+// version: 被依赖插件的版本
+// 返回: 不满足时返回包装了ErrUnsatisfiedDependency的错误
+func (d Dependency) check(version string) error {
+	if d.Constraint.IsAny() {
+		return nil
+	}
+	v, err := ParseSemVer(version)
+	if err != nil || !d.Constraint.Allows(v) {
+		return fmt.Errorf("%w: 需要 %s，实际版本为 %s", ErrUnsatisfiedDependency, d, version)
+	}
+	return nil
+}
+
+// pluginDependencies 解析插件元数据中的依赖声明
+// p: 插件实例
+// 返回: 依赖列表和错误信息
+func pluginDependencies(p Plugin) ([]Dependency, error) {
+	metadata := p.GetMetadata()
+	if metadata == nil {
+		return nil, nil
+	}
+	deps, err := ParseDependencies(metadata.Dependencies)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 插件 %s 的依赖声明无效: %v", ErrUnsatisfiedDependency, p.GetName(), err)
+	}
+	return deps, nil
+}
+
+// resolveOrder 按依赖关系对插件排序，被依赖的插件排在前面
+// 没有依赖关系的插件按名称排序，保证顺序稳定
+// plugins: 插件映射
+// 返回: 插件名称列表和错误信息（依赖缺失、版本不满足或存在循环依赖）
+// 存在循环依赖时同时返回可以排序的部分，环上及依赖环的插件不在其中
+func resolveOrder(plugins map[string]Plugin) ([]string, error) {
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	edges := make(map[string][]string, len(plugins))
+	for _, name := range names {
+		deps, err := pluginDependencies(plugins[name])
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range deps {
+			target, ok := plugins[dep.Name]
+			if !ok {
+				return nil, fmt.Errorf("%w: 插件 %s 依赖 %s，但该插件未加载", ErrUnsatisfiedDependency, name, dep)
+			}
+			if err := dep.check(target.GetVersion()); err != nil {
+				return nil, fmt.Errorf("插件 %s: %w", name, err)
+			}
+			edges[name] = append(edges[name], dep.Name)
+		}
+	}
+
+	order, cycle := depgraph.Sort(names, edges, func(a, b string) bool {
+		return a < b
+	})
+	if cycle != "" {
+		return order, fmt.Errorf("%w: %s", ErrDependencyCycle, cycle)
+	}
+	return order, nil
+}
@@ -0,0 +1,170 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestParseVersionConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		allows     []string
+		rejects    []string
+		wantError  bool
+	}{
+		{constraint: "", allows: []string{"0.0.1", "1.0.0", "2.0.0-beta.1"}},
+		{constraint: "*", allows: []string{"1.0.0"}},
+		{constraint: "1.2.3", allows: []string{"1.2.3", "v1.2.3"}, rejects: []string{"1.2.4", "1.2.2"}},
+		{constraint: "=1.2.3", allows: []string{"1.2.3"}, rejects: []string{"1.3.0"}},
+		{constraint: ">=1.2.0 <2.0.0", allows: []string{"1.2.0", "1.9.9"}, rejects: []string{"1.1.9", "2.0.0"}},
+		{constraint: ">=1.2.0, <2.0.0", allows: []string{"1.5.0"}, rejects: []string{"2.0.0"}},
+		{constraint: ">= 1.2.0", allows: []string{"1.2.0"}, rejects: []string{"1.1.0"}},
+		{constraint: ">1.0.0 <=1.5.0", allows: []string{"1.0.1", "1.5.0"}, rejects: []string{"1.0.0", "1.5.1"}},
+		{constraint: "^1.2.0", allows: []string{"1.2.0", "1.9.0"}, rejects: []string{"1.1.9", "2.0.0"}},
+		{constraint: "^0.2.3", allows: []string{"0.2.3", "0.2.9"}, rejects: []string{"0.3.0", "0.2.2"}},
+		{constraint: "^0.0.3", allows: []string{"0.0.3"}, rejects: []string{"0.0.4"}},
+		{constraint: "~1.2.0", allows: []string{"1.2.0", "1.2.9"}, rejects: []string{"1.3.0", "1.1.0"}},
+		{constraint: "^1.0.0", allows: []string{"1.0.1-rc.1"}, rejects: []string{"1.0.0-rc.1"}},
+		{constraint: "!=1.0.0", wantError: true},
+		{constraint: ">=abc", wantError: true},
+		{constraint: "^", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			constraint, err := ParseVersionConstraint(tt.constraint)
+			if tt.wantError {
+				if err == nil {
+					t.Fatalf("ParseVersionConstraint(%q) succeeded, want error", tt.constraint)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseVersionConstraint(%q) error: %v", tt.constraint, err)
+			}
+			check := func(version string, want bool) {
+				v, err := ParseSemVer(version)
+				if err != nil {
+					t.Fatalf("ParseSemVer(%q) error: %v", version, err)
+				}
+				if got := constraint.Allows(v); got != want {
+					t.Errorf("%q allows %s = %v, want %v", tt.constraint, version, got, want)
+				}
+			}
+			for _, version := range tt.allows {
+				check(version, true)
+			}
+			for _, version := range tt.rejects {
+				check(version, false)
+			}
+		})
+	}
+}
+
+// depsTestPlugin 只提供名称、版本和依赖声明的测试插件
+type depsTestPlugin struct {
+	metadata PluginMetadata
+}
+
+func (p *depsTestPlugin) GetName() string        { return p.metadata.Name }
+func (p *depsTestPlugin) GetVersion() string     { return p.metadata.Version }
+func (p *depsTestPlugin) GetDescription() string { return "" }
+func (p *depsTestPlugin) Initialize(ctx context.Context, logger *zap.Logger, config interface{}) error {
+	return nil
+}
+func (p *depsTestPlugin) RegisterRoutes(router *gin.Engine) error           { return nil }
+func (p *depsTestPlugin) Health() (map[string]interface{}, error)           { return nil, nil }
+func (p *depsTestPlugin) Shutdown(ctx context.Context) error                { return nil }
+func (p *depsTestPlugin) GetMetadata() *PluginMetadata                      { return &p.metadata }
+func (p *depsTestPlugin) CanRunStandalone() bool                            { return false }
+func (p *depsTestPlugin) RunStandalone(ctx context.Context, port int) error { return nil }
+
+func TestResolveOrder(t *testing.T) {
+	type spec struct {
+		version string
+		deps    []string
+	}
+	tests := []struct {
+		name      string
+		plugins   map[string]spec
+		want      []string
+		wantErr   error
+		wantCycle string
+	}{
+		{
+			name:    "independent plugins by name",
+			plugins: map[string]spec{"c": {}, "a": {}, "b": {}},
+			want:    []string{"a", "b", "c"},
+		},
+		{
+			name: "dependencies first",
+			plugins: map[string]spec{
+				"api":     {deps: []string{"iam ^1.0.0", "db"}},
+				"iam":     {version: "1.4.0", deps: []string{"db >=2.0.0"}},
+				"db":      {version: "2.1.0"},
+				"billing": {},
+			},
+			want: []string{"billing", "db", "iam", "api"},
+		},
+		{
+			name:    "missing dependency",
+			plugins: map[string]spec{"api": {deps: []string{"iam"}}},
+			wantErr: ErrUnsatisfiedDependency,
+		},
+		{
+			name:    "version not satisfied",
+			plugins: map[string]spec{"api": {deps: []string{"iam ^2.0.0"}}, "iam": {version: "1.4.0"}},
+			wantErr: ErrUnsatisfiedDependency,
+		},
+		{
+			name:    "invalid declaration",
+			plugins: map[string]spec{"api": {deps: []string{"iam ^x"}}, "iam": {version: "1.0.0"}},
+			wantErr: ErrUnsatisfiedDependency,
+		},
+		{
+			name: "cycle",
+			plugins: map[string]spec{
+				"a":    {deps: []string{"b"}},
+				"b":    {deps: []string{"c"}},
+				"c":    {deps: []string{"a"}},
+				"d":    {deps: []string{"a"}},
+				"base": {},
+			},
+			want:      []string{"base"},
+			wantErr:   ErrDependencyCycle,
+			wantCycle: "a -> b -> c -> a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugins := make(map[string]Plugin, len(tt.plugins))
+			for name, s := range tt.plugins {
+				version := s.version
+				if version == "" {
+					version = "1.0.0"
+				}
+				plugins[name] = &depsTestPlugin{metadata: PluginMetadata{Name: name, Version: version, Dependencies: s.deps}}
+			}
+
+			order, err := resolveOrder(plugins)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("resolveOrder() error = %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantCycle != "" && !strings.Contains(err.Error(), tt.wantCycle) {
+					t.Errorf("resolveOrder() error = %v, want cycle %s", err, tt.wantCycle)
+				}
+			} else if err != nil {
+				t.Fatalf("resolveOrder() error: %v", err)
+			}
+			if tt.want != nil && !reflect.DeepEqual(order, tt.want) {
+				t.Fatalf("resolveOrder() = %v, want %v", order, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package plugin
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+// DiscoveryReport 启动时加载已安装插件的结果
+type DiscoveryReport struct {
+	// Loaded 已加载的插件名称到插件文件名的映射
+	Loaded map[string]string
+
+	// Skipped 未加载的插件文件及原因（平台不匹配、版本不兼容或已有更高版本）
+	Skipped map[string]string
+
+	// Failed 读取或加载失败的插件文件及错误
+	Failed map[string]error
+}
+
+// discoveredPackage 启动时发现的候选插件包
+type discoveredPackage struct {
+	// filename 插件文件名
+	filename string
+
+	// version 插件版本，hasVersion为false时无效
+	version SemVer
+
+	// hasVersion 版本是否为有效的语义化版本
+	hasVersion bool
+}
+
+// LoadInstalledPlugins 加载vpks目录中已安装的插件
+// 每个插件只加载适用于当前平台且与网关兼容的最高版本，最高版本加载失败时依次尝试较低版本
+// 已加载的同名插件不会被替换；单个插件失败不影响其他插件，失败原因记录在结果中
+// 返回: 加载结果和错误信息（仅在无法读取vpks目录时返回错误）
+func (m *Manager) LoadInstalledPlugins() (*DiscoveryReport, error) {
+	m.ops.Lock()
+	defer m.ops.Unlock()
+
+	if m.loader == nil {
+		return nil, fmt.Errorf("plugin loader not set")
+	}
+
+	files, err := m.installer.ListInstalledPlugins()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list installed plugins: %w", err)
+	}
+
+	report := &DiscoveryReport{
+		Loaded:  make(map[string]string),
+		Skipped: make(map[string]string),
+		Failed:  make(map[string]error),
+	}
+	candidates := m.discoverPackages(files, report)
+
+	names := make([]string, 0, len(candidates))
+	for name := range candidates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		packages := candidates[name]
+		if _, exists := m.GetPlugin(name); exists {
+			for _, pkg := range packages {
+				report.Skipped[pkg.filename] = fmt.Sprintf("插件 %s 已加载", name)
+			}
+			continue
+		}
+
+		for i, pkg := range packages {
+			plugin, err := m.loader.LoadPlugin(filepath.Join(m.installer.vpksDir, pkg.filename))
+			if err != nil {
+				report.Failed[pkg.filename] = err
+				m.setState(name, StateFailed, err)
+				m.logger.Error("Failed to load installed plugin",
+					zap.String("name", name),
+					zap.String("filename", pkg.filename),
+					zap.Error(err))
+				continue
+			}
+
+			m.mu.Lock()
+			m.plugins[name] = plugin
+			m.mu.Unlock()
+			m.setState(name, StateLoaded, nil)
+			report.Loaded[name] = pkg.filename
+			m.logger.Info("Installed plugin loaded",
+				zap.String("name", name),
+				zap.String("version", plugin.GetVersion()),
+				zap.String("filename", pkg.filename))
+
+			for _, older := range packages[i+1:] {
+				report.Skipped[older.filename] = fmt.Sprintf("已加载更高版本 %s", pkg.filename)
+			}
+			break
+		}
+	}
+
+	for filename, reason := range report.Skipped {
+		m.logger.Info("Installed plugin skipped",
+			zap.String("filename", filename),
+			zap.String("reason", reason))
+	}
+
+	return report, nil
+}
+
+// discoverPackages 读取插件包清单，按插件名称分组并按版本从高到低排序
+// 不适用于当前平台或与网关不兼容的包记录为跳过，无法读取清单的包记录为失败
+// 调用方需持有ops
+// files: vpks目录中的插件文件名
+// report: 加载结果
+// 返回: 插件名称到候选插件包的映射
+func (m *Manager) discoverPackages(files []string, report *DiscoveryReport) map[string][]discoveredPackage {
+	current := CurrentPlatform()
+	candidates := make(map[string][]discoveredPackage)
+
+	for _, filename := range files {
+		manifest, _, err := ReadManifestFromVKP(filepath.Join(m.installer.vpksDir, filename))
+		if err != nil {
+			report.Failed[filename] = fmt.Errorf("读取插件清单失败: %w", err)
+			m.logger.Error("Failed to read installed plugin manifest",
+				zap.String("filename", filename),
+				zap.Error(err))
+			continue
+		}
+		if !manifest.Platform.Supports(current) {
+			report.Skipped[filename] = fmt.Sprintf("插件平台 %s 与网关平台 %s 不匹配", manifest.Platform, current)
+			continue
+		}
+		if err := CheckCompatibility(&manifest.Plugin); err != nil {
+			report.Skipped[filename] = err.Error()
+			continue
+		}
+
+		pkg := discoveredPackage{filename: filename}
+		// 旧版包的清单可能没有版本，回退到文件名中的版本
+		version, err := ParseSemVer(manifest.Plugin.Version)
+		if err != nil {
+			if info, infoErr := m.installer.parsePluginInfo(filename); infoErr == nil {
+				version, err = info.SemVer()
+			}
+		}
+		if err == nil {
+			pkg.version, pkg.hasVersion = version, true
+		}
+
+		name := manifest.Plugin.Name
+		candidates[name] = append(candidates[name], pkg)
+	}
+
+	for _, packages := range candidates {
+		sort.SliceStable(packages, func(a, b int) bool {
+			pa, pb := packages[a], packages[b]
+			if pa.hasVersion != pb.hasVersion {
+				return pa.hasVersion
+			}
+			if pa.hasVersion {
+				if c := pa.version.Compare(pb.version); c != 0 {
+					return c > 0
+				}
+			}
+			return pa.filename > pb.filename
+		})
+	}
+	return candidates
+}
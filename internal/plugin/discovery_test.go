@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestLoadInstalledPluginsContinuesPastFailures(t *testing.T) {
+	setGatewayVersion(t, "v1.0.0")
+	current := CurrentPlatform()
+	foreign := Platform{OS: "plan9", Arch: current.Arch}
+	if current.OS == foreign.OS {
+		foreign.OS = "linux"
+	}
+
+	vpksDir := t.TempDir()
+	for _, metadata := range []PluginMetadata{
+		{Name: "demo", Version: "1.0.0"},
+		{Name: "demo", Version: "1.1.0"},
+		{Name: "demo", Version: "1.2.0"},
+		{Name: "future", Version: "1.0.0", MinGatewayVersion: "9.0.0"},
+		{Name: "preloaded", Version: "2.0.0"},
+		{Name: "api", Version: "1.0.0"},
+		{Name: "web", Version: "1.0.0", Dependencies: []string{"api ^1.0.0"}},
+	} {
+		writeTestPackageMetadata(t, vpksDir, metadata, current, nil)
+	}
+	foreignPath, _ := writeTestPackage(t, vpksDir, "foreign", "1.0.0", foreign, nil)
+	brokenName := "broken_" + current.OS + "_" + current.Arch + "_v1.0.0.vkp"
+	if err := os.WriteFile(filepath.Join(vpksDir, brokenName), []byte("not a package"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pkg := func(name, version string) string {
+		return name + "_" + current.OS + "_" + current.Arch + "_v" + version + ".vkp"
+	}
+
+	m := NewManager(zap.NewNop(), vpksDir)
+	loader := newTestLoader("api@1.0.0")
+	loader.failLoad = map[string]bool{"demo@1.2.0": true}
+	m.SetLoader(loader)
+	if err := m.RegisterPlugin(&depsTestPlugin{metadata: PluginMetadata{Name: "preloaded", Version: "1.0.0"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := m.LoadInstalledPlugins()
+	if err != nil {
+		t.Fatalf("LoadInstalledPlugins() error: %v", err)
+	}
+
+	// 最高版本加载失败时回退到次高版本
+	wantLoaded := map[string]string{"demo": pkg("demo", "1.1.0"), "api": pkg("api", "1.0.0"), "web": pkg("web", "1.0.0")}
+	if !reflect.DeepEqual(report.Loaded, wantLoaded) {
+		t.Errorf("Loaded = %v, want %v", report.Loaded, wantLoaded)
+	}
+	wantSkipped := []string{filepath.Base(foreignPath), pkg("demo", "1.0.0"), pkg("future", "1.0.0"), pkg("preloaded", "2.0.0")}
+	sort.Strings(wantSkipped)
+	if got := sortedKeys(report.Skipped); !reflect.DeepEqual(got, wantSkipped) {
+		t.Errorf("Skipped = %v, want %q", report.Skipped, wantSkipped)
+	}
+	failed := make(map[string]string)
+	for filename, err := range report.Failed {
+		failed[filename] = err.Error()
+	}
+	if got := sortedKeys(failed); !reflect.DeepEqual(got, []string{brokenName, pkg("demo", "1.2.0")}) {
+		t.Errorf("Failed = %v", report.Failed)
+	}
+	if p, _ := m.GetPlugin("preloaded"); p.GetVersion() != "1.0.0" {
+		t.Errorf("preloaded plugin replaced by %s", p.GetVersion())
+	}
+
+	// 初始化失败的插件及依赖它的插件被卸载，其余插件继续运行
+	err = m.InitializeAll(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "plugin 'api'") || !strings.Contains(err.Error(), "plugin 'web'") {
+		t.Fatalf("InitializeAll() error = %v, want failures for api and web", err)
+	}
+	for name, want := range map[string]bool{"demo": true, "preloaded": true, "api": false, "web": false} {
+		if _, loaded := m.GetPlugin(name); loaded != want {
+			t.Errorf("plugin %s loaded = %v, want %v", name, loaded, want)
+		}
+	}
+	if state, _ := m.states.state("demo"); state != StateRunning {
+		t.Errorf("demo state = %s, want running", state)
+	}
+	if state, _ := m.states.state("api"); state != StateFailed {
+		t.Errorf("api state = %s, want failed", state)
+	}
+}
+
+func TestLoadInstalledPluginsWithoutLoader(t *testing.T) {
+	m := NewManager(zap.NewNop(), t.TempDir())
+	if _, err := m.LoadInstalledPlugins(); err == nil {
+		t.Fatal("LoadInstalledPlugins() without a loader succeeded")
+	}
+}
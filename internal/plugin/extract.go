@@ -0,0 +1,333 @@
+package plugin
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// defaultMaxExtractFileSize 单个文件解压后的默认大小上限
+	defaultMaxExtractFileSize = 512 << 20
+	
+	// defaultMaxExtractTotalSize 解压后总大小的默认上限
+	defaultMaxExtractTotalSize = 1 << 30
+	
+	// defaultMaxExtractEntries 归档条目数量的默认上限
+	defaultMaxExtractEntries = 10000
+)
+
+// ExtractLimits VKP解压限制，防止解压炸弹
+type ExtractLimits struct {
+	// MaxFileSize 单个文件解压后的大小上限（字节）
+	MaxFileSize int64
+	
+	// MaxTotalSize 所有文件解压后的总大小上限（字节）
+	MaxTotalSize int64
+	
+	// MaxEntries 归档条目数量上限
+	MaxEntries int
+}
+
+// DefaultExtractLimits 返回默认解压限制
+// 返回: 解压限制
+func DefaultExtractLimits() ExtractLimits {
+	return ExtractLimits{
+		MaxFileSize:  defaultMaxExtractFileSize,
+		MaxTotalSize: defaultMaxExtractTotalSize,
+		MaxEntries:   defaultMaxExtractEntries,
+	}
+}
+
+// withDefaults 填充未设置的限制
+// 返回: 补全后的解压限制
+func (l ExtractLimits) withDefaults() ExtractLimits {
+	if l.MaxFileSize <= 0 {
+		l.MaxFileSize = defaultMaxExtractFileSize
+	}
+	if l.MaxTotalSize <= 0 {
+		l.MaxTotalSize = defaultMaxExtractTotalSize
+	}
+	if l.MaxEntries <= 0 {
+		l.MaxEntries = defaultMaxExtractEntries
+	}
+	return l
+}
+
+// extractVKPToTemp 将VKP包解压到parentDir下新建的唯一临时目录
+// 解压失败时删除该目录
+// vkpPath: VKP文件路径
+// parentDir: 临时目录的父目录
+// limits: 解压限制
+// 返回: 解压目录和错误信息
+func extractVKPToTemp(vkpPath, parentDir string, limits ExtractLimits) (string, error) {
+	if err := os.MkdirAll(parentDir, 0755); err != nil {
+		return "", err
+	}
+	
+	pattern := strings.TrimSuffix(filepath.Base(vkpPath), filepath.Ext(vkpPath)) + "-*"
+	extractDir, err := os.MkdirTemp(parentDir, pattern)
+	if err != nil {
+		return "", err
+	}
+	
+	if err := extractVKPArchive(vkpPath, extractDir, limits); err != nil {
+		os.RemoveAll(extractDir)
+		return "", err
+	}
+	return extractDir, nil
+}
+
+// extractVKPArchive 安全解压VKP包（tar.gz）
+// 拒绝绝对路径、跳出解压目录的路径、指向目录外的符号链接和硬链接以及设备文件，
+// 限制单文件和总大小，并保留tar头中的可执行权限
+// vkpPath: VKP文件路径
+// extractDir: 解压目录（应为新建的空目录）
+// limits: 解压限制
+// 返回: 错误信息
+func extractVKPArchive(vkpPath, extractDir string, limits ExtractLimits) error {
+	limits = limits.withDefaults()
+	
+	file, err := os.Open(vkpPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	
+	gzr, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gzr.Close()
+	
+	tr := tar.NewReader(gzr)
+	var total int64
+	entries := 0
+	var symlinks []string
+	
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		
+		entries++
+		if entries > limits.MaxEntries {
+			return fmt.Errorf("archive has more than %d entries", limits.MaxEntries)
+		}
+		
+		name, err := sanitizeEntryName(header.Name)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			// 归档根目录（如"./"）
+			continue
+		}
+		
+		target := filepath.Join(extractDir, filepath.FromSlash(name))
+		if err := ensureNoSymlinkParents(extractDir, name); err != nil {
+			return err
+		}
+		
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		
+		case tar.TypeReg:
+			if header.Size < 0 || header.Size > limits.MaxFileSize {
+				return fmt.Errorf("%s: file size %d exceeds limit %d", name, header.Size, limits.MaxFileSize)
+			}
+			if total+header.Size > limits.MaxTotalSize {
+				return fmt.Errorf("archive exceeds total size limit %d", limits.MaxTotalSize)
+			}
+			
+			written, err := writeArchiveFile(tr, target, header, limits.MaxFileSize)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			total += written
+		
+		case tar.TypeSymlink:
+			if err := checkSymlinkTarget(name, header.Linkname); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+			symlinks = append(symlinks, name)
+		
+		case tar.TypeLink:
+			linkName, err := sanitizeEntryName(header.Linkname)
+			if err != nil || linkName == "" {
+				return fmt.Errorf("%s: hardlink target %q is outside the package", name, header.Linkname)
+			}
+			if err := ensureNoSymlinkParents(extractDir, linkName); err != nil {
+				return err
+			}
+			
+			linkTarget := filepath.Join(extractDir, filepath.FromSlash(linkName))
+			info, err := os.Lstat(linkTarget)
+			if err != nil {
+				return fmt.Errorf("%s: hardlink target %s: %w", name, linkName, err)
+			}
+			if !info.Mode().IsRegular() {
+				return fmt.Errorf("%s: hardlink target %s is not a regular file", name, linkName)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Link(linkTarget, target); err != nil {
+				return err
+			}
+		
+		case tar.TypeXGlobalHeader:
+			// PAX全局头不对应文件
+		
+		default:
+			return fmt.Errorf("%s: unsupported entry type %q", name, header.Typeflag)
+		}
+	}
+	
+	// 逐条检查只能识别单个链接，链接之间相互引用时需要解析后再确认
+	return verifySymlinks(extractDir, symlinks)
+}
+
+// verifySymlinks 解析解压出的符号链接，确认最终指向包内
+// root: 解压目录
+// names: 符号链接的包内路径
+// 返回: 错误信息
+func verifySymlinks(root string, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	
+	for _, name := range names {
+		resolved, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(name)))
+		if err != nil {
+			return fmt.Errorf("%s: cannot resolve symlink: %w", name, err)
+		}
+		rel, err := filepath.Rel(realRoot, resolved)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s: symlink resolves outside the package", name)
+		}
+	}
+	return nil
+}
+
+// writeArchiveFile 写入归档中的普通文件
+// r: 归档读取器
+// target: 目标路径
+// header: tar头
+// maxSize: 文件大小上限
+// 返回: 写入字节数和错误信息
+func writeArchiveFile(r io.Reader, target string, header *tar.Header, maxSize int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+	
+	// 保留可执行位，去掉setuid/setgid以及组和其他用户的写权限
+	perm := os.FileMode(header.Mode).Perm()&0755 | 0600
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return 0, err
+	}
+	
+	// 多读一个字节以识别tar头中声明的大小与实际内容不符的情况
+	written, err := io.Copy(out, io.LimitReader(r, maxSize+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return written, err
+	}
+	if written > maxSize {
+		return written, fmt.Errorf("file exceeds size limit %d", maxSize)
+	}
+	
+	// umask可能去掉了可执行位
+	if err := os.Chmod(target, perm); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+// sanitizeEntryName 规范化归档条目名称
+// name: 条目名称
+// 返回: 规范化后的相对路径（归档根目录返回空字符串）和错误信息
+func sanitizeEntryName(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("archive entry with empty name")
+	}
+	if strings.Contains(name, "\\") || path.IsAbs(name) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("archive entry %q: absolute or non-portable path", name)
+	}
+	
+	clean := path.Clean(name)
+	if clean == "." {
+		return "", nil
+	}
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("archive entry %q escapes the package", name)
+	}
+	return clean, nil
+}
+
+// checkSymlinkTarget 确认符号链接指向包内
+// name: 链接的包内路径
+// linkname: 链接目标
+// 返回: 错误信息
+func checkSymlinkTarget(name, linkname string) error {
+	if linkname == "" || path.IsAbs(linkname) || filepath.IsAbs(linkname) || strings.Contains(linkname, "\\") {
+		return fmt.Errorf("%s: symlink target %q is outside the package", name, linkname)
+	}
+	
+	resolved := path.Join(path.Dir(name), linkname)
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return fmt.Errorf("%s: symlink target %q is outside the package", name, linkname)
+	}
+	return nil
+}
+
+// ensureNoSymlinkParents 确认条目的上级目录都不是符号链接
+// 防止先创建指向其他位置的链接再经由该链接写入文件
+// root: 解压目录
+// name: 规范化后的包内路径
+// 返回: 错误信息
+func ensureNoSymlinkParents(root, name string) error {
+	current := root
+	parts := strings.Split(name, "/")
+	for _, part := range parts[:len(parts)-1] {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("archive entry %q is located under a symlink", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package plugin
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testArchiveEntry 测试归档中的条目
+type testArchiveEntry struct {
+	name     string
+	typeflag byte
+	body     string
+	linkname string
+	mode     int64
+}
+
+// writeTestArchive 将条目写入tar.gz文件
+// 返回: 归档路径
+func writeTestArchive(t *testing.T, entries []testArchiveEntry) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.vkp")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	gzw := gzip.NewWriter(file)
+	tw := tar.NewWriter(gzw)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Linkname: entry.linkname,
+			Mode:     entry.mode,
+		}
+		if header.Typeflag == 0 {
+			header.Typeflag = tar.TypeReg
+		}
+		if header.Mode == 0 {
+			header.Mode = 0644
+		}
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len(entry.body))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(entry.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractVKPArchive(t *testing.T) {
+	tests := []struct {
+		name      string
+		entries   []testArchiveEntry
+		limits    ExtractLimits
+		wantError string
+	}{
+		{
+			name: "regular package",
+			entries: []testArchiveEntry{
+				{name: "./", typeflag: tar.TypeDir, mode: 0755},
+				{name: "bin/", typeflag: tar.TypeDir, mode: 0755},
+				{name: "bin/plugin", body: "#!/bin/sh\n", mode: 0755},
+				{name: "plugin", typeflag: tar.TypeSymlink, linkname: "bin/plugin"},
+				{name: "copy", typeflag: tar.TypeLink, linkname: "bin/plugin"},
+			},
+		},
+		{
+			name:      "absolute path",
+			entries:   []testArchiveEntry{{name: "/tmp/evil", body: "x"}},
+			wantError: "absolute",
+		},
+		{
+			name:      "parent traversal",
+			entries:   []testArchiveEntry{{name: "../evil", body: "x"}},
+			wantError: "escapes",
+		},
+		{
+			name:      "nested traversal",
+			entries:   []testArchiveEntry{{name: "bin/../../evil", body: "x"}},
+			wantError: "escapes",
+		},
+		{
+			name:      "backslash path",
+			entries:   []testArchiveEntry{{name: `..\evil`, body: "x"}},
+			wantError: "non-portable",
+		},
+		{
+			name:      "absolute symlink",
+			entries:   []testArchiveEntry{{name: "link", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"}},
+			wantError: "outside the package",
+		},
+		{
+			name:      "symlink out of package",
+			entries:   []testArchiveEntry{{name: "bin/link", typeflag: tar.TypeSymlink, linkname: "../../evil"}},
+			wantError: "outside the package",
+		},
+		{
+			name: "chained symlinks out of package",
+			entries: []testArchiveEntry{
+				{name: "a/b", typeflag: tar.TypeSymlink, linkname: ".."},
+				{name: "a/c", typeflag: tar.TypeSymlink, linkname: "b/.."},
+			},
+			wantError: "resolves outside",
+		},
+		{
+			name: "write through symlink",
+			entries: []testArchiveEntry{
+				{name: "dir/", typeflag: tar.TypeDir, mode: 0755},
+				{name: "link", typeflag: tar.TypeSymlink, linkname: "dir"},
+				{name: "link/file", body: "x"},
+			},
+			wantError: "under a symlink",
+		},
+		{
+			name:      "hardlink out of package",
+			entries:   []testArchiveEntry{{name: "link", typeflag: tar.TypeLink, linkname: "../evil"}},
+			wantError: "outside the package",
+		},
+		{
+			name:      "device file",
+			entries:   []testArchiveEntry{{name: "dev", typeflag: tar.TypeChar}},
+			wantError: "unsupported entry type",
+		},
+		{
+			name:      "file size limit",
+			entries:   []testArchiveEntry{{name: "big", body: strings.Repeat("x", 17)}},
+			limits:    ExtractLimits{MaxFileSize: 16},
+			wantError: "exceeds limit",
+		},
+		{
+			name: "total size limit",
+			entries: []testArchiveEntry{
+				{name: "a", body: strings.Repeat("x", 10)},
+				{name: "b", body: strings.Repeat("x", 10)},
+			},
+			limits:    ExtractLimits{MaxTotalSize: 16},
+			wantError: "total size limit",
+		},
+		{
+			name: "entry limit",
+			entries: []testArchiveEntry{
+				{name: "a", body: "x"},
+				{name: "b", body: "x"},
+				{name: "c", body: "x"},
+			},
+			limits:    ExtractLimits{MaxEntries: 2},
+			wantError: "more than 2 entries",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := writeTestArchive(t, tt.entries)
+			parent := filepath.Join(t.TempDir(), "extract")
+
+			dir, err := extractVKPToTemp(archive, parent, tt.limits)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("extractVKPToTemp() error = %v, want %q", err, tt.wantError)
+				}
+				if _, err := os.Stat(filepath.Join(filepath.Dir(parent), "evil")); !os.IsNotExist(err) {
+					t.Fatalf("archive wrote outside the extract dir")
+				}
+				if left, _ := os.ReadDir(parent); len(left) != 0 {
+					t.Fatalf("failed extraction left %d entries behind", len(left))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractVKPToTemp() error: %v", err)
+			}
+
+			info, err := os.Stat(filepath.Join(dir, "plugin"))
+			if err != nil {
+				t.Fatalf("symlinked entrypoint missing: %v", err)
+			}
+			if info.Mode().Perm()&0100 == 0 {
+				t.Errorf("entrypoint lost its executable bit: %v", info.Mode())
+			}
+			data, err := os.ReadFile(filepath.Join(dir, "copy"))
+			if err != nil || string(data) != "#!/bin/sh\n" {
+				t.Errorf("hardlinked file = %q, %v", data, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package plugin
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"plugin"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+const (
+	// goPluginFactorySymbol .so插件导出的插件工厂变量名，类型为PluginFactory，优先于NewPlugin使用
+	goPluginFactorySymbol = "PluginFactory"
+
+	// goPluginConstructorSymbol .so插件导出的插件构造函数名，签名为 func() Plugin
+	goPluginConstructorSymbol = "NewPlugin"
+
+	// develVersion 未发布构建的模块版本
+	develVersion = "(devel)"
+)
+
+// goPluginABISettings 影响.so插件与网关二进制兼容性的构建设置
+var goPluginABISettings = []string{"GOOS", "GOARCH", "GOEXPERIMENT", "-race"}
+
+// checkGoPluginABI 在打开.so插件前比较插件与网关的构建信息
+// plugin.Open 遇到不同工具链或依赖版本构建的插件时只报告某个包的哈希不一致，
+// 预检查给出具体的Go版本、构建设置或模块版本差异
+// path: .so文件路径
+// 返回: 不一致时返回包装了ErrIncompatiblePlugin的错误
+func checkGoPluginABI(path string) error {
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%w: 无法读取Go插件 %s 的构建信息: %v", ErrIncompatiblePlugin, path, err)
+	}
+	host, _ := debug.ReadBuildInfo()
+
+	if mismatches := goPluginABIMismatches(info, host); len(mismatches) > 0 {
+		return fmt.Errorf("%w: Go插件 %s 与网关的构建不一致: %s",
+			ErrIncompatiblePlugin, path, strings.Join(mismatches, "; "))
+	}
+	return nil
+}
+
+// goPluginABIMismatches 列出插件与网关构建信息的差异
+// 网关构建信息不可用时只比较Go版本和平台；任一方为未发布版本（devel）的模块不比较版本
+// info: 插件的构建信息
+// host: 网关的构建信息，可为nil
+// 返回: 差异描述列表
+func goPluginABIMismatches(info, host *debug.BuildInfo) []string {
+	var mismatches []string
+	if info.GoVersion != runtime.Version() {
+		mismatches = append(mismatches, fmt.Sprintf("Go版本为 %s，网关为 %s", info.GoVersion, runtime.Version()))
+	}
+
+	settings := buildSettings(info)
+	if mode := settings["-buildmode"]; mode != "" && mode != "plugin" {
+		mismatches = append(mismatches, fmt.Sprintf("构建模式为 %s，应为 -buildmode=plugin", mode))
+	}
+	hostSettings := map[string]string{"GOOS": runtime.GOOS, "GOARCH": runtime.GOARCH}
+	if host != nil {
+		hostSettings = buildSettings(host)
+	}
+	for _, key := range goPluginABISettings {
+		if settings[key] != hostSettings[key] {
+			mismatches = append(mismatches, fmt.Sprintf("构建设置 %s 为 %q，网关为 %q", key, settings[key], hostSettings[key]))
+		}
+	}
+
+	// 插件必须链接网关的插件包，否则无法实现Plugin接口
+	pluginPackage := reflect.TypeOf(PluginMetadata{}).PkgPath()
+	modules := buildModules(info)
+	if host != nil && findModule(modules, pluginPackage) == "" {
+		mismatches = append(mismatches, fmt.Sprintf("未引用网关插件包 %s", pluginPackage))
+	}
+	if host == nil {
+		return mismatches
+	}
+
+	hostModules := buildModules(host)
+	for _, path := range sortedKeys(modules) {
+		hostVersion, shared := hostModules[path]
+		version := modules[path]
+		if !shared || version == hostVersion || version == develVersion || hostVersion == develVersion {
+			continue
+		}
+		mismatches = append(mismatches, fmt.Sprintf("模块 %s 版本为 %s，网关为 %s", path, version, hostVersion))
+	}
+	return mismatches
+}
+
+// buildSettings 提取构建设置
+// info: 构建信息
+// 返回: 设置名称到值的映射
+func buildSettings(info *debug.BuildInfo) map[string]string {
+	settings := make(map[string]string, len(info.Settings))
+	for _, setting := range info.Settings {
+		settings[setting.Key] = setting.Value
+	}
+	return settings
+}
+
+// buildModules 提取主模块和依赖模块的版本，被替换的模块使用替换后的路径和版本
+// info: 构建信息
+// 返回: 模块路径到版本的映射
+func buildModules(info *debug.BuildInfo) map[string]string {
+	modules := make(map[string]string, len(info.Deps)+1)
+	add := func(m *debug.Module) {
+		if m == nil || m.Path == "" {
+			return
+		}
+		version := m.Version
+		if m.Replace != nil {
+			version = strings.TrimSuffix(m.Replace.Path+"@"+m.Replace.Version, "@")
+		}
+		modules[m.Path] = version
+	}
+	add(&info.Main)
+	for _, dep := range info.Deps {
+		add(dep)
+	}
+	return modules
+}
+
+// findModule 查找包所属的模块
+// modules: 模块路径到版本的映射
+// pkg: 包路径
+// 返回: 模块路径，未找到时为空
+func findModule(modules map[string]string, pkg string) string {
+	found := ""
+	for path := range modules {
+		if (pkg == path || strings.HasPrefix(pkg, path+"/")) && len(path) > len(found) {
+			found = path
+		}
+	}
+	return found
+}
+
+// sortedKeys 按字典序返回映射的键
+// m: 映射
+// 返回: 排序后的键
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// newGoPlugin 通过.so插件导出的符号创建插件实例
+// 优先使用PluginFactory变量，以便插件在构造时取得配置；未导出时使用NewPlugin函数
+// p: 已打开的Go插件
+// configFor: 按插件元数据取得经过校验的插件配置
+// 返回: 插件实例和错误信息
+func newGoPlugin(p *plugin.Plugin, configFor func(*PluginMetadata) (interface{}, error)) (Plugin, error) {
+	if sym, err := p.Lookup(goPluginFactorySymbol); err == nil {
+		var factory PluginFactory
+		switch v := sym.(type) {
+		case *PluginFactory:
+			factory = *v
+		case PluginFactory:
+			factory = v
+		}
+		if factory == nil {
+			return nil, fmt.Errorf("%s symbol has type %T, want plugin.PluginFactory", goPluginFactorySymbol, sym)
+		}
+
+		metadata := factory.GetMetadata()
+		if metadata == nil {
+			return nil, fmt.Errorf("plugin factory %s returned no metadata", factory.GetPluginType())
+		}
+		if err := CheckCompatibility(metadata); err != nil {
+			return nil, err
+		}
+		config, err := configFor(metadata)
+		if err != nil {
+			return nil, err
+		}
+		instance, err := factory.CreatePlugin(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create plugin %s: %w", metadata.Name, err)
+		}
+		if instance == nil {
+			return nil, fmt.Errorf("plugin factory %s returned a nil plugin", factory.GetPluginType())
+		}
+		if instance.GetName() != metadata.Name {
+			return nil, fmt.Errorf("plugin factory created plugin %q, but its metadata declares %q", instance.GetName(), metadata.Name)
+		}
+		return instance, nil
+	}
+
+	sym, err := p.Lookup(goPluginConstructorSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("plugin exports neither %s nor %s: %w", goPluginFactorySymbol, goPluginConstructorSymbol, err)
+	}
+	newPluginFunc, ok := sym.(func() Plugin)
+	if !ok {
+		return nil, fmt.Errorf("%s function has wrong signature: %T, want func() plugin.Plugin", goPluginConstructorSymbol, sym)
+	}
+	return newPluginFunc(), nil
+}
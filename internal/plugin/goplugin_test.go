@@ -0,0 +1,200 @@
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+// gatewayModule 网关的模块路径
+const gatewayModule = "github.com/vera-byte/vgo-gateway"
+
+// testBuildInfo 返回构建信息，settings依次为键和值
+func testBuildInfo(main string, deps []*debug.Module, settings ...string) *debug.BuildInfo {
+	info := &debug.BuildInfo{
+		GoVersion: runtime.Version(),
+		Main:      debug.Module{Path: main, Version: develVersion},
+		Deps:      deps,
+	}
+	for i := 0; i+1 < len(settings); i += 2 {
+		info.Settings = append(info.Settings, debug.BuildSetting{Key: settings[i], Value: settings[i+1]})
+	}
+	return info
+}
+
+func TestGoPluginABIMismatches(t *testing.T) {
+	host := func() *debug.BuildInfo {
+		return testBuildInfo(gatewayModule, []*debug.Module{
+			{Path: "github.com/gin-gonic/gin", Version: "v1.9.1"},
+			{Path: "go.uber.org/zap", Version: "v1.27.0"},
+			{Path: "example.com/local", Version: develVersion},
+		}, "-buildmode", "exe", "GOOS", runtime.GOOS, "GOARCH", runtime.GOARCH)
+	}
+	pluginInfo := func() *debug.BuildInfo {
+		return testBuildInfo("example.com/demo", []*debug.Module{
+			{Path: gatewayModule, Version: "v1.2.0"},
+			{Path: "github.com/gin-gonic/gin", Version: "v1.9.1"},
+			{Path: "example.com/local", Version: "v0.3.0"},
+			{Path: "example.com/plugin-only", Version: "v2.0.0"},
+		}, "-buildmode", "plugin", "GOOS", runtime.GOOS, "GOARCH", runtime.GOARCH)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(info, host *debug.BuildInfo) *debug.BuildInfo
+		want   []string
+	}{
+		{
+			name:   "compatible",
+			modify: func(info, host *debug.BuildInfo) *debug.BuildInfo { return host },
+		},
+		{
+			name: "go version",
+			modify: func(info, host *debug.BuildInfo) *debug.BuildInfo {
+				info.GoVersion = "go1.0"
+				return host
+			},
+			want: []string{"Go版本为 go1.0"},
+		},
+		{
+			name: "not built as plugin",
+			modify: func(info, host *debug.BuildInfo) *debug.BuildInfo {
+				info.Settings[0].Value = "exe"
+				return host
+			},
+			want: []string{"构建模式为 exe"},
+		},
+		{
+			name: "race detector",
+			modify: func(info, host *debug.BuildInfo) *debug.BuildInfo {
+				info.Settings = append(info.Settings, debug.BuildSetting{Key: "-race", Value: "true"})
+				return host
+			},
+			want: []string{"-race"},
+		},
+		{
+			name: "goexperiment",
+			modify: func(info, host *debug.BuildInfo) *debug.BuildInfo {
+				host.Settings = append(host.Settings, debug.BuildSetting{Key: "GOEXPERIMENT", Value: "arenas"})
+				return host
+			},
+			want: []string{"GOEXPERIMENT"},
+		},
+		{
+			name: "does not import the gateway",
+			modify: func(info, host *debug.BuildInfo) *debug.BuildInfo {
+				info.Deps = info.Deps[1:]
+				return host
+			},
+			want: []string{"未引用网关插件包 " + gatewayModule + "/internal/plugin"},
+		},
+		{
+			name: "shared module version",
+			modify: func(info, host *debug.BuildInfo) *debug.BuildInfo {
+				info.Deps[1].Version = "v1.9.0"
+				return host
+			},
+			want: []string{"模块 github.com/gin-gonic/gin 版本为 v1.9.0，网关为 v1.9.1"},
+		},
+		{
+			name: "replaced module",
+			modify: func(info, host *debug.BuildInfo) *debug.BuildInfo {
+				info.Deps[1].Replace = &debug.Module{Path: "../gin"}
+				return host
+			},
+			want: []string{"模块 github.com/gin-gonic/gin 版本为 ../gin"},
+		},
+		{
+			name: "same replacement",
+			modify: func(info, host *debug.BuildInfo) *debug.BuildInfo {
+				info.Deps[1].Replace = &debug.Module{Path: "example.com/gin", Version: "v1.9.2"}
+				host.Deps[0].Replace = &debug.Module{Path: "example.com/gin", Version: "v1.9.2"}
+				return host
+			},
+		},
+		{
+			name: "several mismatches",
+			modify: func(info, host *debug.BuildInfo) *debug.BuildInfo {
+				info.GoVersion = "go1.0"
+				info.Deps[1].Version = "v1.8.0"
+				return host
+			},
+			want: []string{"Go版本", "github.com/gin-gonic/gin"},
+		},
+		{
+			name: "host build info unavailable",
+			modify: func(info, host *debug.BuildInfo) *debug.BuildInfo {
+				info.Deps = nil
+				return nil
+			},
+		},
+		{
+			name: "host build info unavailable checks platform",
+			modify: func(info, host *debug.BuildInfo) *debug.BuildInfo {
+				info.Settings[1].Value = "plan9"
+				return nil
+			},
+			want: []string{"构建设置 GOOS 为 \"plan9\""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := pluginInfo()
+			mismatches := goPluginABIMismatches(info, tt.modify(info, host()))
+			if len(mismatches) != len(tt.want) {
+				t.Fatalf("mismatches = %q, want %d", mismatches, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(mismatches[i], want) {
+					t.Errorf("mismatch %d = %q, want %q", i, mismatches[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestFindModule(t *testing.T) {
+	modules := map[string]string{
+		"example.com/a":   "v1.0.0",
+		"example.com/a/b": "v2.0.0",
+		"example.com/abc": "v3.0.0",
+		gatewayModule:     develVersion,
+	}
+	tests := map[string]string{
+		"example.com/a":             "example.com/a",
+		"example.com/a/x":           "example.com/a",
+		"example.com/a/b/c":         "example.com/a/b",
+		"example.com/abc/d":         "example.com/abc",
+		"example.com/ab":            "",
+		gatewayModule + "/internal": gatewayModule,
+	}
+	for pkg, want := range tests {
+		if got := findModule(modules, pkg); got != want {
+			t.Errorf("findModule(%s) = %q, want %q", pkg, got, want)
+		}
+	}
+}
+
+func TestCheckGoPluginABI(t *testing.T) {
+	notGo := filepath.Join(t.TempDir(), "demo.so")
+	if err := os.WriteFile(notGo, []byte("not an object file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkGoPluginABI(notGo); !errors.Is(err, ErrIncompatiblePlugin) || !strings.Contains(err.Error(), "构建信息") {
+		t.Fatalf("checkGoPluginABI(not go) error = %v", err)
+	}
+
+	// 测试二进制本身是普通可执行文件而不是插件
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = checkGoPluginABI(exe)
+	if !errors.Is(err, ErrIncompatiblePlugin) || !strings.Contains(err.Error(), "-buildmode=plugin") {
+		t.Fatalf("checkGoPluginABI(test binary) error = %v", err)
+	}
+}
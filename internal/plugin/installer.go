@@ -2,6 +2,9 @@ package plugin
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,11 +13,35 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
-	"time"
 
 	"go.uber.org/zap"
 )
 
+const (
+	// DefaultMaxDownloadSize 插件包默认下载大小上限
+	DefaultMaxDownloadSize = 512 << 20
+	
+	// DefaultArchiveVersions 每个服务默认保留的历史版本数
+	DefaultArchiveVersions = 3
+)
+
+var (
+	// ErrInvalidChecksum 指定的SHA-256校验和格式错误
+	ErrInvalidChecksum = errors.New("invalid package checksum")
+	
+	// ErrChecksumMismatch 插件包内容与指定的SHA-256校验和不符
+	ErrChecksumMismatch = errors.New("package checksum mismatch")
+	
+	// ErrPackageTooLarge 插件包超过大小上限
+	ErrPackageTooLarge = errors.New("package too large")
+	
+	// ErrInvalidPackageName 插件包文件名不符合 <服务名称>_<平台架构>_<版本>.vkp 格式
+	ErrInvalidPackageName = errors.New("invalid package filename")
+	
+	// ErrPackageMismatch 插件包文件名中的服务名称或版本与清单不一致
+	ErrPackageMismatch = errors.New("package filename does not match manifest")
+)
+
 // PluginInstaller 插件安装器
 // 支持从网络下载和安装插件
 type PluginInstaller struct {
@@ -26,6 +53,27 @@ type PluginInstaller struct {
 	
 	// httpClient HTTP客户端
 	httpClient *http.Client
+	
+	// verifier VKP包签名校验器
+	verifier *SignatureVerifier
+	
+	// maxDownloadSize 插件包下载大小上限（字节）
+	maxDownloadSize int64
+	
+	// archiveVersions 每个服务在归档目录中保留的历史版本数
+	archiveVersions int
+	
+	// registry 插件仓库客户端，未配置时为nil
+	registry *RegistryClient
+}
+
+// InstallOptions 插件安装选项
+type InstallOptions struct {
+	// SHA256 期望的包SHA-256（十六进制），为空时不校验
+	SHA256 string
+	
+	// Force 是否允许安装低于已安装版本的插件
+	Force bool
 }
 
 // PluginInfo 插件信息
@@ -60,16 +108,142 @@ func NewPluginInstaller(vpksDir string, logger *zap.Logger) *PluginInstaller {
 	return &PluginInstaller{
 		vpksDir: vpksDir,
 		logger:  logger,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		// 不设置整体超时，下载时长由调用方的上下文控制，避免大包在慢速网络下被截断
+		httpClient:      &http.Client{},
+		verifier:        NewSignatureVerifier(SignaturePolicyWarn, nil, logger),
+		maxDownloadSize: DefaultMaxDownloadSize,
+		archiveVersions: DefaultArchiveVersions,
+	}
+}
+
+// SetMaxDownloadSize 设置插件包下载大小上限
+// 同时限制上传和本地文件安装
+// size: 大小上限（字节），小于等于0时使用默认值
+func (i *PluginInstaller) SetMaxDownloadSize(size int64) {
+	if size <= 0 {
+		size = DefaultMaxDownloadSize
+	}
+	i.maxDownloadSize = size
+}
+
+// MaxDownloadSize 返回插件包大小上限
+// 返回: 大小上限（字节）
+func (i *PluginInstaller) MaxDownloadSize() int64 {
+	return i.maxDownloadSize
+}
+
+// SetSignatureVerifier 设置VKP包签名校验器
+// verifier: 签名校验器
+func (i *PluginInstaller) SetSignatureVerifier(verifier *SignatureVerifier) {
+	i.verifier = verifier
+}
+
+// SetRegistry 设置插件仓库客户端
+// registry: 仓库客户端，nil表示不使用仓库
+func (i *PluginInstaller) SetRegistry(registry *RegistryClient) {
+	i.registry = registry
+}
+
+// InstallByName 按名称从插件仓库安装插件
+// 从仓库索引中选择满足版本范围且适用于当前平台的最高版本，下载后按索引中的SHA-256校验
+// ctx: 上下文
+// name: 插件名称
+// versionConstraint: 版本范围，如 ^1.2.0、>=1.0.0 <2.0.0，为空表示最新版本
+// opts: 安装选项，未指定SHA256时使用索引中的值
+// 返回: 本地文件路径和错误信息
+func (i *PluginInstaller) InstallByName(ctx context.Context, name, versionConstraint string, opts InstallOptions) (string, error) {
+	if i.registry == nil {
+		return "", ErrRegistryNotConfigured
+	}
+	constraint, err := ParseVersionConstraint(versionConstraint)
+	if err != nil {
+		return "", fmt.Errorf("无效的版本范围: %w", err)
+	}
+	
+	index, err := i.registry.FetchIndex(ctx)
+	if err != nil {
+		return "", err
+	}
+	match, err := index.Resolve(name, constraint, CurrentPlatform())
+	if err != nil {
+		return "", err
+	}
+	packageURL, err := i.registry.resolveURL(match.Package.URL)
+	if err != nil {
+		return "", err
+	}
+	if opts.SHA256 == "" {
+		opts.SHA256 = match.Package.SHA256
+	}
+	
+	filename := match.Filename()
+	i.logger.Info("从插件仓库解析到插件包", 
+		zap.String("name", name),
+		zap.String("constraint", constraint.String()),
+		zap.String("version", match.Version.String()),
+		zap.String("platform", match.Package.Platform.String()),
+		zap.String("url", packageURL.String()))
+	
+	// 本地仓库的插件包直接读取，远程插件包按URL下载
+	if packageURL.Scheme == "file" {
+		file, err := os.Open(filepath.FromSlash(packageURL.Path))
+		if err != nil {
+			return "", fmt.Errorf("打开插件文件失败: %w", err)
+		}
+		defer file.Close()
+		return i.InstallFromReader(filename, file, opts)
+	}
+	return i.installFromURL(ctx, packageURL.String(), filename, opts)
+}
+
+// AvailablePlugins 列出插件仓库中适用于当前平台的插件及其已安装版本
+// ctx: 上下文
+// 返回: 可用插件列表和错误信息
+func (i *PluginInstaller) AvailablePlugins(ctx context.Context) ([]AvailablePlugin, error) {
+	if i.registry == nil {
+		return nil, ErrRegistryNotConfigured
 	}
+	index, err := i.registry.FetchIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	
+	available := index.Available(CurrentPlatform())
+	for n := range available {
+		filename, err := i.findInstalledPackage(available[n].Name)
+		if err != nil || filename == "" {
+			continue
+		}
+		if manifest, _, err := ReadManifestFromVKP(filepath.Join(i.vpksDir, filename)); err == nil {
+			available[n].Installed = manifest.Plugin.Version
+		}
+	}
+	return available, nil
 }
 
 // InstallFromURL 从URL安装插件
+// 先下载到vpks目录下的临时文件，校验大小、SHA-256、清单和签名后再原子替换到目标路径
+// ctx: 上下文
 // pluginURL: 插件下载URL
+// opts: 安装选项
 // 返回: 本地文件路径和错误信息
-func (i *PluginInstaller) InstallFromURL(ctx context.Context, pluginURL string) (string, error) {
+func (i *PluginInstaller) InstallFromURL(ctx context.Context, pluginURL string, opts InstallOptions) (string, error) {
+	// 解析文件名
+	filename, err := i.extractFilename(pluginURL)
+	if err != nil {
+		return "", fmt.Errorf("无法解析文件名: %w", err)
+	}
+	
+	return i.installFromURL(ctx, pluginURL, filename, opts)
+}
+
+// installFromURL 从URL下载插件并以指定文件名安装
+// ctx: 上下文
+// pluginURL: 插件下载URL
+// filename: 安装后的插件文件名
+// opts: 安装选项
+// 返回: 本地文件路径和错误信息
+func (i *PluginInstaller) installFromURL(ctx context.Context, pluginURL, filename string, opts InstallOptions) (string, error) {
 	i.logger.Info("开始从URL安装插件", zap.String("url", pluginURL))
 	
 	// 验证URL格式
@@ -77,48 +251,187 @@ func (i *PluginInstaller) InstallFromURL(ctx context.Context, pluginURL string)
 		return "", fmt.Errorf("无效的URL: %w", err)
 	}
 	
-	// 解析文件名
-	filename, err := i.extractFilename(pluginURL)
+	// 验证校验和格式
+	expectedSHA256 := opts.SHA256
+	if err := validateSHA256(expectedSHA256); err != nil {
+		return "", err
+	}
+	
+	// 验证插件文件名格式
+	if err := i.validatePluginFilename(filename); err != nil {
+		return "", err
+	}
+	
+	// 下载到vpks目录下的临时文件，与目标路径位于同一文件系统以便原子替换
+	// 临时文件不以.vkp结尾，不会被列出或加载
+	tmpFile, err := os.CreateTemp(i.vpksDir, "."+filename+".*.tmp")
 	if err != nil {
-		return "", fmt.Errorf("无法解析文件名: %w", err)
+		return "", fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+	
+	// 下载文件
+	size, sum, err := i.downloadFile(ctx, pluginURL, tmpFile)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("下载文件失败: %w", err)
+	}
+	
+	// 校验下载内容的SHA-256
+	if expectedSHA256 != "" && !strings.EqualFold(sum, expectedSHA256) {
+		return "", fmt.Errorf("%w: 期望 %s，实际 %s", ErrChecksumMismatch, strings.ToLower(expectedSHA256), sum)
+	}
+	
+	// 校验清单、签名和文件哈希
+	info, err := i.verifyPackage(pluginURL, filename, tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("插件包校验失败: %w", err)
+	}
+	
+	// 按清单中的版本检查是否为降级
+	if err := i.checkUpgrade(info, opts.Force); err != nil {
+		return "", err
+	}
+	
+	localPath, err := i.installVerified(info, tmpPath)
+	if err != nil {
+		return "", err
+	}
+	
+	i.logger.Info("插件安装成功", 
+		zap.String("url", pluginURL),
+		zap.String("local_path", localPath),
+		zap.Int64("size", size),
+		zap.String("sha256", sum))
+	
+	return localPath, nil
+}
+
+// InstallFromFile 从本地文件安装插件
+// 用于无法访问制品仓库的环境，例如通过命令行安装拷贝到主机上的插件包
+// srcPath: 本地VKP文件路径
+// opts: 安装选项
+// 返回: 安装后的文件路径和错误信息
+func (i *PluginInstaller) InstallFromFile(srcPath string, opts InstallOptions) (string, error) {
+	file, err := os.Open(srcPath)
+	if err != nil {
+		return "", fmt.Errorf("打开插件文件失败: %w", err)
+	}
+	defer file.Close()
+	
+	return i.InstallFromReader(filepath.Base(srcPath), file, opts)
+}
+
+// InstallFromReader 从数据流安装插件
+// 内容先写入vpks目录下的临时文件，校验清单、签名和文件哈希后再原子替换到目标路径
+// filename: 插件文件名
+// r: 插件包内容
+// opts: 安装选项
+// 返回: 本地文件路径和错误信息
+func (i *PluginInstaller) InstallFromReader(filename string, r io.Reader, opts InstallOptions) (string, error) {
+	i.logger.Info("开始从文件安装插件", zap.String("filename", filename))
+	
+	// 文件名只能是单个路径元素
+	if filename == "" || filename != filepath.Base(filename) || strings.ContainsAny(filename, `/\`) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPackageName, filename)
 	}
 	
 	// 验证插件文件名格式
 	if err := i.validatePluginFilename(filename); err != nil {
-		return "", fmt.Errorf("无效的插件文件名: %w", err)
+		return "", err
+	}
+	
+	// 验证校验和格式
+	if err := validateSHA256(opts.SHA256); err != nil {
+		return "", err
 	}
 	
-	// 解析插件信息
-	newPluginInfo, err := i.parsePluginInfo(filename)
+	tmpFile, err := os.CreateTemp(i.vpksDir, "."+filename+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+	
+	// 复制数据，多读一个字节以识别超过上限的内容
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmpFile, hasher), io.LimitReader(r, i.maxDownloadSize+1))
+	if err == nil && size > i.maxDownloadSize {
+		err = fmt.Errorf("%w: 文件大小超过上限 %d", ErrPackageTooLarge, i.maxDownloadSize)
+	}
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
-		i.logger.Warn("无法解析插件信息，继续安装", 
-			zap.String("filename", filename),
-			zap.Error(err))
+		return "", fmt.Errorf("写入插件文件失败: %w", err)
+	}
+	
+	// 校验内容的SHA-256
+	sum := hex.EncodeToString(hasher.Sum(nil))
+	if opts.SHA256 != "" && !strings.EqualFold(sum, opts.SHA256) {
+		return "", fmt.Errorf("%w: 期望 %s，实际 %s", ErrChecksumMismatch, strings.ToLower(opts.SHA256), sum)
+	}
+	
+	// 校验清单、签名和文件哈希
+	info, err := i.verifyPackage(filename, filename, tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("插件包校验失败: %w", err)
+	}
+	
+	// 按清单中的版本检查是否为降级
+	if err := i.checkUpgrade(info, opts.Force); err != nil {
+		return "", err
+	}
+	
+	localPath, err := i.installVerified(info, tmpPath)
+	if err != nil {
+		return "", err
+	}
+	
+	i.logger.Info("插件安装成功", 
+		zap.String("filename", filename),
+		zap.String("local_path", localPath),
+		zap.Int64("size", size),
+		zap.String("sha256", sum))
+	
+	return localPath, nil
+}
+
+// installVerified 将校验通过的临时文件安装到vpks目录
+// 同一服务的其他版本移入归档目录，目标文件通过重命名原子替换
+// newPluginInfo: 由校验通过的清单得到的插件信息
+// tmpPath: 校验通过的临时文件路径（需位于vpks目录）
+// 返回: 本地文件路径和错误信息
+func (i *PluginInstaller) installVerified(newPluginInfo *PluginInfo, tmpPath string) (string, error) {
+	filename := newPluginInfo.Filename
+	
+	// 检查是否存在同一服务的其他版本
+	existingPlugins, err := i.findPluginsByService(newPluginInfo.ServiceName)
+	if err != nil {
+		i.logger.Warn("查找已安装插件失败", zap.Error(err))
 	} else {
-		// 检查是否存在同一服务的其他版本
-		existingPlugins, err := i.findPluginsByService(newPluginInfo.ServiceName)
-		if err != nil {
-			i.logger.Warn("查找已安装插件失败", zap.Error(err))
-		} else if len(existingPlugins) > 0 {
-			// 自动卸载同一服务的旧版本
-			for _, existingPlugin := range existingPlugins {
-				if existingPlugin.Filename != filename {
-					i.logger.Info("检测到同一服务的旧版本，自动卸载", 
-						zap.String("service", newPluginInfo.ServiceName),
-						zap.String("old_version", existingPlugin.Version),
-						zap.String("new_version", newPluginInfo.Version),
-						zap.String("old_filename", existingPlugin.Filename))
-					
-					if err := i.RemovePlugin(existingPlugin.Filename); err != nil {
-						i.logger.Error("卸载旧版本插件失败", 
-							zap.String("filename", existingPlugin.Filename),
-							zap.Error(err))
-						return "", fmt.Errorf("卸载旧版本插件失败: %w", err)
-					}
-					
-					i.logger.Info("旧版本插件卸载成功", 
-						zap.String("filename", existingPlugin.Filename))
-				}
+		// 归档同一服务的旧版本，以便回滚
+		for _, existingPlugin := range existingPlugins {
+			if existingPlugin.Filename == filename {
+				continue
+			}
+			i.logger.Info("检测到同一服务的其他版本，移入归档目录", 
+				zap.String("service", newPluginInfo.ServiceName),
+				zap.String("old_version", existingPlugin.Version),
+				zap.String("new_version", newPluginInfo.Version),
+				zap.String("old_filename", existingPlugin.Filename))
+			
+			if err := i.archivePlugin(existingPlugin.Filename); err != nil {
+				i.logger.Error("归档旧版本插件失败", 
+					zap.String("filename", existingPlugin.Filename),
+					zap.Error(err))
+				return "", fmt.Errorf("归档旧版本插件失败: %w", err)
 			}
 		}
 	}
@@ -131,18 +444,86 @@ func (i *PluginInstaller) InstallFromURL(ctx context.Context, pluginURL string)
 		i.logger.Warn("插件文件已存在，将覆盖", zap.String("path", localPath))
 	}
 	
-	// 下载文件
-	if err := i.downloadFile(ctx, pluginURL, localPath); err != nil {
-		return "", fmt.Errorf("下载文件失败: %w", err)
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		return "", fmt.Errorf("设置插件文件权限失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, localPath); err != nil {
+		return "", fmt.Errorf("移动插件文件失败: %w", err)
 	}
 	
-	i.logger.Info("插件安装成功", 
-		zap.String("url", pluginURL),
-		zap.String("local_path", localPath))
+	i.pruneArchive(newPluginInfo.ServiceName)
 	
 	return localPath, nil
 }
 
+// verifyPackage 校验下载的VKP包
+// 依次校验清单格式、文件名与清单是否一致、签名以及包内文件与清单是否一致
+// source: 包来源（用于日志）
+// filename: 插件文件名
+// vkpPath: VKP文件路径
+// 返回: 以清单中的名称和版本为准的插件信息和错误信息
+func (i *PluginInstaller) verifyPackage(source, filename, vkpPath string) (*PluginInfo, error) {
+	desc, err := readPackageFile(vkpPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := desc.manifest.Validate(); err != nil {
+		return nil, err
+	}
+	if err := CheckCompatibility(&desc.manifest.Plugin); err != nil {
+		return nil, err
+	}
+	info, err := i.manifestPluginInfo(filename, &desc.manifest.Plugin)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := i.verifier.Verify(source, desc.manifestData, desc.signatureData); err != nil {
+		return nil, err
+	}
+	if err := VerifyVKPContents(vkpPath, desc.manifest); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// manifestPluginInfo 以清单中的名称和版本生成插件信息
+// 归档、降级检查和回滚按文件名查找插件，文件名中的服务名称或版本与清单不一致时拒绝安装
+// filename: 插件文件名
+// metadata: 清单中的插件元数据
+// 返回: 插件信息和错误信息
+func (i *PluginInstaller) manifestPluginInfo(filename string, metadata *PluginMetadata) (*PluginInfo, error) {
+	info, err := i.parsePluginInfo(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackageName, err)
+	}
+	if info.ServiceName != metadata.Name {
+		return nil, fmt.Errorf("%w: 文件名中的服务名称 %s，清单中为 %s", ErrPackageMismatch, info.ServiceName, metadata.Name)
+	}
+	if strings.TrimPrefix(info.Version, "v") != strings.TrimPrefix(metadata.Version, "v") {
+		return nil, fmt.Errorf("%w: 文件名中的版本 %s，清单中为 %s", ErrPackageMismatch, info.Version, metadata.Version)
+	}
+	
+	return &PluginInfo{
+		ServiceName: metadata.Name,
+		Platform:    info.Platform,
+		Version:     metadata.Version,
+		Filename:    filename,
+	}, nil
+}
+
+// validateSHA256 验证SHA-256校验和格式
+// sum: 十六进制校验和，为空表示不校验
+// 返回: 错误信息
+func validateSHA256(sum string) error {
+	if sum == "" {
+		return nil
+	}
+	if decoded, err := hex.DecodeString(sum); err != nil || len(decoded) != sha256.Size {
+		return fmt.Errorf("%w: %s", ErrInvalidChecksum, sum)
+	}
+	return nil
+}
+
 // validateURL 验证URL格式
 // pluginURL: 插件URL
 // 返回: 错误信息
@@ -181,12 +562,13 @@ func (i *PluginInstaller) extractFilename(pluginURL string) (string, error) {
 }
 
 // validatePluginFilename 验证插件文件名格式
+// 版本检查、归档和回滚都依赖文件名中的服务名称和版本，不符合格式的文件名会被拒绝
 // filename: 文件名
 // 返回: 错误信息
 func (i *PluginInstaller) validatePluginFilename(filename string) error {
 	// 检查文件扩展名
 	if !strings.HasSuffix(filename, ".vkp") {
-		return fmt.Errorf("插件文件必须以.vkp结尾")
+		return fmt.Errorf("%w: 插件文件必须以.vkp结尾", ErrInvalidPackageName)
 	}
 	
 	// 验证命名格式: <服务名称>_<平台架构>_<版本>.vkp
@@ -198,10 +580,7 @@ func (i *PluginInstaller) validatePluginFilename(filename string) error {
 	}
 	
 	if !matched {
-		i.logger.Warn("插件文件名不符合推荐格式", 
-			zap.String("filename", filename),
-			zap.String("expected_format", "<服务名称>_<平台架构>_<版本>.vkp"))
-		// 不阻止安装，只是警告
+		return fmt.Errorf("%w: %s，应为 <服务名称>_<平台架构>_<版本>.vkp", ErrInvalidPackageName, filename)
 	}
 	
 	return nil
@@ -253,14 +632,15 @@ func (i *PluginInstaller) parsePluginInfo(filename string) (*PluginInfo, error)
 }
 
 // downloadFile 下载文件
+// 超过下载大小上限或内容长度与响应头不符时返回错误
 // url: 下载URL
-// filepath: 本地文件路径
-// 返回: 错误信息
-func (i *PluginInstaller) downloadFile(ctx context.Context, url, filepath string) error {
+// out: 写入的本地文件
+// 返回: 写入字节数、内容SHA-256（十六进制）和错误信息
+func (i *PluginInstaller) downloadFile(ctx context.Context, url string, out *os.File) (int64, string, error) {
 	// 创建HTTP请求
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return err
+		return 0, "", err
 	}
 	
 	// 设置User-Agent
@@ -269,34 +649,46 @@ func (i *PluginInstaller) downloadFile(ctx context.Context, url, filepath string
 	// 发送请求
 	resp, err := i.httpClient.Do(req)
 	if err != nil {
-		return err
+		return 0, "", err
 	}
 	defer resp.Body.Close()
 	
 	// 检查响应状态码
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP错误: %d %s", resp.StatusCode, resp.Status)
+		return 0, "", fmt.Errorf("HTTP错误: %d %s", resp.StatusCode, resp.Status)
 	}
 	
-	// 创建本地文件
-	out, err := os.Create(filepath)
-	if err != nil {
-		return err
+	// 检查声明的内容长度
+	if resp.ContentLength > i.maxDownloadSize {
+		return 0, "", fmt.Errorf("%w: 文件大小 %d 超过上限 %d", ErrPackageTooLarge, resp.ContentLength, i.maxDownloadSize)
 	}
-	defer out.Close()
 	
-	// 复制数据
-	written, err := io.Copy(out, resp.Body)
+	// 复制数据，多读一个字节以识别超过上限的响应
+	hasher := sha256.New()
+	written, err := io.Copy(io.MultiWriter(out, hasher), io.LimitReader(resp.Body, i.maxDownloadSize+1))
 	if err != nil {
-		return err
+		return written, "", err
+	}
+	if written > i.maxDownloadSize {
+		return written, "", fmt.Errorf("%w: 文件大小超过上限 %d", ErrPackageTooLarge, i.maxDownloadSize)
+	}
+	if resp.ContentLength >= 0 && written != resp.ContentLength {
+		return written, "", fmt.Errorf("下载不完整: 期望 %d 字节，实际 %d 字节", resp.ContentLength, written)
 	}
 	
+	// 确保内容落盘后再重命名
+	if err := out.Sync(); err != nil {
+		return written, "", err
+	}
+	
+	sum := hex.EncodeToString(hasher.Sum(nil))
 	i.logger.Info("文件下载完成", 
 		zap.String("url", url),
-		zap.String("filepath", filepath),
-		zap.Int64("size", written))
+		zap.String("filepath", out.Name()),
+		zap.Int64("size", written),
+		zap.String("sha256", sum))
 	
-	return nil
+	return written, sum, nil
 }
 
 // ListInstalledPlugins 列出已安装的插件
@@ -0,0 +1,195 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// servePackage 创建提供插件包的测试服务器
+// path: 插件包路径
+// chunked: 是否不声明内容长度
+// 返回: 测试服务器
+func servePackage(t *testing.T, path string, chunked bool) *httptest.Server {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+filepath.Base(path) {
+			http.NotFound(w, r)
+			return
+		}
+		if !chunked {
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		}
+		w.Write(data[:len(data)/2])
+		if chunked {
+			w.(http.Flusher).Flush()
+		}
+		w.Write(data[len(data)/2:])
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// vpksEntries 返回vpks目录中的文件名，包括临时文件
+func vpksEntries(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestInstallFromURL(t *testing.T) {
+	path, sum := writeTestPackage(t, t.TempDir(), "demo", "1.0.0", CurrentPlatform(), nil)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Base(path)
+
+	tests := []struct {
+		name    string
+		chunked bool
+		target  string
+		sha256  string
+		maxSize int64
+		wantErr error
+		wantMsg string
+	}{
+		{name: "without checksum", target: filename},
+		{name: "matching checksum", target: filename, sha256: sum},
+		{name: "uppercase checksum", target: filename, sha256: strings.ToUpper(sum)},
+		{name: "chunked within limit", chunked: true, target: filename, sha256: sum, maxSize: info.Size()},
+		{name: "malformed checksum", target: filename, sha256: "abc", wantErr: ErrInvalidChecksum},
+		{name: "checksum mismatch", target: filename, sha256: testSHA256, wantErr: ErrChecksumMismatch},
+		{name: "declared length over limit", target: filename, maxSize: info.Size() - 1, wantErr: ErrPackageTooLarge},
+		{name: "chunked body over limit", chunked: true, target: filename, maxSize: info.Size() - 1, wantErr: ErrPackageTooLarge},
+		{name: "malformed filename", target: "demo.vkp", wantErr: ErrInvalidPackageName},
+		{name: "http error", target: "demo_linux_amd64_v9.9.9.vkp", wantMsg: "404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := servePackage(t, path, tt.chunked)
+			vpksDir := t.TempDir()
+			installer := NewPluginInstaller(vpksDir, zap.NewNop())
+			installer.SetMaxDownloadSize(tt.maxSize)
+
+			localPath, err := installer.InstallFromURL(context.Background(), server.URL+"/"+tt.target, InstallOptions{SHA256: tt.sha256})
+			if tt.wantErr == nil && tt.wantMsg == "" {
+				if err != nil {
+					t.Fatalf("InstallFromURL() error: %v", err)
+				}
+				if localPath != filepath.Join(vpksDir, filename) {
+					t.Fatalf("InstallFromURL() = %s", localPath)
+				}
+				if got := vpksEntries(t, vpksDir); len(got) != 1 || got[0] != filename {
+					t.Fatalf("vpks dir = %q, want only %s", got, filename)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("InstallFromURL() succeeded")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("InstallFromURL() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantMsg != "" && !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Fatalf("InstallFromURL() error = %v, want %q", err, tt.wantMsg)
+			}
+			if got := vpksEntries(t, vpksDir); len(got) != 0 {
+				t.Fatalf("failed install left %q in the vpks dir", got)
+			}
+		})
+	}
+}
+
+func TestInstallFromURLCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1024")
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	vpksDir := t.TempDir()
+	installer := NewPluginInstaller(vpksDir, zap.NewNop())
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := installer.InstallFromURL(ctx, server.URL+"/demo_linux_amd64_v1.0.0.vkp", InstallOptions{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("InstallFromURL() error = %v, want context.DeadlineExceeded", err)
+	}
+	if got := vpksEntries(t, vpksDir); len(got) != 0 {
+		t.Fatalf("cancelled install left %q in the vpks dir", got)
+	}
+}
+
+func TestInstallFromReaderLimits(t *testing.T) {
+	path, sum := writeTestPackage(t, t.TempDir(), "demo", "1.0.0", CurrentPlatform(), nil)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		sha256  string
+		maxSize int64
+		wantErr error
+	}{
+		{name: "within limit", sha256: sum, maxSize: info.Size()},
+		{name: "over limit", maxSize: info.Size() - 1, wantErr: ErrPackageTooLarge},
+		{name: "malformed checksum", sha256: "zz", wantErr: ErrInvalidChecksum},
+		{name: "checksum mismatch", sha256: testSHA256, wantErr: ErrChecksumMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			vpksDir := t.TempDir()
+			installer := NewPluginInstaller(vpksDir, zap.NewNop())
+			installer.SetMaxDownloadSize(tt.maxSize)
+			_, err = installer.InstallFromReader(filepath.Base(path), file, InstallOptions{SHA256: tt.sha256})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("InstallFromReader() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if got := vpksEntries(t, vpksDir); len(got) != 0 {
+					t.Fatalf("failed install left %q in the vpks dir", got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// pluginDirEnv 网关通过此环境变量告知子进程插件解压目录，工作目录与解压目录分离时用于读取包内文件
+const pluginDirEnv = "VKP_PLUGIN_DIR"
+
+// baseEnvKeys 不继承网关环境变量时保留的基础环境变量
+var baseEnvKeys = []string{"PATH", "LANG", "LC_ALL", "TZ", "SystemRoot"}
+
+// IsolationOptions VKP插件进程的资源限制和隔离选项
+// Linux上资源限制优先通过cgroup v2实现，未配置CgroupRoot或cgroup不可用时退回到rlimit
+// rlimit由网关重新执行自身作为垫片在exec插件之前设置，运行用户需要有执行网关程序的权限
+type IsolationOptions struct {
+	// MemoryLimit 内存上限（字节），0表示不限制；退回到rlimit时限制的是虚拟地址空间（RLIMIT_AS）
+	MemoryLimit int64
+
+	// CPULimit CPU上限（核数，如0.5），0表示不限制；需要cgroup v2
+	CPULimit float64
+
+	// MaxOpenFiles 打开文件数上限（RLIMIT_NOFILE），0表示不限制
+	MaxOpenFiles uint64
+
+	// MaxProcesses 进程和线程数上限，0表示不限制；退回到rlimit（RLIMIT_NPROC）时按运行用户计数，只在设置了User时生效
+	MaxProcesses uint64
+
+	// CgroupRoot 网关可写的cgroup v2目录，每个插件进程在其下创建子cgroup；为空时不使用cgroup
+	CgroupRoot string
+
+	// WorkDir 工作目录的根目录，插件进程以 WorkDir/<插件名> 为工作目录；为空时使用解压目录
+	WorkDir string
+
+	// InheritEnv 是否继承网关的全部环境变量，默认只保留PATH、LANG等基础环境变量
+	InheritEnv bool
+
+	// Env 额外传给插件进程的环境变量，NAME表示传递网关的同名环境变量，NAME=value表示直接设置
+	Env []string
+
+	// User 运行插件进程的用户（名称或uid），为空时与网关相同；网关需要有切换用户的权限
+	User string
+
+	// Group 运行插件进程的用户组（名称或gid），为空时使用User的主组
+	Group string
+}
+
+// Validate 校验隔离选项，并检查运行用户和用户组是否存在
+// 返回: 错误信息
+func (o IsolationOptions) Validate() error {
+	if o.MemoryLimit < 0 {
+		return fmt.Errorf("invalid plugin memory limit: %d", o.MemoryLimit)
+	}
+	if o.CPULimit < 0 {
+		return fmt.Errorf("invalid plugin CPU limit: %v", o.CPULimit)
+	}
+	for _, entry := range o.Env {
+		if name, _, _ := strings.Cut(entry, "="); name == "" {
+			return fmt.Errorf("invalid plugin environment variable: %q", entry)
+		}
+	}
+	if _, err := lookupCredential(o.User, o.Group); err != nil {
+		return err
+	}
+	return nil
+}
+
+// hasLimits 是否设置了资源限制
+// 返回: 是否设置
+func (o IsolationOptions) hasLimits() bool {
+	return o.MemoryLimit > 0 || o.CPULimit > 0 || o.MaxOpenFiles > 0 || o.MaxProcesses > 0
+}
+
+// workDirFor 创建并返回插件的专用工作目录
+// name: 插件名称
+// fallback: 未设置WorkDir时使用的目录（解压目录）
+// 返回: 工作目录的绝对路径和错误信息
+func (o IsolationOptions) workDirFor(name, fallback string) (string, error) {
+	if o.WorkDir == "" {
+		return fallback, nil
+	}
+	dir, err := filepath.Abs(filepath.Join(o.WorkDir, name))
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", fmt.Errorf("failed to create plugin work dir: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create plugin work dir: %w", err)
+	}
+	return dir, nil
+}
+
+// processEnv 生成插件进程的环境变量
+// options: 隔离选项
+// workDir: 工作目录，不继承环境变量时作为HOME
+// pluginDir: 插件解压目录
+// extra: 追加的环境变量（如敏感配置项）
+// 返回: 环境变量（NAME=value形式）
+func processEnv(options IsolationOptions, workDir, pluginDir string, extra []string) []string {
+	var env []string
+	if options.InheritEnv {
+		env = os.Environ()
+	} else {
+		for _, key := range baseEnvKeys {
+			if value, ok := os.LookupEnv(key); ok {
+				env = append(env, key+"="+value)
+			}
+		}
+		env = append(env, "HOME="+workDir)
+	}
+
+	for _, entry := range options.Env {
+		if strings.Contains(entry, "=") {
+			env = append(env, entry)
+			continue
+		}
+		if value, ok := os.LookupEnv(entry); ok {
+			env = append(env, entry+"="+value)
+		}
+	}
+	env = append(env, pluginDirEnv+"="+pluginDir)
+	return append(env, extra...)
+}
+
+// processCredential 插件进程的运行身份
+type processCredential struct {
+	// uid 用户ID
+	uid uint32
+
+	// gid 用户组ID
+	gid uint32
+}
+
+// lookupCredential 解析运行插件进程的用户和用户组
+// userName: 用户名称或uid，为空时与网关相同
+// groupName: 用户组名称或gid，为空时使用用户的主组
+// 返回: 运行身份（均为空时返回nil）和错误信息
+func lookupCredential(userName, groupName string) (*processCredential, error) {
+	if userName == "" && groupName == "" {
+		return nil, nil
+	}
+
+	credential := &processCredential{uid: uint32(os.Getuid()), gid: uint32(os.Getgid())}
+	primaryGroup := ""
+	if userName != "" {
+		u, err := user.Lookup(userName)
+		if err != nil {
+			u, err = user.LookupId(userName)
+		}
+		switch {
+		case err == nil:
+			primaryGroup = u.Gid
+			userName = u.Uid
+		case groupName == "":
+			// 没有passwd条目的数字uid（如容器中）无法确定主组，需要显式指定用户组
+			return nil, fmt.Errorf("plugin user %q not found (set the group explicitly for a numeric uid)", userName)
+		}
+		uid, err := strconv.ParseUint(userName, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("plugin user %q not found", userName)
+		}
+		credential.uid = uint32(uid)
+	}
+
+	if groupName != "" {
+		g, err := user.LookupGroup(groupName)
+		if err != nil {
+			g, err = user.LookupGroupId(groupName)
+		}
+		if err == nil {
+			groupName = g.Gid
+		}
+		primaryGroup = groupName
+	}
+	if primaryGroup != "" {
+		gid, err := strconv.ParseUint(primaryGroup, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("plugin group %q not found", primaryGroup)
+		}
+		credential.gid = uint32(gid)
+	}
+	return credential, nil
+}
+
+// processSandbox 单个插件子进程的隔离环境
+// 每次启动子进程时创建，子进程退出后释放
+type processSandbox struct {
+	// name 插件名称
+	name string
+
+	// options 隔离选项
+	options IsolationOptions
+
+	// credential 运行身份，nil表示与网关相同
+	credential *processCredential
+
+	// cgroupDir 子进程所在的cgroup目录，未使用cgroup时为空
+	cgroupDir string
+
+	// cgroupFile cgroup目录的文件句柄，用于启动时直接放入cgroup
+	cgroupFile *os.File
+
+	// logger 日志记录器
+	logger *zap.Logger
+}
+
+// newProcessSandbox 创建插件子进程的隔离环境
+// name: 插件名称
+// options: 隔离选项
+// logger: 日志记录器
+// 返回: 隔离环境和错误信息
+func newProcessSandbox(name string, options IsolationOptions, logger *zap.Logger) (*processSandbox, error) {
+	credential, err := lookupCredential(options.User, options.Group)
+	if err != nil {
+		return nil, err
+	}
+	return &processSandbox{
+		name:       name,
+		options:    options,
+		credential: credential,
+		logger:     logger,
+	}, nil
+}
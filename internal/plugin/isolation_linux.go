@@ -0,0 +1,248 @@
+//go:build linux
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// rlimitNproc RLIMIT_NPROC，syscall包未导出该常量
+	rlimitNproc = 0x6
+
+	// cgroupCPUPeriod 写入cpu.max的调度周期（微秒）
+	cgroupCPUPeriod = 100000
+
+	// cgroupRemoveAttempts 删除cgroup的重试次数，等待残留进程被回收
+	cgroupRemoveAttempts = 50
+
+	// rlimitShimEnv 网关以此环境变量重新执行自身，设置rlimit后再exec插件，值为"资源=限制值"列表
+	rlimitShimEnv = "VKP_SANDBOX_RLIMITS"
+
+	// rlimitShimExe 重新执行网关自身时使用的路径
+	rlimitShimExe = "/proc/self/exe"
+)
+
+// init 作为rlimit垫片启动时设置资源限制并exec插件，不会返回
+// 插件代码运行前限制已经生效，不存在启动后再设置的时间窗口
+func init() {
+	if spec, ok := os.LookupEnv(rlimitShimEnv); ok {
+		execWithRlimits(spec, os.Args[1:])
+	}
+}
+
+// grant 将插件运行所需的目录和文件交给运行用户
+// 未切换用户时不做任何操作
+// paths: 目录或文件路径，空字符串会被忽略
+// 返回: 错误信息
+func (s *processSandbox) grant(paths ...string) error {
+	if s.credential == nil {
+		return nil
+	}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if err := os.Chown(path, int(s.credential.uid), int(s.credential.gid)); err != nil {
+			return fmt.Errorf("failed to grant %s to plugin user: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// apply 在子进程启动前设置运行身份、cgroup和rlimit
+// 需要rlimit时改为通过网关自身的垫片启动插件，由垫片设置限制后再exec插件
+// cmd: 子进程命令
+// 返回: 错误信息
+func (s *processSandbox) apply(cmd *exec.Cmd) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	if s.credential != nil {
+		cmd.SysProcAttr.Credential = &syscall.Credential{Uid: s.credential.uid, Gid: s.credential.gid}
+	}
+
+	if s.options.CgroupRoot != "" && (s.options.MemoryLimit > 0 || s.options.CPULimit > 0 || s.options.MaxProcesses > 0) {
+		if err := s.createCgroup(); err != nil {
+			s.logger.Warn("Plugin cgroup unavailable, falling back to rlimits",
+				zap.String("name", s.name),
+				zap.String("cgroup_root", s.options.CgroupRoot),
+				zap.Error(err))
+		} else {
+			cmd.SysProcAttr.UseCgroupFD = true
+			cmd.SysProcAttr.CgroupFD = int(s.cgroupFile.Fd())
+		}
+	}
+	if s.cgroupDir == "" && s.options.CPULimit > 0 {
+		s.logger.Warn("Plugin CPU limit requires cgroup v2 and is not applied",
+			zap.String("name", s.name),
+			zap.Float64("cpu_limit", s.options.CPULimit))
+	}
+
+	if spec := s.rlimitSpec(); spec != "" {
+		cmd.Env = append(cmd.Env, rlimitShimEnv+"="+spec)
+		cmd.Args = append([]string{cmd.Args[0], cmd.Path}, cmd.Args[1:]...)
+		cmd.Path = rlimitShimExe
+	}
+	return nil
+}
+
+// rlimitSpec 生成垫片需要设置的rlimit
+// 打开文件数始终通过rlimit限制；未使用cgroup时内存和进程数也通过rlimit限制
+// RLIMIT_NPROC按真实用户计数，未设置运行用户时会把网关自身的进程和线程算在内，因此只记录警告
+// 返回: "资源=限制值"列表，不需要rlimit时为空
+func (s *processSandbox) rlimitSpec() string {
+	var limits []string
+	add := func(resource int, value uint64) {
+		limits = append(limits, strconv.Itoa(resource)+"="+strconv.FormatUint(value, 10))
+	}
+	if s.options.MaxOpenFiles > 0 {
+		add(syscall.RLIMIT_NOFILE, s.options.MaxOpenFiles)
+	}
+	if s.cgroupDir == "" {
+		if s.options.MaxProcesses > 0 {
+			if s.credential != nil {
+				add(rlimitNproc, s.options.MaxProcesses)
+			} else {
+				s.logger.Warn("Plugin process limit requires cgroup v2 or a dedicated user and is not applied",
+					zap.String("name", s.name),
+					zap.Uint64("max_processes", s.options.MaxProcesses))
+			}
+		}
+		// 地址空间限制放在最后，垫片设置后立即exec，避免垫片自身分配内存失败
+		if s.options.MemoryLimit > 0 {
+			add(syscall.RLIMIT_AS, uint64(s.options.MemoryLimit))
+		}
+	}
+	return strings.Join(limits, ",")
+}
+
+// execWithRlimits 按顺序设置rlimit（软限制和硬限制相同）后exec插件
+// 失败时输出错误并以127退出，由网关作为插件启动失败处理
+// spec: "资源=限制值"列表
+// args: 插件可执行文件路径和命令行参数
+func execWithRlimits(spec string, args []string) {
+	fail := func(err error) {
+		fmt.Fprintf(os.Stderr, "vkp sandbox: %v\n", err)
+		os.Exit(127)
+	}
+	os.Unsetenv(rlimitShimEnv)
+	if len(args) == 0 {
+		fail(errors.New("missing plugin executable"))
+	}
+
+	var limits [][2]uint64
+	for _, field := range strings.Split(spec, ",") {
+		resource, value, _ := strings.Cut(field, "=")
+		r, err := strconv.ParseUint(resource, 10, 32)
+		if err != nil {
+			fail(fmt.Errorf("invalid resource limit %q", field))
+		}
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			fail(fmt.Errorf("invalid resource limit %q", field))
+		}
+		limits = append(limits, [2]uint64{r, v})
+	}
+	env := os.Environ()
+	for _, limit := range limits {
+		if err := syscall.Setrlimit(int(limit[0]), &syscall.Rlimit{Cur: limit[1], Max: limit[1]}); err != nil {
+			fail(fmt.Errorf("failed to set resource limit %d to %d: %w", limit[0], limit[1], err))
+		}
+	}
+	fail(syscall.Exec(args[0], args, env))
+}
+
+// createCgroup 在CgroupRoot下为子进程创建cgroup并写入资源限制
+// 返回: 错误信息
+func (s *processSandbox) createCgroup() (err error) {
+	root := s.options.CgroupRoot
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err != nil {
+		return fmt.Errorf("%s is not a cgroup v2 directory: %w", root, err)
+	}
+
+	// 在父cgroup中启用所需的控制器，已启用或无权限时忽略，由写入限制时报告错误
+	for _, controller := range []string{"+memory", "+cpu", "+pids"} {
+		_ = os.WriteFile(filepath.Join(root, "cgroup.subtree_control"), []byte(controller), 0)
+	}
+
+	dir, err := os.MkdirTemp(root, s.name+"-")
+	if err != nil {
+		return fmt.Errorf("failed to create cgroup: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			syscall.Rmdir(dir)
+		}
+	}()
+
+	var limits [][2]string
+	if s.options.MemoryLimit > 0 {
+		limits = append(limits, [2]string{"memory.max", strconv.FormatInt(s.options.MemoryLimit, 10)})
+	}
+	if s.options.CPULimit > 0 {
+		quota := int64(s.options.CPULimit * cgroupCPUPeriod)
+		limits = append(limits, [2]string{"cpu.max", fmt.Sprintf("%d %d", quota, cgroupCPUPeriod)})
+	}
+	if s.options.MaxProcesses > 0 {
+		limits = append(limits, [2]string{"pids.max", strconv.FormatUint(s.options.MaxProcesses, 10)})
+	}
+	for _, limit := range limits {
+		if err := os.WriteFile(filepath.Join(dir, limit[0]), []byte(limit[1]), 0); err != nil {
+			return fmt.Errorf("failed to set %s: %w", limit[0], err)
+		}
+	}
+
+	file, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open cgroup: %w", err)
+	}
+	s.cgroupDir, s.cgroupFile = dir, file
+	return nil
+}
+
+// started 子进程已放入cgroup，关闭cgroup目录的句柄
+func (s *processSandbox) started() {
+	if s.cgroupFile != nil {
+		s.cgroupFile.Close()
+		s.cgroupFile = nil
+	}
+}
+
+// release 子进程退出后结束cgroup中残留的进程并删除cgroup
+func (s *processSandbox) release() {
+	if s.cgroupFile != nil {
+		s.cgroupFile.Close()
+		s.cgroupFile = nil
+	}
+	if s.cgroupDir == "" {
+		return
+	}
+
+	dir := s.cgroupDir
+	s.cgroupDir = ""
+	_ = os.WriteFile(filepath.Join(dir, "cgroup.kill"), []byte("1"), 0)
+
+	var err error
+	for i := 0; i < cgroupRemoveAttempts; i++ {
+		err = syscall.Rmdir(dir)
+		if err == nil || errors.Is(err, syscall.ENOENT) {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	s.logger.Warn("Failed to remove plugin cgroup",
+		zap.String("name", s.name),
+		zap.String("cgroup", dir),
+		zap.Error(err))
+}
@@ -0,0 +1,83 @@
+//go:build linux
+
+package plugin
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestRlimitSpec(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    IsolationOptions
+		credential *processCredential
+		cgroupDir  string
+		want       string
+	}{
+		{name: "no limits", want: ""},
+		{
+			name:    "open files and memory",
+			options: IsolationOptions{MaxOpenFiles: 64, MemoryLimit: 1 << 30},
+			want:    "7=64,9=1073741824",
+		},
+		{
+			name:    "processes without user",
+			options: IsolationOptions{MaxProcesses: 32},
+			want:    "",
+		},
+		{
+			name:       "processes with user",
+			options:    IsolationOptions{MaxProcesses: 32},
+			credential: &processCredential{uid: 1000, gid: 1000},
+			want:       "6=32",
+		},
+		{
+			name:      "cgroup limits memory and processes",
+			options:   IsolationOptions{MaxOpenFiles: 64, MemoryLimit: 1 << 30, MaxProcesses: 32},
+			cgroupDir: "/sys/fs/cgroup/vkp/demo",
+			want:      "7=64",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &processSandbox{name: "demo", options: tt.options, credential: tt.credential, cgroupDir: tt.cgroupDir, logger: zap.NewNop()}
+			if got := s.rlimitSpec(); got != tt.want {
+				t.Fatalf("rlimitSpec() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyRlimitsBeforeExec(t *testing.T) {
+	var current syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &current); err != nil {
+		t.Fatalf("getrlimit: %v", err)
+	}
+	limit := uint64(64)
+	if current.Max < limit {
+		t.Skipf("hard open file limit %d is below %d", current.Max, limit)
+	}
+
+	s := &processSandbox{name: "demo", options: IsolationOptions{MaxOpenFiles: limit}, logger: zap.NewNop()}
+	cmd := exec.Command("/bin/sh", "-c", "ulimit -n")
+	if err := s.apply(cmd); err != nil {
+		t.Fatalf("apply() error: %v", err)
+	}
+	if cmd.Path != rlimitShimExe {
+		t.Fatalf("command should run through the rlimit shim, got %q", cmd.Path)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("shim failed: %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != strconv.FormatUint(limit, 10) {
+		t.Fatalf("plugin open file limit = %q, want %d", got, limit)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build !linux
+
+package plugin
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"go.uber.org/zap"
+)
+
+// grant 非Linux平台不支持切换用户，无需调整所有者
+// paths: 目录或文件路径
+// 返回: 错误信息
+func (s *processSandbox) grant(paths ...string) error {
+	return nil
+}
+
+// apply 非Linux平台不支持资源限制和切换用户
+// 设置了资源限制时记录警告，设置了运行用户时返回错误
+// cmd: 子进程命令
+// 返回: 错误信息
+func (s *processSandbox) apply(cmd *exec.Cmd) error {
+	if s.credential != nil {
+		return fmt.Errorf("running plugins as another user is not supported on %s", runtime.GOOS)
+	}
+	if s.options.hasLimits() {
+		s.logger.Warn("Plugin resource limits are not supported on this platform",
+			zap.String("name", s.name),
+			zap.String("os", runtime.GOOS))
+	}
+	return nil
+}
+
+// started 非Linux平台启动后没有需要处理的资源
+func (s *processSandbox) started() {}
+
+// release 非Linux平台没有需要释放的资源
+func (s *processSandbox) release() {}
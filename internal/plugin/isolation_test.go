@@ -0,0 +1,133 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLookupCredential(t *testing.T) {
+	tests := []struct {
+		name      string
+		user      string
+		group     string
+		want      *processCredential
+		wantError bool
+	}{
+		{name: "unset", want: nil},
+		{name: "user name", user: "root", want: &processCredential{uid: 0, gid: 0}},
+		{name: "numeric uid", user: "0", want: &processCredential{uid: 0, gid: 0}},
+		{name: "numeric uid without passwd entry", user: "54321", group: "54322", want: &processCredential{uid: 54321, gid: 54322}},
+		{name: "group only", group: "0", want: &processCredential{uid: uint32(os.Getuid()), gid: 0}},
+		{name: "numeric uid without group", user: "54321", wantError: true},
+		{name: "unknown user", user: "vkp-no-such-user", group: "0", wantError: true},
+		{name: "unknown group", user: "root", group: "vkp-no-such-group", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lookupCredential(tt.user, tt.group)
+			if tt.wantError {
+				if err == nil {
+					t.Fatalf("lookupCredential(%q, %q) = %+v, want error", tt.user, tt.group, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("lookupCredential(%q, %q) error: %v", tt.user, tt.group, err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Fatalf("lookupCredential(%q, %q) = %+v, want %+v", tt.user, tt.group, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessEnv(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin")
+	t.Setenv("VKP_TEST_PASSED", "passed")
+	t.Setenv("VKP_TEST_PRIVATE", "private")
+
+	tests := []struct {
+		name    string
+		options IsolationOptions
+		want    []string
+		exclude []string
+	}{
+		{
+			name:    "base environment",
+			options: IsolationOptions{},
+			want:    []string{"PATH=/usr/bin", "HOME=/work", pluginDirEnv + "=/plugin", "SECRET=1"},
+			exclude: []string{"VKP_TEST_PASSED=passed", "VKP_TEST_PRIVATE=private"},
+		},
+		{
+			name:    "explicit variables",
+			options: IsolationOptions{Env: []string{"VKP_TEST_PASSED", "VKP_TEST_MISSING", "MODE=test"}},
+			want:    []string{"VKP_TEST_PASSED=passed", "MODE=test", "HOME=/work"},
+			exclude: []string{"VKP_TEST_MISSING=", "VKP_TEST_PRIVATE=private"},
+		},
+		{
+			name:    "inherit environment",
+			options: IsolationOptions{InheritEnv: true},
+			want:    []string{"VKP_TEST_PASSED=passed", "VKP_TEST_PRIVATE=private", pluginDirEnv + "=/plugin"},
+			exclude: []string{"HOME=/work"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := processEnv(tt.options, "/work", "/plugin", []string{"SECRET=1"})
+			for _, entry := range tt.want {
+				if !slices.Contains(env, entry) {
+					t.Errorf("environment %q is missing %q", env, entry)
+				}
+			}
+			for _, entry := range tt.exclude {
+				if slices.Contains(env, entry) {
+					t.Errorf("environment %q should not contain %q", env, entry)
+				}
+			}
+			if env[len(env)-1] != "SECRET=1" {
+				t.Errorf("extra variables should come last, got %q", env)
+			}
+		})
+	}
+}
+
+func TestWorkDirFor(t *testing.T) {
+	root := t.TempDir()
+
+	t.Run("fallback", func(t *testing.T) {
+		dir, err := IsolationOptions{}.workDirFor("demo", "/plugins/demo")
+		if err != nil || dir != "/plugins/demo" {
+			t.Fatalf("workDirFor() = %q, %v, want fallback", dir, err)
+		}
+	})
+
+	t.Run("dedicated", func(t *testing.T) {
+		dir, err := IsolationOptions{WorkDir: filepath.Join(root, "work")}.workDirFor("demo", "/plugins/demo")
+		if err != nil {
+			t.Fatalf("workDirFor() error: %v", err)
+		}
+		if want := filepath.Join(root, "work", "demo"); dir != want {
+			t.Fatalf("workDirFor() = %q, want %q", dir, want)
+		}
+		info, err := os.Stat(dir)
+		if err != nil {
+			t.Fatalf("work dir not created: %v", err)
+		}
+		if perm := info.Mode().Perm(); perm != 0700 {
+			t.Errorf("work dir permissions = %o, want 700", perm)
+		}
+	})
+
+	t.Run("relative", func(t *testing.T) {
+		t.Chdir(root)
+		dir, err := IsolationOptions{WorkDir: "relative"}.workDirFor("demo", "")
+		if err != nil {
+			t.Fatalf("workDirFor() error: %v", err)
+		}
+		if !filepath.IsAbs(dir) {
+			t.Errorf("workDirFor() = %q, want an absolute path", dir)
+		}
+	})
+}
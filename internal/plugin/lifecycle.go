@@ -0,0 +1,268 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// PluginDetail 插件详情，包括生命周期状态、元数据和健康状态
+type PluginDetail struct {
+	// Name 插件名称
+	Name string `json:"name"`
+
+	// Version 插件版本
+	Version string `json:"version"`
+
+	// Status 生命周期状态
+	Status PluginStatus `json:"status"`
+
+	// Metadata 插件元数据
+	Metadata *PluginMetadata `json:"metadata,omitempty"`
+
+	// Health 健康状态，仅运行中或降级的插件有
+	Health map[string]interface{} `json:"health,omitempty"`
+}
+
+// PluginDetail 获取插件详情
+// 已加载的插件会执行一次健康检查并据此更新运行/降级状态，检查时不持有管理器的锁；
+// 未加载但已安装的插件返回安装包清单中的元数据
+// name: 插件名称
+// 返回: 插件详情和错误信息，插件未加载也未安装时返回ErrPluginNotFound
+func (m *Manager) PluginDetail(name string) (*PluginDetail, error) {
+	if plugin, loaded := m.GetPlugin(name); loaded {
+		detail := &PluginDetail{
+			Name:     name,
+			Version:  plugin.GetVersion(),
+			Metadata: plugin.GetMetadata(),
+		}
+		if state, _ := m.states.state(name); state == StateRunning || state == StateDegraded {
+			health, err := plugin.Health()
+			m.states.observeHealth(name, err)
+			if health == nil {
+				health = make(map[string]interface{})
+			}
+			if err != nil {
+				health["error"] = err.Error()
+			}
+			detail.Health = health
+		}
+		detail.Status, _ = m.states.status(name)
+		return detail, nil
+	}
+
+	status, tracked := m.states.status(name)
+	filename, err := m.installer.findInstalledPackage(name)
+	if err != nil {
+		return nil, fmt.Errorf("查找已安装插件失败: %w", err)
+	}
+	if filename == "" && !tracked {
+		return nil, fmt.Errorf("%w: %s", ErrPluginNotFound, name)
+	}
+
+	detail := &PluginDetail{Name: name, Status: status}
+	if filename != "" {
+		path := filepath.Join(m.installer.vpksDir, filename)
+		manifest, _, err := ReadManifestFromVKP(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取插件清单失败: %w", err)
+		}
+		detail.Version = manifest.Plugin.Version
+		detail.Metadata = &manifest.Plugin
+
+		// 从未加载过的插件以安装时间作为进入已安装状态的时间
+		if !tracked {
+			detail.Status = PluginStatus{State: StateInstalled}
+			if info, err := os.Stat(path); err == nil {
+				detail.Status.Since = info.ModTime()
+			}
+		}
+	}
+	return detail, nil
+}
+
+// ActivePlugins 列出运行中或降级的插件，即需要定期健康检查的插件
+// 只读取状态记录，不获取管理器的锁，健康检查调度不会被安装或启停操作阻塞
+// 返回: 按名称排序的插件名称列表
+func (m *Manager) ActivePlugins() []string {
+	return m.states.namesIn(StateRunning, StateDegraded)
+}
+
+// CheckPluginHealth 检查单个插件的健康状态，并据此在运行和降级之间切换
+// 调用插件时不持有管理器的锁，插件无响应不会阻塞启停等操作
+// name: 插件名称
+// 返回: 插件返回的健康信息和错误信息，插件未加载时返回ErrPluginNotFound
+func (m *Manager) CheckPluginHealth(name string) (map[string]interface{}, error) {
+	plugin, exists := m.GetPlugin(name)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrPluginNotFound, name)
+	}
+
+	health, err := plugin.Health()
+	m.states.observeHealth(name, err)
+	return health, err
+}
+
+// StartPlugin 启动插件
+// 已加载但已停止或失败的插件重新初始化；未加载的插件从vpks目录加载已安装的包
+// 插件依赖的插件需已加载且未停止或失败
+// ctx: 上下文
+// name: 插件名称
+// 返回: 插件实例和错误信息
+func (m *Manager) StartPlugin(ctx context.Context, name string) (Plugin, error) {
+	m.ops.Lock()
+	defer m.ops.Unlock()
+
+	if m.loader == nil {
+		return nil, fmt.Errorf("plugin loader not set")
+	}
+
+	plugin, loaded := m.GetPlugin(name)
+	if !loaded {
+		filename, err := m.installer.findInstalledPackage(name)
+		if err != nil {
+			return nil, fmt.Errorf("查找已安装插件失败: %w", err)
+		}
+		if filename == "" {
+			return nil, fmt.Errorf("%w: %s", ErrPluginNotFound, name)
+		}
+		plugin, err = m.startPlugin(ctx, name, filepath.Join(m.installer.vpksDir, filename))
+		if err != nil {
+			return nil, err
+		}
+		m.logger.Info("Plugin started", zap.String("name", name))
+		return plugin, nil
+	}
+
+	state, _ := m.states.state(name)
+	if !state.CanTransition(StateInitializing) {
+		return nil, fmt.Errorf("%w: 插件 %s 当前状态为 %s", ErrInvalidStateTransition, name, state)
+	}
+	if err := m.checkDependencies(plugin); err != nil {
+		return nil, err
+	}
+
+	if err := m.initializePlugin(ctx, plugin); err != nil {
+		return nil, err
+	}
+	m.logger.Info("Plugin started", zap.String("name", name))
+	return plugin, nil
+}
+
+// StopPlugin 停止插件
+// 下线插件路由并等待进行中的请求完成后停止插件，插件保持加载，可再次启动
+// 仍有运行中的插件依赖该插件时拒绝停止
+// ctx: 上下文
+// name: 插件名称
+// 返回: 错误信息
+func (m *Manager) StopPlugin(ctx context.Context, name string) error {
+	m.ops.Lock()
+	defer m.ops.Unlock()
+
+	plugin, loaded := m.GetPlugin(name)
+	if !loaded {
+		return fmt.Errorf("%w: 插件 %s 未加载", ErrPluginNotFound, name)
+	}
+	state, _ := m.states.state(name)
+	if !state.CanTransition(StateStopped) {
+		return fmt.Errorf("%w: 插件 %s 当前状态为 %s", ErrInvalidStateTransition, name, state)
+	}
+
+	m.mu.RLock()
+	dependents := m.dependentsOf(name)
+	m.mu.RUnlock()
+	var running []string
+	for _, other := range dependents {
+		if m.isActive(other) {
+			running = append(running, other)
+		}
+	}
+	if len(running) > 0 {
+		return fmt.Errorf("%w: %s 被运行中的 %s 依赖", ErrPluginInUse, name, strings.Join(running, ", "))
+	}
+
+	m.retireRoutes(name, false)
+	if err := plugin.Shutdown(ctx); err != nil {
+		m.setState(name, StateFailed, err)
+		return fmt.Errorf("停止插件失败: %w", err)
+	}
+	m.setState(name, StateStopped, nil)
+	m.logger.Info("Plugin stopped", zap.String("name", name))
+	return nil
+}
+
+// RestartPlugin 重启插件
+// 重启期间插件路由返回503，进行中的请求完成后停止并重新初始化插件
+// ctx: 上下文
+// name: 插件名称
+// 返回: 错误信息
+func (m *Manager) RestartPlugin(ctx context.Context, name string) error {
+	m.ops.Lock()
+	defer m.ops.Unlock()
+
+	plugin, loaded := m.GetPlugin(name)
+	if !loaded {
+		return fmt.Errorf("%w: 插件 %s 未加载", ErrPluginNotFound, name)
+	}
+	state, _ := m.states.state(name)
+	if state == StateInitializing {
+		return fmt.Errorf("%w: 插件 %s 当前状态为 %s", ErrInvalidStateTransition, name, state)
+	}
+	if err := m.checkDependencies(plugin); err != nil {
+		return err
+	}
+
+	m.retireRoutes(name, true)
+	if err := plugin.Shutdown(ctx); err != nil {
+		m.routes.unmount(name)
+		m.setState(name, StateFailed, err)
+		return fmt.Errorf("停止插件失败: %w", err)
+	}
+	m.setState(name, StateStopped, nil)
+
+	if err := m.initializePlugin(ctx, plugin); err != nil {
+		m.routes.unmount(name)
+		return err
+	}
+	m.logger.Info("Plugin restarted", zap.String("name", name))
+	return nil
+}
+
+// initializePlugin 校验配置后初始化已加载的插件并挂载路由
+// 失败时插件记录为失败状态并保持加载，可再次启动
+// 调用方需持有ops
+// ctx: 上下文
+// plugin: 插件实例
+// 返回: 错误信息
+func (m *Manager) initializePlugin(ctx context.Context, plugin Plugin) error {
+	name := plugin.GetName()
+	pluginConfig, err := ValidateConfig(name, plugin.GetMetadata(), m.pluginConfig(name))
+	if err != nil {
+		m.setState(name, StateFailed, err)
+		return err
+	}
+	m.setState(name, StateInitializing, nil)
+	if err := plugin.Initialize(ctx, m.logger, pluginConfig); err != nil {
+		m.setState(name, StateFailed, err)
+		return fmt.Errorf("插件初始化失败: %w", err)
+	}
+	if err := m.mountPlugin(plugin); err != nil {
+		m.setState(name, StateFailed, err)
+		plugin.Shutdown(ctx)
+		return fmt.Errorf("注册插件路由失败: %w", err)
+	}
+	m.setState(name, StateRunning, nil)
+	return nil
+}
+
+// isActive 插件是否处于运行或降级状态
+// name: 插件名称
+// 返回: 是否运行中
+func (m *Manager) isActive(name string) bool {
+	state, _ := m.states.state(name)
+	return state == StateRunning || state == StateDegraded
+}
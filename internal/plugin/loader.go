@@ -1,20 +1,21 @@
 package plugin
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"plugin"
 	"sync"
-	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vera-byte/vgo-gateway/pkg/model"
 	"go.uber.org/zap"
 )
 
@@ -30,6 +31,21 @@ type VKPLoader struct {
 	// pluginDir 插件存储目录
 	pluginDir string
 	
+	// processOptions VKP插件进程默认运行选项
+	processOptions ProcessOptions
+	
+	// pluginOptions 按插件名称覆盖的进程运行选项
+	pluginOptions map[string]ProcessOptions
+	
+	// pluginConfigs 按插件名称设置的配置，Go插件工厂创建插件时使用
+	pluginConfigs map[string]interface{}
+	
+	// extractLimits VKP解压限制
+	extractLimits ExtractLimits
+	
+	// verifier VKP包签名校验器
+	verifier *SignatureVerifier
+	
 	// mu 读写锁
 	mu sync.RWMutex
 }
@@ -59,12 +75,65 @@ func NewVKPLoader(pluginDir string, logger *zap.Logger) *VKPLoader {
 	}
 	
 	return &VKPLoader{
-		loadedPlugins: make(map[string]*LoadedPlugin),
-		logger:        logger,
-		pluginDir:     pluginDir,
+		loadedPlugins:  make(map[string]*LoadedPlugin),
+		logger:         logger,
+		pluginDir:      pluginDir,
+		processOptions: DefaultProcessOptions(),
+		pluginOptions:  make(map[string]ProcessOptions),
+		pluginConfigs:  make(map[string]interface{}),
+		extractLimits:  DefaultExtractLimits(),
+		verifier:       NewSignatureVerifier(SignaturePolicyWarn, nil, logger),
 	}
 }
 
+// SetSignatureVerifier 设置VKP包签名校验器
+// 仅对之后加载的插件生效
+// verifier: 签名校验器
+func (l *VKPLoader) SetSignatureVerifier(verifier *SignatureVerifier) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.verifier = verifier
+}
+
+// SetProcessOptions 设置VKP插件进程运行选项
+// 仅对之后加载的插件生效
+// opts: 进程运行选项
+func (l *VKPLoader) SetProcessOptions(opts ProcessOptions) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.processOptions = opts.withDefaults()
+}
+
+// SetPluginProcessOptions 为指定插件设置进程运行选项
+// 覆盖默认选项，仅对之后加载的插件生效
+// name: 插件名称
+// opts: 进程运行选项
+func (l *VKPLoader) SetPluginProcessOptions(name string, opts ProcessOptions) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pluginOptions[name] = opts.withDefaults()
+}
+
+// SetPluginConfig 设置指定插件的配置
+// 导出PluginFactory的Go插件在创建实例时取得经过配置模式校验的配置，仅对之后加载的插件生效
+// name: 插件名称
+// config: 插件配置
+func (l *VKPLoader) SetPluginConfig(name string, config interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pluginConfigs[name] = config
+}
+
+// processOptionsFor 获取指定插件的进程运行选项
+// name: 插件名称
+// 返回: 进程运行选项
+func (l *VKPLoader) processOptionsFor(name string) ProcessOptions {
+	if opts, ok := l.pluginOptions[name]; ok {
+		return opts
+	}
+	return l.processOptions
+}
+
 // LoadPlugin 加载插件
 // path: 插件文件路径
 // 返回: 插件实例和错误信息
@@ -92,37 +161,42 @@ func (l *VKPLoader) LoadPlugin(path string) (Plugin, error) {
 // loadVKPPlugin 加载VKP插件
 // path: VKP文件路径
 // 返回: 插件实例和错误信息
-func (l *VKPLoader) loadVKPPlugin(path string) (Plugin, error) {
+func (l *VKPLoader) loadVKPPlugin(path string) (_ Plugin, err error) {
 	l.logger.Info("开始加载VKP插件", zap.String("path", path))
 	
-	// 创建临时解压目录
-	extractDir := filepath.Join(l.pluginDir, "temp", filepath.Base(path)+"_extracted")
-	if err := os.MkdirAll(extractDir, 0755); err != nil {
-		return nil, fmt.Errorf("创建解压目录失败: %w", err)
-	}
-	
-	// 解压VKP文件
-	if err := l.extractVKP(path, extractDir); err != nil {
+	// 解压到新建的唯一临时目录，避免与其他插件或旧的解压结果混用
+	extractDir, err := extractVKPToTemp(path, filepath.Join(l.pluginDir, "temp"), l.extractLimits)
+	if err != nil {
 		return nil, fmt.Errorf("解压VKP文件失败: %w", err)
 	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(extractDir)
+		}
+	}()
 	
-	// 读取插件元数据
-	metadata, err := l.readPluginMetadata(extractDir)
+	// 读取并校验插件清单
+	manifest, err := l.readManifest(path, extractDir)
 	if err != nil {
-		return nil, fmt.Errorf("读取插件元数据失败: %w", err)
+		return nil, fmt.Errorf("读取插件清单失败: %w", err)
 	}
+	metadata := &manifest.Plugin
 	
 	// 设置插件二进制文件权限
-	binaryPath := filepath.Join(extractDir, "plugin")
+	binaryPath := filepath.Join(extractDir, filepath.FromSlash(manifest.Entrypoint))
 	if err := os.Chmod(binaryPath, 0755); err != nil {
 		return nil, fmt.Errorf("设置插件二进制文件权限失败: %w", err)
 	}
 	
 	// 创建VKP插件包装器
+	options := l.processOptionsFor(metadata.Name)
 	vkpPlugin := &VKPPlugin{
 		execPath: binaryPath,
+		workDir:  extractDir,
 		metadata: metadata,
 		logger:   l.logger,
+		options:  options,
+		logs:     NewLogBuffer(options.LogBufferSize),
 	}
 	
 	name := vkpPlugin.GetName()
@@ -141,29 +215,30 @@ func (l *VKPLoader) loadVKPPlugin(path string) (Plugin, error) {
 }
 
 // loadGoPlugin 加载Go插件
+// 打开前先比较插件与网关的构建信息，避免不同工具链或依赖版本构建的插件在加载时失败或崩溃
 // path: .so文件路径
 // 返回: 插件实例和错误信息
 func (l *VKPLoader) loadGoPlugin(path string) (Plugin, error) {
+	if err := checkGoPluginABI(path); err != nil {
+		return nil, err
+	}
+	
 	// 打开Go插件
 	p, err := plugin.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open go plugin: %w", err)
 	}
 	
-	// 查找插件工厂函数
-	sym, err := p.Lookup("NewPlugin")
+	// 通过PluginFactory或NewPlugin创建插件实例并检查兼容性
+	pluginInstance, err := newGoPlugin(p, func(metadata *PluginMetadata) (interface{}, error) {
+		return ValidateConfig(metadata.Name, metadata, l.pluginConfigs[metadata.Name])
+	})
 	if err != nil {
-		return nil, fmt.Errorf("plugin does not export NewPlugin function: %w", err)
+		return nil, err
 	}
-	
-	// 类型断言
-	newPluginFunc, ok := sym.(func() Plugin)
-	if !ok {
-		return nil, fmt.Errorf("NewPlugin function has wrong signature")
+	if err := CheckCompatibility(pluginInstance.GetMetadata()); err != nil {
+		return nil, err
 	}
-	
-	// 创建插件实例
-	pluginInstance := newPluginFunc()
 	name := pluginInstance.GetName()
 	
 	l.loadedPlugins[name] = &LoadedPlugin{
@@ -179,91 +254,69 @@ func (l *VKPLoader) loadGoPlugin(path string) (Plugin, error) {
 	return pluginInstance, nil
 }
 
-// extractVKP 解压VKP文件
-// vkpPath: VKP文件路径
+// readManifest 读取并校验插件清单和签名
+// 旧版包（plugin.json或metadata.json）仍可加载，但会输出弃用警告
+// path: VKP文件路径
 // extractDir: 解压目录
-// 返回: 错误信息
-func (l *VKPLoader) extractVKP(vkpPath, extractDir string) error {
-	file, err := os.Open(vkpPath)
+// 返回: 插件清单和错误信息
+func (l *VKPLoader) readManifest(path, extractDir string) (*Manifest, error) {
+	desc, err := readPackageDir(extractDir)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer file.Close()
-	
-	gzr, err := gzip.NewReader(file)
-	if err != nil {
-		return err
+	manifest, legacyFile := desc.manifest, desc.legacyFile
+	if legacyFile != "" {
+		l.logger.Warn("VKP包使用已弃用的旧版元数据格式，请使用打包器重新打包", 
+			zap.String("path", path),
+			zap.String("file", legacyFile))
 	}
-	defer gzr.Close()
 	
-	tr := tar.NewReader(gzr)
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
 	
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-		
-		target := filepath.Join(extractDir, header.Name)
-		
-		switch header.Typeflag {
-		case tar.TypeReg:
-			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
-				return err
-			}
-			
-			outFile, err := os.Create(target)
-			if err != nil {
-				return err
-			}
-			
-			if _, err := io.Copy(outFile, tr); err != nil {
-				outFile.Close()
-				return err
-			}
-			outFile.Close()
-		}
+	current := CurrentPlatform()
+	if !manifest.Platform.Supports(current) {
+		return nil, fmt.Errorf("插件平台 %s 与网关平台 %s 不匹配", manifest.Platform, current)
 	}
 	
-	return nil
-}
-
-// readPluginMetadata 读取插件元数据
-// extractDir: 解压目录
-// 返回: 插件元数据和错误信息
-func (l *VKPLoader) readPluginMetadata(extractDir string) (*PluginMetadata, error) {
-	metadataPath := filepath.Join(extractDir, "plugin.json")
-	data, err := os.ReadFile(metadataPath)
-	if err != nil {
+	if err := CheckCompatibility(&manifest.Plugin); err != nil {
 		return nil, err
 	}
 	
-	var metadata PluginMetadata
-	if err := json.Unmarshal(data, &metadata); err != nil {
-		return nil, err
+	// 签名只覆盖清单，文件内容由清单中的SHA-256保证
+	if _, err := l.verifier.Verify(path, desc.manifestData, desc.signatureData); err != nil {
+		return nil, fmt.Errorf("插件签名校验失败: %w", err)
+	}
+	
+	if err := manifest.VerifyFiles(extractDir); err != nil {
+		return nil, fmt.Errorf("插件文件校验失败: %w", err)
 	}
 	
-	return &metadata, nil
+	return manifest, nil
 }
 
 // UnloadPlugin 卸载插件
+// VKP插件会先优雅停止子进程（SIGTERM，超时后SIGKILL）
 // name: 插件名称
 // 返回: 错误信息
 func (l *VKPLoader) UnloadPlugin(name string) error {
 	l.mu.Lock()
-	defer l.mu.Unlock()
-	
 	loadedPlugin, exists := l.loadedPlugins[name]
 	if !exists {
+		l.mu.Unlock()
 		return fmt.Errorf("plugin '%s' not loaded", name)
 	}
+	delete(l.loadedPlugins, name)
+	l.mu.Unlock()
 	
 	// 如果是VKP插件，需要停止进程
 	if vkpPlugin, ok := loadedPlugin.Plugin.(*VKPPlugin); ok {
-		if err := vkpPlugin.stop(); err != nil {
+		options, _, _ := vkpPlugin.launchConfig()
+		ctx, cancel := context.WithTimeout(context.Background(), options.withDefaults().StopTimeout)
+		err := vkpPlugin.Shutdown(ctx)
+		cancel()
+		if err != nil {
 			l.logger.Warn("停止VKP插件失败", 
 				zap.String("name", name),
 				zap.Error(err))
@@ -280,7 +333,6 @@ func (l *VKPLoader) UnloadPlugin(name string) error {
 		}
 	}
 	
-	delete(l.loadedPlugins, name)
 	l.logger.Info("插件卸载成功", zap.String("name", name))
 	return nil
 }
@@ -324,8 +376,33 @@ type VKPPlugin struct {
 	// logger 日志记录器
 	logger *zap.Logger
 	
-	// cmd 运行中的命令
-	cmd *exec.Cmd
+	// workDir 插件解压目录，存放配置文件和Unix域套接字
+	workDir string
+	
+	// options 进程运行选项
+	options ProcessOptions
+	
+	// logs 子进程输出的环形缓冲区，跨重启保留
+	logs *LogBuffer
+	
+	// configPath 传给子进程的配置文件路径，未传入配置时为空
+	configPath string
+	
+	// secretEnv 通过环境变量传递的敏感配置项
+	secretEnv []string
+	
+	// process 运行中的插件子进程
+	process *pluginProcess
+	
+	// supervisor 进程监督器
+	supervisor *processSupervisor
+	
+	// proxy 转发到插件进程的反向代理
+	proxy *httputil.ReverseProxy
+	
+	// mu 保护process、proxy以及options、configPath、secretEnv的读写锁
+	// 监督器重启子进程时会并发读取启动参数
+	mu sync.RWMutex
 }
 
 // loadMetadata 加载插件元数据（已废弃，元数据现在通过plugin.json文件加载）
@@ -383,30 +460,180 @@ func (p *VKPPlugin) CanRunStandalone() bool {
 }
 
 // stop 停止VKP插件进程
+// 先停止监督器避免重启，再优雅结束子进程
+// ctx: 上下文，其截止时间即优雅退出时限
 // 返回: 错误信息
-func (p *VKPPlugin) stop() error {
-	if p.cmd != nil && p.cmd.Process != nil {
-		return p.cmd.Process.Kill()
+func (p *VKPPlugin) stop(ctx context.Context) error {
+	p.mu.Lock()
+	sup := p.supervisor
+	p.supervisor = nil
+	p.mu.Unlock()
+	
+	if sup != nil {
+		sup.stop()
+	}
+	
+	p.mu.Lock()
+	proc := p.process
+	p.process = nil
+	p.mu.Unlock()
+	
+	p.setUpstream(nil, nil)
+	if proc == nil {
+		return nil
+	}
+	
+	pid := proc.pid()
+	state, forced, err := proc.terminate(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to stop VKP plugin '%s' (pid %d): %w", p.GetName(), pid, err)
+	}
+	
+	p.logger.Info("VKP plugin process stopped",
+		zap.String("name", p.GetName()),
+		zap.Int("pid", pid),
+		zap.Stringer("exit_status", state),
+		zap.Bool("forced", forced))
+	
+	if forced {
+		return fmt.Errorf("VKP plugin '%s' (pid %d) did not exit gracefully and was killed: %s", p.GetName(), pid, state)
 	}
 	return nil
 }
 
+// spawn 分配监听端点、启动子进程并等待就绪
+// 使用TCP时子进程未就绪即退出（如分配的端口已被占用）会换一个端口重试
+// ctx: 上下文，仅用于等待就绪，子进程生命周期独立于ctx
+// 返回: 已就绪的子进程和错误信息
+func (p *VKPPlugin) spawn(ctx context.Context) (*pluginProcess, error) {
+	options, configPath, secretEnv := p.launchConfig()
+	for attempt := 1; ; attempt++ {
+		proc, err := p.spawnOnce(ctx, options, configPath, secretEnv)
+		if err == nil {
+			return proc, nil
+		}
+		if !errors.Is(err, errExitedBeforeReady) || options.Network != NetworkTCP ||
+			attempt >= maxSpawnAttempts || ctx.Err() != nil {
+			return nil, err
+		}
+		p.logger.Warn("VKP plugin exited before ready, retrying on a new port",
+			zap.String("name", p.GetName()),
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+	}
+}
+
+// launchConfig 返回启动子进程所需的参数
+// 返回: 进程运行选项、配置文件路径和敏感配置项的环境变量
+func (p *VKPPlugin) launchConfig() (ProcessOptions, string, []string) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.options, p.configPath, p.secretEnv
+}
+
+// spawnOnce 分配监听端点、启动一个子进程并等待就绪
+// ctx: 上下文，仅用于等待就绪
+// options: 进程运行选项
+// configPath: 配置文件路径，为空表示不传配置
+// secretEnv: 敏感配置项的环境变量
+// 返回: 已就绪的子进程和错误信息
+func (p *VKPPlugin) spawnOnce(ctx context.Context, options ProcessOptions, configPath string, secretEnv []string) (*pluginProcess, error) {
+	isolation := options.Isolation
+	runDir, err := isolation.workDirFor(p.GetName(), p.workDir)
+	if err != nil {
+		return nil, err
+	}
+	sandbox, err := newProcessSandbox(p.GetName(), isolation, p.logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare VKP plugin process: %w", err)
+	}
+	// 切换用户时子进程需要在解压目录中创建套接字、读取配置文件
+	if err := sandbox.grant(p.workDir, runDir, configPath); err != nil {
+		return nil, err
+	}
+	
+	endpoint, err := allocateEndpoint(options.Network, p.workDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate endpoint for VKP plugin: %w", err)
+	}
+	
+	var args []string
+	if configPath != "" {
+		args = []string{pluginConfigFlag, configPath}
+	}
+	env := processEnv(isolation, runDir, p.workDir, secretEnv)
+	sink := newPluginLogSink(p.logger, p.GetName(), p.GetVersion(), p.logs)
+	proc, err := startPluginProcess(p.execPath, runDir, endpoint, args, env, sandbox, sink)
+	if err != nil {
+		return nil, err
+	}
+	
+	if err := proc.waitReady(ctx, options.ReadyTimeout); err != nil {
+		proc.kill()
+		return nil, fmt.Errorf("VKP plugin '%s' failed readiness check: %w", p.GetName(), err)
+	}
+	
+	return proc, nil
+}
+
+// attachProcess 将已就绪的子进程设为当前进程并切换代理上游
+// proc: 已就绪的子进程
+func (p *VKPPlugin) attachProcess(proc *pluginProcess) {
+	p.mu.Lock()
+	p.process = proc
+	p.mu.Unlock()
+	p.setUpstream(proc.endpoint.url(), proc.endpoint.transport())
+}
+
 // Initialize 初始化插件
+// 分配监听端点并启动子进程，阻塞直到子进程就绪或超时，之后由监督器按重启策略托管
 // ctx: 上下文
 // logger: 日志记录器
-// config: 配置数据
+// config: 配置数据，非nil时写入解压目录中的配置文件并通过--config参数传给子进程，
+// 敏感配置项不写入文件，改为通过VKP_SECRET_*环境变量传递
 // 返回: 错误信息
 func (p *VKPPlugin) Initialize(ctx context.Context, logger *zap.Logger, config interface{}) error {
-	// 通过命令行参数启动VKP插件
-	cmd := exec.CommandContext(ctx, p.execPath, "--mode=gateway")
-	p.cmd = cmd
+	p.mu.RLock()
+	running := p.supervisor != nil
+	p.mu.RUnlock()
+	if running {
+		return fmt.Errorf("VKP plugin '%s' is already running", p.GetName())
+	}
+	
+	var configPath string
+	var secretEnv []string
+	if config != nil {
+		path, env, err := writeRuntimeConfig(p.workDir, p.GetMetadata(), config)
+		if err != nil {
+			return fmt.Errorf("failed to pass config to VKP plugin '%s': %w", p.GetName(), err)
+		}
+		configPath, secretEnv = path, env
+	}
+	
+	p.mu.Lock()
+	p.configPath, p.secretEnv = configPath, secretEnv
+	p.options = p.options.withDefaults()
+	options := p.options
+	p.mu.Unlock()
 	
-	// 启动插件进程
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start VKP plugin: %w", err)
+	proc, err := p.spawn(ctx)
+	if err != nil {
+		return err
 	}
+	p.attachProcess(proc)
 	
-	p.logger.Info("VKP plugin initialized", zap.String("name", p.GetName()))
+	sup := newProcessSupervisor(p.GetName(), options.RestartPolicy, p.spawn, p.attachProcess, p.logger)
+	p.mu.Lock()
+	p.supervisor = sup
+	p.mu.Unlock()
+	sup.watch(proc)
+	
+	p.logger.Info("VKP plugin initialized",
+		zap.String("name", p.GetName()),
+		zap.Int("pid", proc.pid()),
+		zap.String("endpoint", proc.endpoint.String()),
+		zap.String("restart_policy", string(options.RestartPolicy.Mode)),
+		zap.Duration("startup", time.Since(proc.startedAt)))
 	return nil
 }
 
@@ -424,34 +651,62 @@ func (p *VKPPlugin) RegisterRoutes(router *gin.Engine) error {
 }
 
 // Health 健康检查
-// 返回: 健康状态和错误信息
+// 返回: 健康状态和错误信息，包含监督器记录的重启次数和最近退出码
 func (p *VKPPlugin) Health() (map[string]interface{}, error) {
+	p.mu.RLock()
+	proc := p.process
+	sup := p.supervisor
+	p.mu.RUnlock()
+	
 	// 检查VKP进程是否运行
-	if p.cmd == nil || p.cmd.Process == nil {
+	if proc == nil {
 		return map[string]interface{}{
 			"status": "stopped",
 		}, nil
 	}
 	
-	// 简单的进程存活检查
-	if err := p.cmd.Process.Signal(syscall.Signal(0)); err != nil {
-		return map[string]interface{}{
-			"status": "unhealthy",
-			"error":  err.Error(),
-		}, err
+	health := map[string]interface{}{
+		"status":   "healthy",
+		"pid":      proc.pid(),
+		"endpoint": proc.endpoint.String(),
+	}
+	var status SupervisorStatus
+	if sup != nil {
+		status = sup.snapshot()
+		health["restarts"] = status.Restarts
+		health["supervisor"] = status
+		if status.LastExitCode != nil {
+			health["last_exit_code"] = *status.LastExitCode
+		}
 	}
 	
-	return map[string]interface{}{
-		"status": "healthy",
-		"pid":    p.cmd.Process.Pid,
-	}, nil
+	if !proc.exited() {
+		health["uptime"] = time.Since(proc.startedAt).String()
+		return health, nil
+	}
+	
+	err := fmt.Errorf("plugin process exited: %v", proc.waitErr)
+	health["status"] = "unhealthy"
+	health["error"] = err.Error()
+	if status.Restarting {
+		health["status"] = "restarting"
+	}
+	return health, err
+}
+
+// Logs 获取子进程最近的输出日志
+// limit: 最大条数，小于等于0表示全部
+// 返回: 按时间顺序排列的日志条目
+func (p *VKPPlugin) Logs(limit int) []LogEntry {
+	return p.logs.Entries(limit)
 }
 
 // Shutdown 关闭插件
+// 发送SIGTERM并等待子进程退出，ctx到期后发送SIGKILL
 // ctx: 上下文
 // 返回: 错误信息
 func (p *VKPPlugin) Shutdown(ctx context.Context) error {
-	return p.stop()
+	return p.stop(ctx)
 }
 
 // RunStandalone 独立运行模式
@@ -463,14 +718,35 @@ func (p *VKPPlugin) RunStandalone(ctx context.Context, port int) error {
 	return cmd.Run()
 }
 
+// setUpstream 设置插件进程的上游地址
+// target: 上游地址
+// transport: HTTP传输层
+func (p *VKPPlugin) setUpstream(target *url.URL, transport http.RoundTripper) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	
+	if target == nil {
+		p.proxy = nil
+		return
+	}
+	p.proxy = newReverseProxy(p.GetName(), target, transport, p.logger)
+}
+
 // proxyHandler 代理处理器
-// 将请求转发到VKP插件进程
+// 将请求完整转发到VKP插件进程（方法、路径、查询参数、头部和请求体）
 func (p *VKPPlugin) proxyHandler(c *gin.Context) {
-	// 这里应该实现HTTP代理逻辑
-	// 将请求转发到VKP插件的HTTP服务
-	c.JSON(200, gin.H{
-		"message": "VKP plugin proxy",
-		"plugin":  p.GetName(),
-		"path":    c.Request.URL.Path,
-	})
-}
\ No newline at end of file
+	p.mu.RLock()
+	proxy := p.proxy
+	p.mu.RUnlock()
+	
+	if proxy == nil {
+		c.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: "Plugin not ready",
+			Error:   fmt.Sprintf("plugin '%s' has no running upstream", p.GetName()),
+		})
+		return
+	}
+	
+	proxy.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,276 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// defaultLogBufferSize 每个插件默认保留的日志条数
+	defaultLogBufferSize = 1000
+	
+	// maxLogLineSize 单行日志最大长度，超出部分被截断
+	maxLogLineSize = 64 * 1024
+	
+	// StreamStdout 标准输出
+	StreamStdout = "stdout"
+	
+	// StreamStderr 标准错误
+	StreamStderr = "stderr"
+)
+
+// LogProvider 可提供运行日志的插件
+type LogProvider interface {
+	// Logs 获取最近的日志
+	// limit: 最大条数，小于等于0表示全部
+	// 返回: 按时间顺序排列的日志条目
+	Logs(limit int) []LogEntry
+}
+
+// LogEntry 插件日志条目
+type LogEntry struct {
+	// Time 网关收到日志的时间
+	Time time.Time `json:"time"`
+	
+	// Stream 输出流: stdout 或 stderr
+	Stream string `json:"stream"`
+	
+	// PID 输出日志的进程ID
+	PID int `json:"pid"`
+	
+	// Level 日志级别
+	Level string `json:"level"`
+	
+	// Message 日志消息
+	Message string `json:"message"`
+	
+	// Fields 结构化日志的附加字段
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// LogBuffer 有界环形日志缓冲区
+type LogBuffer struct {
+	// entries 日志存储
+	entries []LogEntry
+	
+	// next 下一个写入位置
+	next int
+	
+	// full 缓冲区是否已写满
+	full bool
+	
+	// mu 互斥锁
+	mu sync.Mutex
+}
+
+// NewLogBuffer 创建日志缓冲区
+// capacity: 容量
+// 返回: 日志缓冲区实例
+func NewLogBuffer(capacity int) *LogBuffer {
+	if capacity <= 0 {
+		capacity = defaultLogBufferSize
+	}
+	return &LogBuffer{
+		entries: make([]LogEntry, capacity),
+	}
+}
+
+// Add 追加日志，写满后覆盖最旧的条目
+// entry: 日志条目
+func (b *LogBuffer) Add(entry LogEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Entries 获取最近的日志
+// limit: 最大条数，小于等于0表示全部
+// 返回: 按时间顺序排列的日志条目
+func (b *LogBuffer) Entries(limit int) []LogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	
+	size := b.next
+	if b.full {
+		size = len(b.entries)
+	}
+	if limit <= 0 || limit > size {
+		limit = size
+	}
+	
+	result := make([]LogEntry, 0, limit)
+	start := (b.next - limit + len(b.entries)) % len(b.entries)
+	for i := 0; i < limit; i++ {
+		result = append(result, b.entries[(start+i)%len(b.entries)])
+	}
+	return result
+}
+
+// pluginLogSink 插件输出接收器
+// 将子进程输出写入网关日志和环形缓冲区
+type pluginLogSink struct {
+	// logger 带有plugin和version字段的日志记录器
+	logger *zap.Logger
+	
+	// buffer 日志缓冲区
+	buffer *LogBuffer
+}
+
+// newPluginLogSink 创建插件输出接收器
+// logger: 网关日志记录器
+// name: 插件名称
+// version: 插件版本
+// buffer: 日志缓冲区
+// 返回: 插件输出接收器
+func newPluginLogSink(logger *zap.Logger, name, version string, buffer *LogBuffer) *pluginLogSink {
+	// 转发的日志来自子进程，网关侧的调用位置和堆栈没有意义
+	logger = logger.WithOptions(zap.WithCaller(false), zap.AddStacktrace(zapcore.FatalLevel))
+	return &pluginLogSink{
+		logger: logger.With(zap.String("plugin", name), zap.String("version", version)),
+		buffer: buffer,
+	}
+}
+
+// consume 逐行读取子进程输出直到EOF
+// r: 输出读取端
+// stream: 输出流名称
+// pid: 进程ID
+// handle: 行预处理函数，返回false表示该行已被消费不再记录
+func (s *pluginLogSink) consume(r io.Reader, stream string, pid int, handle func(line string) bool) {
+	logger := s.logger.With(zap.Int("pid", pid), zap.String("stream", stream))
+	
+	readLines(r, func(line string) {
+		if handle != nil && !handle(line) {
+			return
+		}
+		if strings.TrimSpace(line) == "" {
+			return
+		}
+		s.write(logger, stream, pid, line)
+	})
+}
+
+// write 记录一行插件输出
+// vgo-kit构建的插件输出JSON日志，保留其级别和字段；其他输出按流区分级别
+// logger: 日志记录器
+// stream: 输出流名称
+// pid: 进程ID
+// line: 日志行
+func (s *pluginLogSink) write(logger *zap.Logger, stream string, pid int, line string) {
+	entry := parseLogLine(line, stream)
+	entry.Time = time.Now()
+	entry.PID = pid
+	s.buffer.Add(entry)
+	
+	level, err := zapcore.ParseLevel(entry.Level)
+	if err != nil {
+		level = zapcore.InfoLevel
+	}
+	// 插件的Fatal/Panic日志不能触发网关进程退出
+	if level > zapcore.ErrorLevel {
+		level = zapcore.ErrorLevel
+	}
+	
+	if ce := logger.Check(level, entry.Message); ce != nil {
+		keys := make([]string, 0, len(entry.Fields))
+		for key := range entry.Fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		
+		fields := make([]zap.Field, 0, len(keys))
+		for _, key := range keys {
+			name := key
+			switch key {
+			case "plugin", "version", "pid", "stream":
+				name = "plugin_" + key
+			}
+			fields = append(fields, zap.Any(name, entry.Fields[key]))
+		}
+		ce.Write(fields...)
+	}
+}
+
+// parseLogLine 解析日志行
+// line: 日志行
+// stream: 输出流名称
+// 返回: 日志条目
+func parseLogLine(line, stream string) LogEntry {
+	entry := LogEntry{
+		Stream:  stream,
+		Level:   zapcore.InfoLevel.String(),
+		Message: line,
+	}
+	if stream == StreamStderr {
+		entry.Level = zapcore.WarnLevel.String()
+	}
+	
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "{") {
+		return entry
+	}
+	
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(trimmed), &fields); err != nil {
+		return entry
+	}
+	
+	if level, ok := fields["level"].(string); ok {
+		entry.Level = strings.ToLower(level)
+	}
+	for _, key := range []string{"msg", "message"} {
+		if msg, ok := fields[key].(string); ok {
+			entry.Message = msg
+			break
+		}
+	}
+	for _, key := range []string{"level", "ts", "time", "msg", "message"} {
+		delete(fields, key)
+	}
+	if len(fields) > 0 {
+		entry.Fields = fields
+	}
+	return entry
+}
+
+// readLines 逐行读取，超长行截断后继续读取
+// r: 读取端
+// fn: 行处理函数
+func readLines(r io.Reader, fn func(line string)) {
+	reader := bufio.NewReaderSize(r, maxLogLineSize)
+	for {
+		line, err := reader.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			fn(string(line) + "...(truncated)")
+			// 丢弃该行剩余部分
+			for errors.Is(err, bufio.ErrBufferFull) {
+				_, err = reader.ReadSlice('\n')
+			}
+			if err != nil {
+				return
+			}
+			continue
+		}
+		
+		if len(line) > 0 {
+			fn(strings.TrimRight(string(line), "\r\n"))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package plugin
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogBuffer(t *testing.T) {
+	messages := func(entries []LogEntry) []string {
+		var result []string
+		for _, entry := range entries {
+			result = append(result, entry.Message)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		capacity int
+		added    int
+		limit    int
+		want     []string
+	}{
+		{name: "empty", capacity: 3, limit: 0, want: nil},
+		{name: "partially filled", capacity: 3, added: 2, limit: 0, want: []string{"0", "1"}},
+		{name: "limit on partially filled", capacity: 3, added: 2, limit: 1, want: []string{"1"}},
+		{name: "full", capacity: 3, added: 3, limit: 0, want: []string{"0", "1", "2"}},
+		{name: "wrapped keeps newest", capacity: 3, added: 5, limit: 0, want: []string{"2", "3", "4"}},
+		{name: "limit on wrapped", capacity: 3, added: 5, limit: 2, want: []string{"3", "4"}},
+		{name: "limit above size", capacity: 3, added: 5, limit: 10, want: []string{"2", "3", "4"}},
+		{name: "default capacity", capacity: 0, added: defaultLogBufferSize + 1, limit: 1, want: []string{strconv.Itoa(defaultLogBufferSize)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewLogBuffer(tt.capacity)
+			for i := 0; i < tt.added; i++ {
+				b.Add(LogEntry{Message: strconv.Itoa(i)})
+			}
+			if got := messages(b.Entries(tt.limit)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Entries(%d) = %q, want %q", tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLogLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		stream string
+		want   LogEntry
+	}{
+		{
+			name:   "plain stdout",
+			line:   "listening on :8080",
+			stream: StreamStdout,
+			want:   LogEntry{Stream: StreamStdout, Level: "info", Message: "listening on :8080"},
+		},
+		{
+			name:   "plain stderr",
+			line:   "panic: boom",
+			stream: StreamStderr,
+			want:   LogEntry{Stream: StreamStderr, Level: "warn", Message: "panic: boom"},
+		},
+		{
+			name:   "json log",
+			line:   `{"level":"ERROR","ts":1700000000,"msg":"query failed","table":"users","attempt":2}`,
+			stream: StreamStdout,
+			want: LogEntry{Stream: StreamStdout, Level: "error", Message: "query failed",
+				Fields: map[string]interface{}{"table": "users", "attempt": float64(2)}},
+		},
+		{
+			name:   "json with message key",
+			line:   `  {"message":"started","time":"2024-01-01T00:00:00Z"}`,
+			stream: StreamStderr,
+			want:   LogEntry{Stream: StreamStderr, Level: "warn", Message: "started"},
+		},
+		{
+			name:   "invalid json",
+			line:   `{"level":"info"`,
+			stream: StreamStdout,
+			want:   LogEntry{Stream: StreamStdout, Level: "info", Message: `{"level":"info"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseLogLine(tt.line, tt.stream); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseLogLine() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	long := strings.Repeat("x", maxLogLineSize+10)
+	input := "first\r\n" + long + "\nafter\nlast"
+
+	var lines []string
+	readLines(strings.NewReader(input), func(line string) { lines = append(lines, line) })
+
+	want := []string{"first", strings.Repeat("x", maxLogLineSize) + "...(truncated)", "after", "last"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Fatalf("readLines() returned %d lines, want %q", len(lines), []string{want[0], "x...(truncated)", want[2], want[3]})
+	}
+}
+
+func TestPluginLogSink(t *testing.T) {
+	core, observed := observer.New(zapcore.DebugLevel)
+	buffer := NewLogBuffer(10)
+	sink := newPluginLogSink(zap.New(core), "demo", "1.2.0", buffer)
+
+	input := strings.Join([]string{
+		"plain line",
+		"",
+		"VKP_READY",
+		`{"level":"fatal","msg":"exiting","plugin":"inner","code":3}`,
+	}, "\n")
+	sink.consume(strings.NewReader(input), StreamStdout, 42, func(line string) bool {
+		return line != "VKP_READY"
+	})
+
+	entries := buffer.Entries(0)
+	if len(entries) != 2 || entries[0].Message != "plain line" || entries[1].Message != "exiting" {
+		t.Fatalf("buffered entries = %+v", entries)
+	}
+	if entries[0].PID != 42 || entries[0].Time.IsZero() {
+		t.Errorf("entry = %+v, want pid 42 and a time", entries[0])
+	}
+
+	logs := observed.All()
+	if len(logs) != 2 {
+		t.Fatalf("gateway logged %d lines, want 2", len(logs))
+	}
+	if logs[0].Level != zapcore.InfoLevel || logs[0].Message != "plain line" {
+		t.Errorf("first log = %v %q", logs[0].Level, logs[0].Message)
+	}
+	// 插件的fatal日志降为error，与网关字段同名的字段加上plugin_前缀
+	if logs[1].Level != zapcore.ErrorLevel {
+		t.Errorf("fatal plugin log logged at %v, want error", logs[1].Level)
+	}
+	want := map[string]interface{}{
+		"plugin":        "demo",
+		"version":       "1.2.0",
+		"pid":           int64(42),
+		"stream":        StreamStdout,
+		"plugin_plugin": "inner",
+		"code":          float64(3),
+	}
+	if got := logs[1].ContextMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("log fields = %v, want %v", got, want)
+	}
+}
+
+// logsTestPlugin 提供运行日志的测试插件
+type logsTestPlugin struct {
+	depsTestPlugin
+
+	// buffer 日志缓冲区
+	buffer *LogBuffer
+}
+
+func (p *logsTestPlugin) Logs(limit int) []LogEntry { return p.buffer.Entries(limit) }
+
+func TestManagerGetPluginLogs(t *testing.T) {
+	m := NewManager(zap.NewNop(), t.TempDir())
+	buffer := NewLogBuffer(10)
+	for _, message := range []string{"a", "b", "c"} {
+		buffer.Add(LogEntry{Message: message})
+	}
+	if err := m.RegisterPlugin(&logsTestPlugin{depsTestPlugin: depsTestPlugin{metadata: PluginMetadata{Name: "logged", Version: "1.0.0"}}, buffer: buffer}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RegisterPlugin(&depsTestPlugin{metadata: PluginMetadata{Name: "silent", Version: "1.0.0"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := m.GetPluginLogs("logged", 2)
+	if err != nil || len(entries) != 2 || entries[0].Message != "b" {
+		t.Errorf("GetPluginLogs(logged) = %+v, %v", entries, err)
+	}
+	if _, err := m.GetPluginLogs("silent", 0); err == nil || !strings.Contains(err.Error(), "does not provide logs") {
+		t.Errorf("GetPluginLogs(silent) error = %v", err)
+	}
+	if _, err := m.GetPluginLogs("missing", 0); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("GetPluginLogs(missing) error = %v", err)
+	}
+}
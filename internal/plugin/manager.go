@@ -2,7 +2,12 @@ package plugin
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,12 +30,28 @@ type Manager struct {
 	// installer 插件安装器
 	installer *PluginInstaller
 	
+	// routes 插件路由表，支持运行时挂载和卸载
+	routes *routeTable
+	
+	// drainTimeout 卸载或升级插件时等待进行中请求完成的时间
+	drainTimeout time.Duration
+	
+	// states 插件生命周期状态
+	states *stateTracker
+	
+	// config 插件配置映射，启动或重启插件时按插件名称取出
+	config map[string]interface{}
+	
 	// logger 日志记录器
 	logger *zap.Logger
 	
-	// mu 读写锁
+	// mu 读写锁，保护插件映射和配置，只在读写时短暂持有
 	mu sync.RWMutex
 	
+	// ops 串行化插件的加载、安装、启停和卸载操作
+	// 下载、就绪等待和请求排空等耗时步骤只持有ops，查询和健康检查不会被阻塞
+	ops sync.Mutex
+	
 	// initialized 是否已初始化
 	initialized bool
 }
@@ -41,10 +62,13 @@ type Manager struct {
 // 返回: 插件管理器实例
 func NewManager(logger *zap.Logger, vpksDir string) *Manager {
 	return &Manager{
-		plugins:   make(map[string]Plugin),
-		factories: make(map[string]PluginFactory),
-		installer: NewPluginInstaller(vpksDir, logger),
-		logger:    logger,
+		plugins:      make(map[string]Plugin),
+		factories:    make(map[string]PluginFactory),
+		installer:    NewPluginInstaller(vpksDir, logger),
+		routes:       newRouteTable(),
+		drainTimeout: DefaultDrainTimeout,
+		states:       newStateTracker(),
+		logger:       logger,
 	}
 }
 
@@ -56,6 +80,41 @@ func (m *Manager) SetLoader(loader PluginLoader) {
 	m.loader = loader
 }
 
+// SetSignatureVerifier 设置安装插件时使用的签名校验器
+// verifier: 签名校验器
+func (m *Manager) SetSignatureVerifier(verifier *SignatureVerifier) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.installer.SetSignatureVerifier(verifier)
+}
+
+// SetRegistry 设置按名称安装插件时使用的插件仓库
+// registry: 仓库客户端，nil表示不使用仓库
+func (m *Manager) SetRegistry(registry *RegistryClient) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.installer.SetRegistry(registry)
+}
+
+// SetDrainTimeout 设置卸载或升级插件时等待进行中请求完成的时间
+// timeout: 等待时间，小于等于0时使用默认值
+func (m *Manager) SetDrainTimeout(timeout time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if timeout <= 0 {
+		timeout = DefaultDrainTimeout
+	}
+	m.drainTimeout = timeout
+}
+
+// SetMaxDownloadSize 设置安装插件时的下载大小上限
+// size: 大小上限（字节），小于等于0时使用默认值
+func (m *Manager) SetMaxDownloadSize(size int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.installer.SetMaxDownloadSize(size)
+}
+
 // RegisterFactory 注册插件工厂
 // factory: 插件工厂
 // 返回: 错误信息
@@ -86,6 +145,7 @@ func (m *Manager) RegisterPlugin(plugin Plugin) error {
 	}
 	
 	m.plugins[name] = plugin
+	m.setState(name, StateLoaded, nil)
 	m.logger.Info("Plugin registered", 
 		zap.String("name", name),
 		zap.String("version", plugin.GetVersion()))
@@ -96,8 +156,8 @@ func (m *Manager) RegisterPlugin(plugin Plugin) error {
 // path: 插件文件路径
 // 返回: 错误信息
 func (m *Manager) LoadPluginFromFile(path string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.ops.Lock()
+	defer m.ops.Unlock()
 	
 	if m.loader == nil {
 		return fmt.Errorf("plugin loader not set")
@@ -109,11 +169,15 @@ func (m *Manager) LoadPluginFromFile(path string) error {
 	}
 	
 	name := plugin.GetName()
+	m.mu.Lock()
 	if _, exists := m.plugins[name]; exists {
+		m.mu.Unlock()
 		return fmt.Errorf("plugin '%s' already loaded", name)
 	}
-	
 	m.plugins[name] = plugin
+	m.mu.Unlock()
+	
+	m.setState(name, StateLoaded, nil)
 	m.logger.Info("Plugin loaded from file", 
 		zap.String("name", name),
 		zap.String("path", path))
@@ -121,89 +185,239 @@ func (m *Manager) LoadPluginFromFile(path string) error {
 }
 
 // UnregisterPlugin 注销插件
+// 被其他已加载插件依赖的插件不能注销
 // name: 插件名称
 // 返回: 错误信息
 func (m *Manager) UnregisterPlugin(name string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.ops.Lock()
+	defer m.ops.Unlock()
 	
-	plugin, exists := m.plugins[name]
+	m.mu.RLock()
+	_, exists := m.plugins[name]
+	dependents := m.dependentsOf(name)
+	m.mu.RUnlock()
 	if !exists {
 		return fmt.Errorf("plugin '%s' not found", name)
 	}
-	
-	// 关闭插件
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	
-	if err := plugin.Shutdown(ctx); err != nil {
-		m.logger.Warn("Failed to shutdown plugin", 
-			zap.String("name", name),
-			zap.Error(err))
+	if len(dependents) > 0 {
+		return fmt.Errorf("%w: %s 被 %s 依赖", ErrPluginInUse, name, strings.Join(dependents, ", "))
 	}
 	
-	delete(m.plugins, name)
+	// 关闭插件
+	m.unloadPlugin(name, false)
+	m.states.remove(name)
 	m.logger.Info("Plugin unregistered", zap.String("name", name))
 	return nil
 }
 
-// InitializeAll 初始化所有插件
+// InitializeAll 按依赖顺序初始化所有插件
+// 插件配置按插件声明的配置模式校验并填充默认值后传给插件
+// 单个插件失败不影响其他插件：依赖缺失、版本不满足、位于循环依赖中、配置无效、初始化失败
+// 或所依赖的插件初始化失败的插件会被卸载，错误汇总后返回
 // ctx: 上下文
-// config: 配置映射
+// config: 配置映射，按插件名称取出各插件的配置
 // 返回: 错误信息
 func (m *Manager) InitializeAll(ctx context.Context, config map[string]interface{}) error {
+	m.ops.Lock()
+	defer m.ops.Unlock()
+	
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.config = config
+	m.mu.Unlock()
 	
-	for name, plugin := range m.plugins {
-		pluginConfig := config[name]
+	var errs []error
+	fail := func(name string, err error) {
+		errs = append(errs, fmt.Errorf("failed to initialize plugin '%s': %w", name, err))
+		m.logger.Error("Failed to initialize plugin", 
+			zap.String("name", name),
+			zap.Error(err))
+		m.setState(name, StateFailed, err)
+		m.unloadPlugin(name, false)
+	}
+	
+	// 逐轮移除依赖不满足的插件，直到剩余插件的依赖都已加载
+	for removed := true; removed; {
+		removed = false
+		plugins := m.loadedPlugins()
+		for _, name := range sortedNames(plugins) {
+			if err := m.checkDependencies(plugins[name]); err != nil {
+				fail(name, err)
+				removed = true
+			}
+		}
+	}
+	
+	plugins := m.loadedPlugins()
+	order, err := resolveOrder(plugins)
+	if err != nil {
+		resolved := make(map[string]bool, len(order))
+		for _, name := range order {
+			resolved[name] = true
+		}
+		for _, name := range sortedNames(plugins) {
+			if !resolved[name] {
+				fail(name, err)
+			}
+		}
+	}
+	
+	for _, name := range order {
+		plugin := plugins[name]
+		if err := m.checkDependencies(plugin); err != nil {
+			fail(name, err)
+			continue
+		}
+		
+		pluginConfig, err := ValidateConfig(name, plugin.GetMetadata(), config[name])
+		if err != nil {
+			fail(name, err)
+			continue
+		}
+		m.setState(name, StateInitializing, nil)
 		if err := plugin.Initialize(ctx, m.logger, pluginConfig); err != nil {
-			return fmt.Errorf("failed to initialize plugin '%s': %w", name, err)
+			fail(name, err)
+			continue
 		}
+		m.setState(name, StateRunning, nil)
 		m.logger.Info("Plugin initialized", zap.String("name", name))
 	}
 	
+	m.mu.Lock()
 	m.initialized = true
-	return nil
+	m.mu.Unlock()
+	return errors.Join(errs...)
 }
 
-// RegisterAllRoutes 注册所有插件的路由
+// RegisterAllRoutes 挂载插件路由分发器，并按依赖顺序挂载所有插件的路由
+// 分发器作为NoRoute处理器处理/api/v1/<插件名称>/...，之后加载、升级或卸载的插件无需重启即可生效；
+// 网关和模块的静态路由需在此之前注册，其/api/v1/下的第一段路径不能再用作插件名称，
+// 同名插件挂载时返回ErrRouteConflict并停止，避免插件路由被静态路由遮蔽
+// 单个插件注册失败不影响其他插件，错误汇总后返回
 // router: Gin路由器
 // 返回: 错误信息
 func (m *Manager) RegisterAllRoutes(router *gin.Engine) error {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.ops.Lock()
+	defer m.ops.Unlock()
 	
-	for name, plugin := range m.plugins {
-		if err := plugin.RegisterRoutes(router); err != nil {
-			return fmt.Errorf("failed to register routes for plugin '%s': %w", name, err)
+	m.routes.reserve(staticRouteNames(router))
+	router.NoRoute(m.routes.handle)
+	
+	plugins := m.loadedPlugins()
+	order, err := resolveOrder(plugins)
+	if err != nil {
+		return fmt.Errorf("failed to resolve plugin dependencies: %w", err)
+	}
+	
+	var errs []error
+	for _, name := range order {
+		if state, _ := m.states.state(name); state == StateStopped {
+			continue
+		}
+		if err := m.mountPlugin(plugins[name]); err != nil {
+			errs = append(errs, fmt.Errorf("failed to register routes for plugin '%s': %w", name, err))
+			m.logger.Error("Failed to register plugin routes", 
+				zap.String("name", name),
+				zap.Error(err))
+			m.setState(name, StateFailed, err)
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			plugins[name].Shutdown(ctx)
+			cancel()
+			continue
 		}
 		m.logger.Info("Plugin routes registered", zap.String("name", name))
 	}
 	
+	return errors.Join(errs...)
+}
+
+// mountPlugin 将插件路由注册到独立的Gin引擎并挂载到路由表
+// 插件名称已被静态路由占用时返回ErrRouteConflict，插件路由冲突导致的panic转换为错误
+// plugin: 插件实例
+// 返回: 错误信息
+func (m *Manager) mountPlugin(plugin Plugin) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	
+	if name := plugin.GetName(); m.routes.isReserved(name) {
+		return fmt.Errorf("%w: %s%s/ 已被网关或模块的静态路由占用", ErrRouteConflict, pluginRoutePrefix, name)
+	}
+	
+	engine := gin.New()
+	if err := plugin.RegisterRoutes(engine); err != nil {
+		return err
+	}
+	m.routes.mount(plugin.GetName(), engine)
 	return nil
 }
 
+// retireRoutes 下线插件路由并等待进行中的请求完成
+// 等待期间不持有mu，调用方需持有ops
+// name: 插件名称
+// reloading: 是否即将重新加载，为true时期间的请求返回503，否则返回404
+func (m *Manager) retireRoutes(name string, reloading bool) {
+	var entry *routeEntry
+	if reloading {
+		entry = m.routes.suspend(name)
+	} else {
+		entry = m.routes.unmount(name)
+	}
+	if entry == nil || entry.handler == nil {
+		return
+	}
+	
+	m.mu.RLock()
+	timeout := m.drainTimeout
+	m.mu.RUnlock()
+	
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := entry.drain(ctx); err != nil {
+		m.logger.Warn("Plugin requests did not drain before shutdown", 
+			zap.String("name", name),
+			zap.Error(err))
+	}
+}
+
+// dropSuspendedRoute 插件未能重新加载时移除重新加载期间的占位路由
+// 调用方需持有ops
+// name: 插件名称
+func (m *Manager) dropSuspendedRoute(name string) {
+	if _, loaded := m.GetPlugin(name); !loaded {
+		m.routes.unmount(name)
+	}
+}
+
 // HealthCheckAll 检查所有插件的健康状态
 // 返回: 健康状态映射和错误信息
 func (m *Manager) HealthCheckAll() (map[string]interface{}, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	
 	result := make(map[string]interface{})
 	allHealthy := true
 	
-	for name, plugin := range m.plugins {
+	// 调用插件时不持有锁，插件无响应不会阻塞启停等操作
+	for name, plugin := range m.loadedPlugins() {
+		// 手动停止的插件不计入整体健康状态
+		if state, _ := m.states.state(name); state == StateStopped {
+			result[name] = map[string]interface{}{"status": "stopped"}
+			continue
+		}
+		
 		health, err := plugin.Health()
+		m.states.observeHealth(name, err)
 		if err != nil {
-			result[name] = map[string]interface{}{
-				"status": "unhealthy",
-				"error":  err.Error(),
+			// 保留插件返回的详细信息（如重启次数、退出码）
+			if health == nil {
+				health = make(map[string]interface{})
+			}
+			if _, ok := health["status"]; !ok {
+				health["status"] = "unhealthy"
 			}
+			health["error"] = err.Error()
 			allHealthy = false
-		} else {
-			result[name] = health
 		}
+		result[name] = health
 	}
 	
 	result["overall_status"] = "healthy"
@@ -214,19 +428,29 @@ func (m *Manager) HealthCheckAll() (map[string]interface{}, error) {
 	return result, nil
 }
 
-// ShutdownAll 关闭所有插件
+// ShutdownAll 按依赖的逆序关闭所有插件，依赖方先于被依赖方关闭
 // ctx: 上下文
 // 返回: 错误信息
 func (m *Manager) ShutdownAll(ctx context.Context) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.ops.Lock()
+	defer m.ops.Unlock()
 	
 	var errors []error
 	
-	for name, plugin := range m.plugins {
+	plugins := m.loadedPlugins()
+	order := m.shutdownOrder(plugins)
+	for i := len(order) - 1; i >= 0; i-- {
+		name := order[i]
+		plugin := plugins[name]
+		m.routes.unmount(name)
+		if state, _ := m.states.state(name); state == StateStopped {
+			continue
+		}
 		if err := plugin.Shutdown(ctx); err != nil {
 			errors = append(errors, fmt.Errorf("failed to shutdown plugin '%s': %w", name, err))
+			m.setState(name, StateFailed, err)
 		} else {
+			m.setState(name, StateStopped, nil)
 			m.logger.Info("Plugin shutdown", zap.String("name", name))
 		}
 	}
@@ -235,7 +459,132 @@ func (m *Manager) ShutdownAll(ctx context.Context) error {
 		return fmt.Errorf("shutdown errors: %v", errors)
 	}
 	
+	m.mu.Lock()
 	m.initialized = false
+	m.mu.Unlock()
+	return nil
+}
+
+// shutdownOrder 返回插件的依赖顺序，用于逆序关闭
+// 依赖关系无法解析时按名称排序，保证所有插件仍会被关闭
+// plugins: 插件映射
+// 返回: 插件名称列表
+func (m *Manager) shutdownOrder(plugins map[string]Plugin) []string {
+	order, err := resolveOrder(plugins)
+	if err == nil {
+		return order
+	}
+	m.logger.Warn("Failed to resolve plugin dependencies, shutting down in name order", zap.Error(err))
+	return sortedNames(plugins)
+}
+
+// loadedPlugins 返回已加载插件的快照，遍历和调用插件时无需持有锁
+// 返回: 插件名称到插件实例的映射
+func (m *Manager) loadedPlugins() map[string]Plugin {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	
+	plugins := make(map[string]Plugin, len(m.plugins))
+	for name, plugin := range m.plugins {
+		plugins[name] = plugin
+	}
+	return plugins
+}
+
+// pluginConfig 返回插件的配置
+// name: 插件名称
+// 返回: 插件配置，未配置时为nil
+func (m *Manager) pluginConfig(name string) interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.config[name]
+}
+
+// sortedNames 返回按名称排序的插件名称
+// plugins: 插件映射
+// 返回: 插件名称列表
+func sortedNames(plugins map[string]Plugin) []string {
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// dependentsOf 列出依赖指定插件的已加载插件
+// 调用方需持有锁
+// name: 插件名称
+// 返回: 按名称排序的插件名称列表
+func (m *Manager) dependentsOf(name string) []string {
+	var dependents []string
+	for other, plugin := range m.plugins {
+		if other == name {
+			continue
+		}
+		deps, err := pluginDependencies(plugin)
+		if err != nil {
+			continue
+		}
+		for _, dep := range deps {
+			if dep.Name == name {
+				dependents = append(dependents, other)
+				break
+			}
+		}
+	}
+	sort.Strings(dependents)
+	return dependents
+}
+
+// checkDependencies 检查插件的依赖均已加载、版本满足范围且未停止或失败
+// plugin: 插件实例
+// 返回: 错误信息
+func (m *Manager) checkDependencies(plugin Plugin) error {
+	deps, err := pluginDependencies(plugin)
+	if err != nil {
+		return err
+	}
+	
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, dep := range deps {
+		if dep.Name == plugin.GetName() {
+			return fmt.Errorf("%w: %s -> %s", ErrDependencyCycle, dep.Name, dep.Name)
+		}
+		target, ok := m.plugins[dep.Name]
+		if !ok {
+			return fmt.Errorf("%w: 插件 %s 依赖 %s，但该插件未加载", ErrUnsatisfiedDependency, plugin.GetName(), dep)
+		}
+		if err := dep.check(target.GetVersion()); err != nil {
+			return fmt.Errorf("插件 %s: %w", plugin.GetName(), err)
+		}
+		if state, _ := m.states.state(dep.Name); state == StateStopped || state == StateFailed {
+			return fmt.Errorf("%w: 插件 %s 依赖的 %s 当前状态为 %s", ErrUnsatisfiedDependency, plugin.GetName(), dep.Name, state)
+		}
+	}
+	return nil
+}
+
+// checkDependents 检查替换插件版本后依赖它的已加载插件是否仍然满足
+// name: 插件名称
+// version: 替换后的版本
+// 返回: 错误信息
+func (m *Manager) checkDependents(name, version string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	
+	for _, other := range m.dependentsOf(name) {
+		deps, _ := pluginDependencies(m.plugins[other])
+		for _, dep := range deps {
+			if dep.Name != name {
+				continue
+			}
+			if err := dep.check(version); err != nil {
+				return fmt.Errorf("%w: 插件 %s: %v", ErrPluginInUse, other, err)
+			}
+		}
+	}
 	return nil
 }
 
@@ -250,6 +599,23 @@ func (m *Manager) GetPlugin(name string) (Plugin, bool) {
 	return plugin, exists
 }
 
+// GetPluginLogs 获取插件最近的运行日志
+// name: 插件名称
+// limit: 最大条数，小于等于0表示全部
+// 返回: 日志条目和错误信息
+func (m *Manager) GetPluginLogs(name string, limit int) ([]LogEntry, error) {
+	plugin, exists := m.GetPlugin(name)
+	if !exists {
+		return nil, fmt.Errorf("plugin '%s' not found", name)
+	}
+	
+	provider, ok := plugin.(LogProvider)
+	if !ok {
+		return nil, fmt.Errorf("plugin '%s' does not provide logs", name)
+	}
+	return provider.Logs(limit), nil
+}
+
 // ListPlugins 列出所有已注册的插件
 // 返回: 插件信息列表
 func (m *Manager) ListPlugins() []map[string]interface{} {
@@ -259,10 +625,12 @@ func (m *Manager) ListPlugins() []map[string]interface{} {
 	var plugins []map[string]interface{}
 	for name, plugin := range m.plugins {
 		metadata := plugin.GetMetadata()
+		state, _ := m.states.state(name)
 		plugins = append(plugins, map[string]interface{}{
 			"name":        name,
 			"version":     plugin.GetVersion(),
 			"description": plugin.GetDescription(),
+			"state":       state,
 			"metadata":    metadata,
 		})
 	}
@@ -281,13 +649,14 @@ func (m *Manager) IsInitialized() bool {
 // InstallPluginFromURL 从URL安装插件
 // ctx: 上下文
 // pluginURL: 插件下载URL
+// opts: 安装选项
 // 返回: 错误信息
-func (m *Manager) InstallPluginFromURL(ctx context.Context, pluginURL string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+func (m *Manager) InstallPluginFromURL(ctx context.Context, pluginURL string, opts InstallOptions) error {
+	m.ops.Lock()
+	defer m.ops.Unlock()
 	
 	// 下载插件
-	localPath, err := m.installer.InstallFromURL(ctx, pluginURL)
+	localPath, err := m.installer.InstallFromURL(ctx, pluginURL, opts)
 	if err != nil {
 		return fmt.Errorf("安装插件失败: %w", err)
 	}
@@ -302,35 +671,358 @@ func (m *Manager) InstallPluginFromURL(ctx context.Context, pluginURL string) er
 // InstallAndLoadPluginFromURL 从URL安装并加载插件
 // ctx: 上下文
 // pluginURL: 插件下载URL
-// 返回: 错误信息
-func (m *Manager) InstallAndLoadPluginFromURL(ctx context.Context, pluginURL string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// opts: 安装选项
+// 返回: 插件名称和错误信息
+func (m *Manager) InstallAndLoadPluginFromURL(ctx context.Context, pluginURL string, opts InstallOptions) (string, error) {
+	m.ops.Lock()
+	defer m.ops.Unlock()
+	
+	if m.loader == nil {
+		return "", fmt.Errorf("plugin loader not set")
+	}
 	
 	// 下载插件
-	localPath, err := m.installer.InstallFromURL(ctx, pluginURL)
+	localPath, err := m.installer.InstallFromURL(ctx, pluginURL, opts)
 	if err != nil {
-		return fmt.Errorf("安装插件失败: %w", err)
+		return "", fmt.Errorf("安装插件失败: %w", err)
 	}
 	
 	// 加载插件
-	plugin, err := m.loader.LoadPlugin(localPath)
+	plugin, err := m.activatePlugin(ctx, localPath)
 	if err != nil {
-		return fmt.Errorf("加载插件失败: %w", err)
+		return "", err
 	}
 	
 	name := plugin.GetName()
-	if _, exists := m.plugins[name]; exists {
-		return fmt.Errorf("插件 '%s' 已存在", name)
+	m.logger.Info("插件安装并加载成功", 
+		zap.String("name", name),
+		zap.String("url", pluginURL),
+		zap.String("local_path", localPath))
+	
+	return name, nil
+}
+
+// InstallPluginByName 按名称从插件仓库安装插件
+// ctx: 上下文
+// name: 插件名称
+// versionConstraint: 版本范围，为空表示最新版本
+// opts: 安装选项
+// 返回: 本地文件路径和错误信息
+func (m *Manager) InstallPluginByName(ctx context.Context, name, versionConstraint string, opts InstallOptions) (string, error) {
+	m.ops.Lock()
+	defer m.ops.Unlock()
+	
+	localPath, err := m.installer.InstallByName(ctx, name, versionConstraint, opts)
+	if err != nil {
+		return "", fmt.Errorf("安装插件失败: %w", err)
 	}
 	
-	m.plugins[name] = plugin
+	m.logger.Info("插件下载完成", 
+		zap.String("name", name),
+		zap.String("local_path", localPath))
+	
+	return localPath, nil
+}
+
+// InstallAndLoadPluginByName 按名称从插件仓库安装并加载插件
+// ctx: 上下文
+// name: 插件名称
+// versionConstraint: 版本范围，为空表示最新版本
+// opts: 安装选项
+// 返回: 插件名称和错误信息
+func (m *Manager) InstallAndLoadPluginByName(ctx context.Context, name, versionConstraint string, opts InstallOptions) (string, error) {
+	m.ops.Lock()
+	defer m.ops.Unlock()
+	
+	if m.loader == nil {
+		return "", fmt.Errorf("plugin loader not set")
+	}
+	
+	localPath, err := m.installer.InstallByName(ctx, name, versionConstraint, opts)
+	if err != nil {
+		return "", fmt.Errorf("安装插件失败: %w", err)
+	}
+	
+	// 加载插件
+	plugin, err := m.activatePlugin(ctx, localPath)
+	if err != nil {
+		return "", err
+	}
+	
+	m.logger.Info("插件安装并加载成功", 
+		zap.String("name", plugin.GetName()),
+		zap.String("version", plugin.GetVersion()),
+		zap.String("local_path", localPath))
+	
+	return plugin.GetName(), nil
+}
+
+// AvailablePlugins 列出插件仓库中适用于当前平台的插件
+// ctx: 上下文
+// 返回: 可用插件列表和错误信息，未配置仓库时返回ErrRegistryNotConfigured
+func (m *Manager) AvailablePlugins(ctx context.Context) ([]AvailablePlugin, error) {
+	return m.installer.AvailablePlugins(ctx)
+}
+
+// InstallPluginFromReader 从数据流安装插件
+// filename: 插件文件名
+// r: 插件包内容
+// opts: 安装选项
+// 返回: 本地文件路径和错误信息
+func (m *Manager) InstallPluginFromReader(filename string, r io.Reader, opts InstallOptions) (string, error) {
+	m.ops.Lock()
+	defer m.ops.Unlock()
+	
+	localPath, err := m.installer.InstallFromReader(filename, r, opts)
+	if err != nil {
+		return "", fmt.Errorf("安装插件失败: %w", err)
+	}
+	
+	return localPath, nil
+}
+
+// InstallAndLoadPluginFromReader 从数据流安装并加载插件
+// ctx: 上下文
+// filename: 插件文件名
+// r: 插件包内容
+// opts: 安装选项
+// 返回: 插件名称和错误信息
+func (m *Manager) InstallAndLoadPluginFromReader(ctx context.Context, filename string, r io.Reader, opts InstallOptions) (string, error) {
+	m.ops.Lock()
+	defer m.ops.Unlock()
+	
+	if m.loader == nil {
+		return "", fmt.Errorf("plugin loader not set")
+	}
+	
+	localPath, err := m.installer.InstallFromReader(filename, r, opts)
+	if err != nil {
+		return "", fmt.Errorf("安装插件失败: %w", err)
+	}
+	
+	// 加载插件
+	plugin, err := m.activatePlugin(ctx, localPath)
+	if err != nil {
+		return "", err
+	}
+	
+	name := plugin.GetName()
 	m.logger.Info("插件安装并加载成功", 
 		zap.String("name", name),
-		zap.String("url", pluginURL),
+		zap.String("filename", filename),
 		zap.String("local_path", localPath))
 	
-	return nil
+	return name, nil
+}
+
+// RollbackPlugin 将插件回滚到上一版本并重新加载
+// ctx: 上下文
+// name: 插件名称
+// 返回: 回滚后的插件实例和错误信息
+func (m *Manager) RollbackPlugin(ctx context.Context, name string) (Plugin, error) {
+	m.ops.Lock()
+	defer m.ops.Unlock()
+	
+	if m.loader == nil {
+		return nil, fmt.Errorf("plugin loader not set")
+	}
+	
+	// 回滚前确认依赖该插件的其他插件接受回滚后的版本
+	_, previous, err := m.installer.rollbackTarget(name)
+	if err != nil {
+		return nil, err
+	}
+	previousManifest, _, err := ReadManifestFromVKP(filepath.Join(m.installer.archiveDir(), previous.Filename))
+	if err != nil {
+		return nil, fmt.Errorf("读取回滚版本清单失败: %w", err)
+	}
+	if err := m.checkDependents(name, previousManifest.Plugin.Version); err != nil {
+		return nil, err
+	}
+	
+	localPath, err := m.installer.Rollback(name)
+	if err != nil {
+		return nil, err
+	}
+	
+	defer m.dropSuspendedRoute(name)
+	if _, loaded := m.GetPlugin(name); loaded {
+		m.unloadPlugin(name, true)
+	}
+	
+	plugin, err := m.startPlugin(ctx, name, localPath)
+	if err != nil {
+		return nil, fmt.Errorf("加载回滚版本失败: %w", err)
+	}
+	
+	m.logger.Info("插件回滚成功", 
+		zap.String("name", name),
+		zap.String("version", plugin.GetVersion()),
+		zap.String("local_path", localPath))
+	
+	return plugin, nil
+}
+
+// activatePlugin 加载新安装的插件，替换已加载的同名插件
+// 新版本不满足依赖它的插件、配置不满足新版本的配置模式、加载或就绪检查失败时回滚到上一版本，
+// 并在原先已加载的情况下重新加载上一版本
+// 调用方需持有ops
+// ctx: 上下文
+// localPath: 插件文件路径
+// 返回: 插件实例和错误信息
+func (m *Manager) activatePlugin(ctx context.Context, localPath string) (Plugin, error) {
+	manifest, _, err := ReadManifestFromVKP(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取插件清单失败: %w", err)
+	}
+	name := manifest.Plugin.Name
+	defer m.dropSuspendedRoute(name)
+	
+	// 依赖或配置检查失败时旧版本保持运行，不需要重新加载
+	_, wasLoaded := m.GetPlugin(name)
+	err = m.checkDependents(name, manifest.Plugin.Version)
+	if err == nil {
+		_, err = ValidateConfig(name, &manifest.Plugin, m.pluginConfig(name))
+	}
+	if err == nil {
+		if wasLoaded {
+			m.unloadPlugin(name, true)
+		}
+		
+		var plugin Plugin
+		plugin, err = m.startPlugin(ctx, name, localPath)
+		if err == nil {
+			return plugin, nil
+		}
+	}
+	m.logger.Error("插件加载失败，回滚到上一版本", 
+		zap.String("name", name),
+		zap.String("local_path", localPath),
+		zap.Error(err))
+	
+	previousPath, rollbackErr := m.installer.Rollback(name)
+	if rollbackErr != nil {
+		return nil, fmt.Errorf("加载插件失败: %w（回滚失败: %v）", err, rollbackErr)
+	}
+	if _, stillLoaded := m.GetPlugin(name); wasLoaded && !stillLoaded {
+		if _, reloadErr := m.startPlugin(ctx, name, previousPath); reloadErr != nil {
+			return nil, fmt.Errorf("加载插件失败: %w（已回滚到 %s，但重新加载失败: %v）", err, filepath.Base(previousPath), reloadErr)
+		}
+	}
+	return nil, fmt.Errorf("加载插件失败，已回滚到 %s: %w", filepath.Base(previousPath), err)
+}
+
+// startPlugin 加载并初始化插件
+// 插件的依赖需已加载且版本满足范围，配置需满足插件声明的配置模式
+// VKP插件初始化时启动子进程并等待就绪，依赖、配置或就绪检查失败时卸载插件并记录为失败状态
+// 等待就绪期间不持有mu，初始化成功后才加入插件映射，调用方需持有ops
+// ctx: 上下文
+// name: 插件名称，用于在加载失败时记录状态
+// path: 插件文件路径
+// 返回: 插件实例和错误信息
+func (m *Manager) startPlugin(ctx context.Context, name, path string) (Plugin, error) {
+	plugin, err := m.loader.LoadPlugin(path)
+	if err != nil {
+		m.setState(name, StateFailed, err)
+		return nil, err
+	}
+	
+	name = plugin.GetName()
+	m.setState(name, StateLoaded, nil)
+	if err := m.checkDependencies(plugin); err != nil {
+		m.setState(name, StateFailed, err)
+		if unloadErr := m.loader.UnloadPlugin(name); unloadErr != nil {
+			plugin.Shutdown(ctx)
+		}
+		return nil, err
+	}
+	pluginConfig, err := ValidateConfig(name, plugin.GetMetadata(), m.pluginConfig(name))
+	if err != nil {
+		m.setState(name, StateFailed, err)
+		if unloadErr := m.loader.UnloadPlugin(name); unloadErr != nil {
+			plugin.Shutdown(ctx)
+		}
+		return nil, err
+	}
+	m.setState(name, StateInitializing, nil)
+	if err := plugin.Initialize(ctx, m.logger, pluginConfig); err != nil {
+		m.setState(name, StateFailed, err)
+		if unloadErr := m.loader.UnloadPlugin(name); unloadErr != nil {
+			plugin.Shutdown(ctx)
+		}
+		return nil, fmt.Errorf("插件初始化失败: %w", err)
+	}
+	
+	m.mu.Lock()
+	m.plugins[name] = plugin
+	m.mu.Unlock()
+	if err := m.mountPlugin(plugin); err != nil {
+		m.setState(name, StateFailed, err)
+		m.unloadPlugin(name, false)
+		return nil, fmt.Errorf("注册插件路由失败: %w", err)
+	}
+	m.setState(name, StateRunning, nil)
+	return plugin, nil
+}
+
+// unloadPlugin 下线插件路由，等待进行中的请求完成后停止并移除插件
+// 插件记录为停止状态，已处于失败状态的保留失败原因
+// 排空请求和停止插件时不持有mu，调用方需持有ops
+// name: 插件名称
+// reloading: 是否即将重新加载同名插件
+func (m *Manager) unloadPlugin(name string, reloading bool) {
+	plugin, exists := m.GetPlugin(name)
+	if !exists {
+		return
+	}
+	m.retireRoutes(name, reloading)
+	m.mu.Lock()
+	delete(m.plugins, name)
+	m.mu.Unlock()
+	if state, _ := m.states.state(name); state != StateFailed {
+		m.setState(name, StateStopped, nil)
+	}
+	
+	// 加载器管理的插件由加载器负责停止进程和清理解压目录
+	if err := m.loader.UnloadPlugin(name); err == nil {
+		return
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := plugin.Shutdown(ctx); err != nil {
+		m.logger.Warn("Failed to shutdown plugin", 
+			zap.String("name", name),
+			zap.Error(err))
+	}
+}
+
+// setState 记录插件的状态转换，不允许的转换只记录日志
+// name: 插件名称
+// to: 目标状态
+// cause: 导致转换的错误，可为nil
+func (m *Manager) setState(name string, to PluginState, cause error) {
+	if err := m.states.transition(name, to, cause); err != nil {
+		m.logger.Warn("Unexpected plugin state transition", 
+			zap.String("name", name),
+			zap.Error(err))
+	}
+}
+
+// SetArchiveVersions 设置每个服务保留的历史版本数
+// n: 历史版本数，0表示不保留，负数使用默认值
+func (m *Manager) SetArchiveVersions(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.installer.SetArchiveVersions(n)
+}
+
+// MaxPackageSize 返回安装插件包的大小上限
+// 返回: 大小上限（字节）
+func (m *Manager) MaxPackageSize() int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.installer.MaxDownloadSize()
 }
 
 // ListInstalledPlugins 列出已安装的插件文件
@@ -346,8 +1038,8 @@ func (m *Manager) ListInstalledPlugins() ([]string, error) {
 // filename: 插件文件名
 // 返回: 错误信息
 func (m *Manager) RemoveInstalledPlugin(filename string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.ops.Lock()
+	defer m.ops.Unlock()
 	
 	return m.installer.RemovePlugin(filename)
 }
\ No newline at end of file
@@ -0,0 +1,646 @@
+package plugin
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	// ManifestFileName VKP包清单文件名
+	ManifestFileName = "manifest.json"
+	
+	// ManifestFormatVersion 当前VKP清单格式版本
+	ManifestFormatVersion = 1
+	
+	// DefaultEntrypoint 默认入口可执行文件
+	DefaultEntrypoint = "plugin"
+	
+	// legacyPluginFile 旧版元数据文件（plugin.json）
+	legacyPluginFile = "plugin.json"
+	
+	// legacyMetadataFile 旧版打包器生成的元数据文件（metadata.json）
+	legacyMetadataFile = "metadata.json"
+	
+	// maxManifestSize 清单文件大小上限
+	maxManifestSize = 1 << 20
+)
+
+// Manifest VKP包清单
+// 描述包格式版本、插件元数据、入口、目标平台以及包内文件的大小和SHA-256
+type Manifest struct {
+	// FormatVersion 清单格式版本，旧版包为0
+	FormatVersion int `json:"format_version"`
+	
+	// Plugin 插件元数据
+	Plugin PluginMetadata `json:"plugin"`
+	
+	// Entrypoint 入口可执行文件在包内的路径
+	Entrypoint string `json:"entrypoint"`
+	
+	// Platform 目标平台
+	Platform Platform `json:"platform"`
+	
+	// Files 包内文件列表（不含清单自身）
+	Files []ManifestFile `json:"files"`
+	
+	// CreatedAt 打包时间
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// Platform 目标平台
+type Platform struct {
+	// OS 操作系统，与GOOS取值一致，为空表示不限
+	OS string `json:"os"`
+	
+	// Arch 架构，与GOARCH取值一致，为空表示不限
+	Arch string `json:"arch"`
+}
+
+// ManifestFile 清单中的文件条目
+type ManifestFile struct {
+	// Path 包内路径（使用/分隔）
+	Path string `json:"path"`
+	
+	// Size 文件大小（字节）
+	Size int64 `json:"size"`
+	
+	// SHA256 文件内容的SHA-256（十六进制）
+	SHA256 string `json:"sha256"`
+}
+
+// PackageFile 待打包的文件
+type PackageFile struct {
+	// Source 本地文件路径
+	Source string
+	
+	// Path 包内路径
+	Path string
+}
+
+// CurrentPlatform 返回网关运行的平台
+// 返回: 当前平台
+func CurrentPlatform() Platform {
+	return Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+}
+
+// Supports 判断包是否可在指定平台运行
+// target: 运行平台
+// 返回: 是否支持
+func (p Platform) Supports(target Platform) bool {
+	return (p.OS == "" || p.OS == target.OS) && (p.Arch == "" || p.Arch == target.Arch)
+}
+
+// String 返回平台描述
+// 返回: os/arch 格式的字符串
+func (p Platform) String() string {
+	osName, arch := p.OS, p.Arch
+	if osName == "" {
+		osName = "any"
+	}
+	if arch == "" {
+		arch = "any"
+	}
+	return osName + "/" + arch
+}
+
+// IsLegacy 是否为旧版（无清单）包
+// 返回: 是否旧版
+func (m *Manifest) IsLegacy() bool {
+	return m.FormatVersion == 0
+}
+
+// Validate 校验清单内容
+// 返回: 错误信息
+func (m *Manifest) Validate() error {
+	if m.FormatVersion < 0 || m.FormatVersion > ManifestFormatVersion {
+		return fmt.Errorf("unsupported manifest format version %d (supported: %d)", m.FormatVersion, ManifestFormatVersion)
+	}
+	if m.Plugin.Name == "" {
+		return fmt.Errorf("manifest: plugin name is required")
+	}
+	if err := validateArchivePath(m.Entrypoint); err != nil {
+		return fmt.Errorf("manifest: invalid entrypoint: %w", err)
+	}
+	
+	// 旧版包没有文件列表，只校验入口
+	if m.IsLegacy() {
+		return nil
+	}
+	
+	if m.Plugin.Version == "" {
+		return fmt.Errorf("manifest: plugin version is required")
+	}
+	if _, err := ParseDependencies(m.Plugin.Dependencies); err != nil {
+		return fmt.Errorf("manifest: %w", err)
+	}
+	
+	seen := make(map[string]bool, len(m.Files))
+	for _, file := range m.Files {
+		if err := validateArchivePath(file.Path); err != nil {
+			return fmt.Errorf("manifest: invalid file path: %w", err)
+		}
+		if seen[file.Path] {
+			return fmt.Errorf("manifest: duplicate file %s", file.Path)
+		}
+		seen[file.Path] = true
+		
+		if file.Size < 0 {
+			return fmt.Errorf("manifest: invalid size for %s", file.Path)
+		}
+		if sum, err := hex.DecodeString(file.SHA256); err != nil || len(sum) != sha256.Size {
+			return fmt.Errorf("manifest: invalid sha256 for %s", file.Path)
+		}
+	}
+	if !seen[m.Entrypoint] {
+		return fmt.Errorf("manifest: entrypoint %s is not listed in files", m.Entrypoint)
+	}
+	
+	return nil
+}
+
+// VerifyFiles 校验解压目录中的文件与清单一致
+// dir: 解压目录
+// 返回: 错误信息
+func (m *Manifest) VerifyFiles(dir string) error {
+	for _, file := range m.Files {
+		size, sum, err := hashFile(filepath.Join(dir, filepath.FromSlash(file.Path)))
+		if err != nil {
+			return fmt.Errorf("file %s: %w", file.Path, err)
+		}
+		if size != file.Size {
+			return fmt.Errorf("file %s: size mismatch (manifest %d, actual %d)", file.Path, file.Size, size)
+		}
+		if !strings.EqualFold(sum, file.SHA256) {
+			return fmt.Errorf("file %s: sha256 mismatch", file.Path)
+		}
+	}
+	
+	// 旧版包没有文件列表，至少确认入口存在
+	if m.IsLegacy() {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(m.Entrypoint))); err != nil {
+			return fmt.Errorf("entrypoint %s: %w", m.Entrypoint, err)
+		}
+		return nil
+	}
+	
+	// 清单和签名之外的普通文件必须全部列在清单中
+	listed := make(map[string]bool, len(m.Files))
+	for _, file := range m.Files {
+		listed[file.Path] = true
+	}
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if name == ManifestFileName || name == SignatureFileName || listed[name] {
+			return nil
+		}
+		return fmt.Errorf("file %s is not listed in manifest", name)
+	})
+}
+
+// BuildManifest 生成VKP清单
+// 计算每个文件的大小和SHA-256
+// metadata: 插件元数据
+// entrypoint: 入口文件的包内路径
+// platform: 目标平台
+// files: 待打包文件
+// 返回: 清单和错误信息
+func BuildManifest(metadata *PluginMetadata, entrypoint string, platform Platform, files []PackageFile) (*Manifest, error) {
+	if metadata == nil {
+		return nil, fmt.Errorf("plugin metadata is required")
+	}
+	
+	manifest := &Manifest{
+		FormatVersion: ManifestFormatVersion,
+		Plugin:        *metadata,
+		Entrypoint:    entrypoint,
+		Platform:      platform,
+		Files:         make([]ManifestFile, 0, len(files)),
+		CreatedAt:     time.Now().UTC(),
+	}
+	
+	for _, file := range files {
+		size, sum, err := hashFile(file.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", file.Source, err)
+		}
+		manifest.Files = append(manifest.Files, ManifestFile{
+			Path:   file.Path,
+			Size:   size,
+			SHA256: sum,
+		})
+	}
+	
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// WriteVKP 写入VKP包（tar.gz）
+// 清单作为第一个条目写入，签名（如有）紧随其后，随后按清单顺序写入文件
+// w: 输出
+// manifest: 清单
+// files: 待打包文件，需与清单中的文件一一对应
+// signer: Ed25519签名私钥，为nil时不签名
+// 返回: 错误信息
+func WriteVKP(w io.Writer, manifest *Manifest, files []PackageFile, signer ed25519.PrivateKey) error {
+	if len(files) != len(manifest.Files) {
+		return fmt.Errorf("manifest lists %d files, got %d", len(manifest.Files), len(files))
+	}
+	
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	
+	gzWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzWriter)
+	
+	if err := writeTarEntry(tarWriter, ManifestFileName, data, manifest.CreatedAt); err != nil {
+		return err
+	}
+	
+	if signer != nil {
+		signature, err := json.MarshalIndent(SignManifest(data, signer), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal signature: %w", err)
+		}
+		if err := writeTarEntry(tarWriter, SignatureFileName, signature, manifest.CreatedAt); err != nil {
+			return err
+		}
+	}
+	
+	for i, file := range files {
+		if file.Path != manifest.Files[i].Path {
+			return fmt.Errorf("file %s does not match manifest entry %s", file.Path, manifest.Files[i].Path)
+		}
+		if err := addFileToTar(tarWriter, file.Source, manifest.Files[i]); err != nil {
+			return err
+		}
+	}
+	
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("failed to close tar writer: %w", err)
+	}
+	return gzWriter.Close()
+}
+
+// writeTarEntry 将内存中的数据作为普通文件写入tar归档
+// tarWriter: tar写入器
+// name: 包内路径
+// data: 文件内容
+// modTime: 修改时间
+// 返回: 错误信息
+func writeTarEntry(tarWriter *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Size:    int64(len(data)),
+		Mode:    0644,
+		ModTime: modTime,
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s header: %w", name, err)
+	}
+	if _, err := tarWriter.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// addFileToTar 添加文件到tar归档，并确认内容与清单条目一致
+// tarWriter: tar写入器
+// source: 本地文件路径
+// entry: 清单条目
+// 返回: 错误信息
+func addFileToTar(tarWriter *tar.Writer, source string, entry ManifestFile) error {
+	file, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", source, err)
+	}
+	defer file.Close()
+	
+	stat, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file %s: %w", source, err)
+	}
+	if stat.Size() != entry.Size {
+		return fmt.Errorf("file %s changed after manifest was built", source)
+	}
+	
+	header := &tar.Header{
+		Name:    entry.Path,
+		Size:    stat.Size(),
+		Mode:    int64(stat.Mode().Perm()),
+		ModTime: stat.ModTime(),
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write tar header: %w", err)
+	}
+	
+	hasher := sha256.New()
+	if _, err := io.Copy(tarWriter, io.TeeReader(file, hasher)); err != nil {
+		return fmt.Errorf("failed to copy file data: %w", err)
+	}
+	if hex.EncodeToString(hasher.Sum(nil)) != entry.SHA256 {
+		return fmt.Errorf("file %s changed after manifest was built", source)
+	}
+	
+	return nil
+}
+
+// packageDescriptor 包内清单和签名的原始内容
+type packageDescriptor struct {
+	// manifest 解析后的清单
+	manifest *Manifest
+	
+	// legacyFile 旧版包使用的元数据文件名，新格式为空
+	legacyFile string
+	
+	// manifestData manifest.json的原始内容，旧版包为nil
+	manifestData []byte
+	
+	// signatureData manifest.sig的内容，未签名为nil
+	signatureData []byte
+}
+
+// ReadManifest 从解压目录读取清单
+// 没有manifest.json时回退到旧版plugin.json或metadata.json
+// dir: 解压目录
+// 返回: 清单、使用的旧版文件名（新格式为空）和错误信息
+func ReadManifest(dir string) (*Manifest, string, error) {
+	desc, err := readPackageDir(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	return desc.manifest, desc.legacyFile, nil
+}
+
+// ReadManifestFromVKP 直接从VKP文件读取清单，无需解压
+// 没有manifest.json时回退到旧版plugin.json或metadata.json
+// vkpPath: VKP文件路径
+// 返回: 清单、使用的旧版文件名（新格式为空）和错误信息
+func ReadManifestFromVKP(vkpPath string) (*Manifest, string, error) {
+	desc, err := readPackageFile(vkpPath)
+	if err != nil {
+		return nil, "", err
+	}
+	return desc.manifest, desc.legacyFile, nil
+}
+
+// readPackageDir 从解压目录读取清单和签名
+// dir: 解压目录
+// 返回: 包描述和错误信息
+func readPackageDir(dir string) (*packageDescriptor, error) {
+	found := make(map[string][]byte)
+	for _, name := range []string{ManifestFileName, SignatureFileName, legacyPluginFile, legacyMetadataFile} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found[name] = data
+	}
+	return newPackageDescriptor(found)
+}
+
+// readPackageFile 直接从VKP文件读取清单和签名
+// vkpPath: VKP文件路径
+// 返回: 包描述和错误信息
+func readPackageFile(vkpPath string) (*packageDescriptor, error) {
+	file, err := os.Open(vkpPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	
+	gzr, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gzr.Close()
+	
+	found := make(map[string][]byte)
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		
+		name := path.Clean(header.Name)
+		switch name {
+		case ManifestFileName, SignatureFileName, legacyPluginFile, legacyMetadataFile:
+		default:
+			continue
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		
+		data, err := io.ReadAll(io.LimitReader(tr, maxManifestSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > maxManifestSize {
+			return nil, fmt.Errorf("%s exceeds %d bytes", name, maxManifestSize)
+		}
+		found[name] = data
+		
+		// 清单和签名总是最先写入，都找到后无需继续扫描
+		if found[ManifestFileName] != nil && found[SignatureFileName] != nil {
+			break
+		}
+	}
+	return newPackageDescriptor(found)
+}
+
+// newPackageDescriptor 根据读取到的文件创建包描述
+// found: 文件名到内容的映射
+// 返回: 包描述和错误信息
+func newPackageDescriptor(found map[string][]byte) (*packageDescriptor, error) {
+	for _, name := range []string{ManifestFileName, legacyPluginFile, legacyMetadataFile} {
+		data, ok := found[name]
+		if !ok {
+			continue
+		}
+		
+		manifest, legacyFile, err := parseManifest(name, data)
+		if err != nil {
+			return nil, err
+		}
+		desc := &packageDescriptor{
+			manifest:      manifest,
+			legacyFile:    legacyFile,
+			signatureData: found[SignatureFileName],
+		}
+		if legacyFile == "" {
+			desc.manifestData = data
+		}
+		return desc, nil
+	}
+	return nil, fmt.Errorf("no %s found in package", ManifestFileName)
+}
+
+// VerifyVKPContents 流式校验VKP文件内容与清单一致，无需解压
+// 清单外的普通文件、缺失的文件以及大小或SHA-256不符都会导致校验失败
+// vkpPath: VKP文件路径
+// manifest: 清单
+// 返回: 错误信息
+func VerifyVKPContents(vkpPath string, manifest *Manifest) error {
+	// 旧版包没有文件列表
+	if manifest.IsLegacy() {
+		return nil
+	}
+	
+	file, err := os.Open(vkpPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	
+	gzr, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gzr.Close()
+	
+	expected := make(map[string]ManifestFile, len(manifest.Files))
+	for _, entry := range manifest.Files {
+		expected[entry.Path] = entry
+	}
+	
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		
+		name := path.Clean(header.Name)
+		if name == ManifestFileName || name == SignatureFileName {
+			continue
+		}
+		entry, ok := expected[name]
+		if !ok {
+			return fmt.Errorf("file %s is not listed in manifest", name)
+		}
+		delete(expected, name)
+		
+		hasher := sha256.New()
+		size, err := io.Copy(hasher, io.LimitReader(tr, entry.Size+1))
+		if err != nil {
+			return err
+		}
+		if size != entry.Size {
+			return fmt.Errorf("file %s: size mismatch (manifest %d)", name, entry.Size)
+		}
+		if !strings.EqualFold(hex.EncodeToString(hasher.Sum(nil)), entry.SHA256) {
+			return fmt.Errorf("file %s: sha256 mismatch", name)
+		}
+	}
+	
+	for name := range expected {
+		return fmt.Errorf("file %s listed in manifest is missing", name)
+	}
+	return nil
+}
+
+// parseManifest 解析清单或旧版元数据文件
+// name: 文件名
+// data: 文件内容
+// 返回: 清单、使用的旧版文件名（新格式为空）和错误信息
+func parseManifest(name string, data []byte) (*Manifest, string, error) {
+	if name == ManifestFileName {
+		var manifest Manifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, "", fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		if manifest.FormatVersion < 1 {
+			return nil, "", fmt.Errorf("%s: missing format_version", name)
+		}
+		return &manifest, "", nil
+	}
+	
+	var metadata PluginMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, "", fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return &Manifest{
+		FormatVersion: 0,
+		Plugin:        metadata,
+		Entrypoint:    DefaultEntrypoint,
+	}, name, nil
+}
+
+// validateArchivePath 校验包内路径
+// 必须是规范化的相对路径，且不能跳出包根目录
+// p: 包内路径
+// 返回: 错误信息
+func validateArchivePath(p string) error {
+	if p == "" {
+		return fmt.Errorf("empty path")
+	}
+	if strings.Contains(p, "\\") || path.IsAbs(p) || filepath.IsAbs(p) || filepath.VolumeName(p) != "" {
+		return fmt.Errorf("%s: path must be relative and use '/'", p)
+	}
+	if path.Clean(p) != p || p == "." || p == ".." || strings.HasPrefix(p, "../") {
+		return fmt.Errorf("%s: path must be clean and stay inside the package", p)
+	}
+	if p == ManifestFileName || p == SignatureFileName {
+		return fmt.Errorf("%s: reserved file name", p)
+	}
+	return nil
+}
+
+// hashFile 计算文件大小和SHA-256
+// filePath: 文件路径
+// 返回: 文件大小、十六进制SHA-256和错误信息
+func hashFile(filePath string) (int64, string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+	
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, file)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hasher.Sum(nil)), nil
+}
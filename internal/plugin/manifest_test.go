@@ -0,0 +1,92 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManifestRoundTrip(t *testing.T) {
+	platform := Platform{OS: "linux", Arch: "amd64"}
+	path, _ := writeTestPackage(t, t.TempDir(), "demo", "1.2.0", platform, nil)
+
+	manifest, legacyFile, err := ReadManifestFromVKP(path)
+	if err != nil {
+		t.Fatalf("ReadManifestFromVKP() error: %v", err)
+	}
+	if legacyFile != "" {
+		t.Errorf("package read as legacy %s", legacyFile)
+	}
+	if manifest.Plugin.Name != "demo" || manifest.Plugin.Version != "1.2.0" || manifest.Platform != platform {
+		t.Fatalf("manifest = %s %s %s, want demo 1.2.0 %s", manifest.Plugin.Name, manifest.Plugin.Version, manifest.Platform, platform)
+	}
+	if manifest.Entrypoint != "plugin" || len(manifest.Files) != 1 || manifest.Files[0].Path != "plugin" {
+		t.Fatalf("manifest files = %+v, entrypoint %s", manifest.Files, manifest.Entrypoint)
+	}
+	if err := manifest.Validate(); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+	if err := VerifyVKPContents(path, manifest); err != nil {
+		t.Fatalf("VerifyVKPContents() error: %v", err)
+	}
+
+	dir, err := extractVKPToTemp(path, t.TempDir(), DefaultExtractLimits())
+	if err != nil {
+		t.Fatalf("extractVKPToTemp() error: %v", err)
+	}
+	if err := manifest.VerifyFiles(dir); err != nil {
+		t.Fatalf("VerifyFiles() error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "extra"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.VerifyFiles(dir); err == nil || !strings.Contains(err.Error(), "not listed") {
+		t.Fatalf("VerifyFiles() with an unlisted file error = %v", err)
+	}
+}
+
+func TestVerifyVKPContentsRejectsTampering(t *testing.T) {
+	path, _ := writeTestPackage(t, t.TempDir(), "demo", "1.0.0", CurrentPlatform(), nil)
+
+	tests := []struct {
+		name      string
+		edit      func(m *Manifest)
+		wantError string
+	}{
+		{
+			name:      "sha256 mismatch",
+			edit:      func(m *Manifest) { m.Files[0].SHA256 = testSHA256 },
+			wantError: "sha256 mismatch",
+		},
+		{
+			name:      "size mismatch",
+			edit:      func(m *Manifest) { m.Files[0].Size++ },
+			wantError: "size mismatch",
+		},
+		{
+			name:      "unlisted file",
+			edit:      func(m *Manifest) { m.Files[0].Path = "other" },
+			wantError: "not listed",
+		},
+		{
+			name: "missing file",
+			edit: func(m *Manifest) {
+				m.Files = append(m.Files, ManifestFile{Path: "missing", SHA256: testSHA256})
+			},
+			wantError: "is missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, _, err := ReadManifestFromVKP(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.edit(manifest)
+			if err := VerifyVKPContents(path, manifest); err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Fatalf("VerifyVKPContents() error = %v, want %q", err, tt.wantError)
+			}
+		})
+	}
+}
@@ -1,21 +1,20 @@
 package plugin
 
 import (
-	"archive/tar"
-	"compress/gzip"
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"go.uber.org/zap"
 )
 
 // VKPPackager VKP打包器
-// 负责将模块编译和打包为.vkp文件
+// 负责将模块编译和打包为.vkp文件（manifest.json格式）
 type VKPPackager struct {
 	// logger 日志记录器
 	logger *zap.Logger
@@ -49,6 +48,9 @@ type PackageConfig struct {
 	
 	// IncludeFiles 包含的额外文件
 	IncludeFiles []string `json:"include_files,omitempty"`
+	
+	// SigningKey Ed25519签名私钥文件路径，为空时不签名
+	SigningKey string `json:"signing_key,omitempty"`
 }
 
 // NewVKPPackager 创建新的VKP打包器
@@ -84,7 +86,7 @@ func (p *VKPPackager) Package(config *PackageConfig) error {
 	}
 	
 	// 创建VKP包
-	if err := p.createVKPPackage(config, binaryPath, tempDir); err != nil {
+	if err := p.createVKPPackage(config, binaryPath); err != nil {
 		return fmt.Errorf("failed to create VKP package: %w", err)
 	}
 	
@@ -152,15 +154,50 @@ func (p *VKPPackager) buildModule(config *PackageConfig, tempDir string) (string
 }
 
 // createVKPPackage 创建VKP包
+// 包内包含manifest.json、签名（配置了签名私钥时）、入口二进制文件和额外文件
 // config: 打包配置
 // binaryPath: 二进制文件路径
-// tempDir: 临时目录
 // 返回: 错误信息
-func (p *VKPPackager) createVKPPackage(config *PackageConfig, binaryPath, tempDir string) error {
-	// 创建元数据文件
-	metadataPath := filepath.Join(tempDir, "metadata.json")
-	if err := p.createMetadataFile(config.Metadata, metadataPath); err != nil {
-		return fmt.Errorf("failed to create metadata file: %w", err)
+func (p *VKPPackager) createVKPPackage(config *PackageConfig, binaryPath string) error {
+	entrypoint := DefaultEntrypoint
+	if config.GOOS == "windows" {
+		entrypoint += ".exe"
+	}
+	
+	files := []PackageFile{{Source: binaryPath, Path: entrypoint}}
+	
+	// 添加额外文件
+	for _, filePath := range config.IncludeFiles {
+		stat, err := os.Stat(filePath)
+		if err != nil {
+			continue
+		}
+		if !stat.Mode().IsRegular() {
+			p.logger.Warn("Skipping non-regular file", zap.String("file", filePath))
+			continue
+		}
+		
+		fileName := filepath.Base(filePath)
+		if err := validateArchivePath(fileName); err != nil || fileName == entrypoint {
+			p.logger.Warn("Failed to add file to VKP", 
+				zap.String("file", filePath),
+				zap.String("reason", "conflicting or invalid file name"))
+			continue
+		}
+		files = append(files, PackageFile{Source: filePath, Path: fileName})
+	}
+	
+	manifest, err := BuildManifest(packageMetadata(config.Metadata), entrypoint, packagePlatform(config), files)
+	if err != nil {
+		return fmt.Errorf("failed to build manifest: %w", err)
+	}
+	
+	var signer ed25519.PrivateKey
+	if config.SigningKey != "" {
+		signer, err = LoadPrivateKey(config.SigningKey)
+		if err != nil {
+			return fmt.Errorf("failed to load signing key: %w", err)
+		}
 	}
 	
 	// 创建VKP文件
@@ -170,44 +207,29 @@ func (p *VKPPackager) createVKPPackage(config *PackageConfig, binaryPath, tempDi
 	}
 	defer vkpFile.Close()
 	
-	// 创建gzip压缩器
-	gzWriter := gzip.NewWriter(vkpFile)
-	defer gzWriter.Close()
-	
-	// 创建tar归档器
-	tarWriter := tar.NewWriter(gzWriter)
-	defer tarWriter.Close()
-	
-	// 添加二进制文件
-	if err := p.addFileToTar(tarWriter, binaryPath, "plugin"); err != nil {
-		return fmt.Errorf("failed to add binary to VKP: %w", err)
+	if err := WriteVKP(vkpFile, manifest, files, signer); err != nil {
+		return err
 	}
 	
-	// 添加元数据文件
-	if err := p.addFileToTar(tarWriter, metadataPath, "metadata.json"); err != nil {
-		return fmt.Errorf("failed to add metadata to VKP: %w", err)
+	fields := []zap.Field{
+		zap.String("plugin", manifest.Plugin.Name),
+		zap.String("version", manifest.Plugin.Version),
+		zap.String("platform", manifest.Platform.String()),
+		zap.Int("files", len(manifest.Files)),
+		zap.Bool("signed", signer != nil),
 	}
-	
-	// 添加额外文件
-	for _, filePath := range config.IncludeFiles {
-		if _, err := os.Stat(filePath); err == nil {
-			fileName := filepath.Base(filePath)
-			if err := p.addFileToTar(tarWriter, filePath, fileName); err != nil {
-				p.logger.Warn("Failed to add file to VKP", 
-					zap.String("file", filePath),
-					zap.Error(err))
-			}
-		}
+	if signer != nil {
+		fields = append(fields, zap.String("key_id", KeyID(signer.Public().(ed25519.PublicKey))))
 	}
+	p.logger.Info("VKP manifest written", fields...)
 	
-	return nil
+	return vkpFile.Close()
 }
 
-// createMetadataFile 创建元数据文件
+// packageMetadata 补全打包使用的插件元数据
 // metadata: 插件元数据
-// filePath: 文件路径
-// 返回: 错误信息
-func (p *VKPPackager) createMetadataFile(metadata *PluginMetadata, filePath string) error {
+// 返回: 补全后的插件元数据
+func packageMetadata(metadata *PluginMetadata) *PluginMetadata {
 	if metadata == nil {
 		metadata = &PluginMetadata{
 			Name:        "unknown",
@@ -217,52 +239,32 @@ func (p *VKPPackager) createMetadataFile(metadata *PluginMetadata, filePath stri
 		}
 	}
 	
-	// 添加构建时间
 	if metadata.APIVersion == "" {
 		metadata.APIVersion = "v1"
 	}
 	
-	data, err := json.MarshalIndent(metadata, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal metadata: %w", err)
-	}
-	
-	return os.WriteFile(filePath, data, 0644)
+	return metadata
 }
 
-// addFileToTar 添加文件到tar归档
-// tarWriter: tar写入器
-// filePath: 文件路径
-// tarPath: tar中的路径
-// 返回: 错误信息
-func (p *VKPPackager) addFileToTar(tarWriter *tar.Writer, filePath, tarPath string) error {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to open file %s: %w", filePath, err)
+// packagePlatform 获取打包的目标平台
+// 未指定GOOS/GOARCH时与go build一致，使用环境变量或当前平台
+// config: 打包配置
+// 返回: 目标平台
+func packagePlatform(config *PackageConfig) Platform {
+	platform := Platform{OS: config.GOOS, Arch: config.GOARCH}
+	if platform.OS == "" {
+		platform.OS = os.Getenv("GOOS")
 	}
-	defer file.Close()
-	
-	stat, err := file.Stat()
-	if err != nil {
-		return fmt.Errorf("failed to stat file %s: %w", filePath, err)
+	if platform.OS == "" {
+		platform.OS = runtime.GOOS
 	}
-	
-	header := &tar.Header{
-		Name:    tarPath,
-		Size:    stat.Size(),
-		Mode:    int64(stat.Mode()),
-		ModTime: stat.ModTime(),
+	if platform.Arch == "" {
+		platform.Arch = os.Getenv("GOARCH")
 	}
-	
-	if err := tarWriter.WriteHeader(header); err != nil {
-		return fmt.Errorf("failed to write tar header: %w", err)
+	if platform.Arch == "" {
+		platform.Arch = runtime.GOARCH
 	}
-	
-	if _, err := io.Copy(tarWriter, file); err != nil {
-		return fmt.Errorf("failed to copy file data: %w", err)
-	}
-	
-	return nil
+	return platform
 }
 
 // PackageFromConfig 从配置文件打包
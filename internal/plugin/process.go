@@ -0,0 +1,520 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// NetworkTCP 通过回环地址上的TCP端口与插件通信
+	NetworkTCP = "tcp"
+	
+	// NetworkUnix 通过解压目录下的Unix域套接字与插件通信
+	NetworkUnix = "unix"
+	
+	// readyHandshakeEnv 网关设置此环境变量，要求子进程在就绪后输出握手行
+	readyHandshakeEnv = "VKP_READY_HANDSHAKE"
+	
+	// readyHandshakePrefix 子进程就绪握手行前缀
+	readyHandshakePrefix = "VKP_READY"
+	
+	// defaultReadyTimeout 默认就绪等待超时时间
+	defaultReadyTimeout = 30 * time.Second
+	
+	// defaultStopTimeout 默认优雅停止超时时间，超时后发送SIGKILL
+	defaultStopTimeout = 10 * time.Second
+	
+	// readyPollInterval 健康检查轮询间隔
+	readyPollInterval = 200 * time.Millisecond
+	
+	// maxUnixSocketPath Unix域套接字路径长度上限（兼容darwin的104字节）
+	maxUnixSocketPath = 104
+	
+	// maxSpawnAttempts 子进程未就绪即退出时最多尝试启动的次数，每次使用新分配的端口
+	maxSpawnAttempts = 3
+)
+
+// errExitedBeforeReady 子进程在就绪前退出，如分配的端口在子进程绑定前被其他进程占用
+var errExitedBeforeReady = errors.New("plugin process exited before ready")
+
+// ProcessOptions VKP插件进程运行选项
+type ProcessOptions struct {
+	// Network 与子进程通信的网络类型: tcp 或 unix
+	Network string
+	
+	// ReadyTimeout 等待子进程就绪的超时时间
+	ReadyTimeout time.Duration
+	
+	// RestartPolicy 子进程退出后的重启策略
+	RestartPolicy RestartPolicy
+	
+	// StopTimeout 卸载插件时等待子进程优雅退出的时间
+	StopTimeout time.Duration
+	
+	// LogBufferSize 内存中保留的插件日志条数
+	LogBufferSize int
+	
+	// Isolation 资源限制和隔离选项
+	Isolation IsolationOptions
+}
+
+// DefaultProcessOptions 返回默认的进程运行选项
+// 返回: 进程运行选项
+func DefaultProcessOptions() ProcessOptions {
+	return ProcessOptions{
+		Network:       NetworkTCP,
+		ReadyTimeout:  defaultReadyTimeout,
+		RestartPolicy: DefaultRestartPolicy(),
+		StopTimeout:   defaultStopTimeout,
+		LogBufferSize: defaultLogBufferSize,
+	}
+}
+
+// withDefaults 填充未设置的选项
+// 返回: 补全后的进程运行选项
+func (o ProcessOptions) withDefaults() ProcessOptions {
+	if o.Network == "" {
+		o.Network = NetworkTCP
+	}
+	if o.ReadyTimeout <= 0 {
+		o.ReadyTimeout = defaultReadyTimeout
+	}
+	if o.StopTimeout <= 0 {
+		o.StopTimeout = defaultStopTimeout
+	}
+	if o.LogBufferSize <= 0 {
+		o.LogBufferSize = defaultLogBufferSize
+	}
+	o.RestartPolicy = o.RestartPolicy.withDefaults()
+	return o
+}
+
+// processEndpoint 插件子进程的监听端点
+type processEndpoint struct {
+	// network 网络类型
+	network string
+	
+	// address 监听地址（host:port 或套接字路径）
+	address string
+}
+
+// allocateEndpoint 为插件子进程分配监听端点
+// TCP端口在子进程绑定前释放，期间可能被其他进程占用，调用方需在子进程未就绪即退出时重新分配
+// network: 网络类型
+// workDir: 插件工作目录（用于存放Unix域套接字）
+// 返回: 监听端点和错误信息
+func allocateEndpoint(network, workDir string) (*processEndpoint, error) {
+	switch network {
+	case NetworkTCP:
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate port: %w", err)
+		}
+		addr := ln.Addr().String()
+		if err := ln.Close(); err != nil {
+			return nil, fmt.Errorf("failed to release allocated port: %w", err)
+		}
+		return &processEndpoint{network: NetworkTCP, address: addr}, nil
+	case NetworkUnix:
+		socketPath, err := filepath.Abs(filepath.Join(workDir, "plugin.sock"))
+		if err != nil {
+			return nil, err
+		}
+		if len(socketPath) >= maxUnixSocketPath {
+			return nil, fmt.Errorf("unix socket path too long (%d bytes): %s", len(socketPath), socketPath)
+		}
+		if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+		return &processEndpoint{network: NetworkUnix, address: socketPath}, nil
+	default:
+		return nil, fmt.Errorf("unsupported plugin network: %s", network)
+	}
+}
+
+// args 返回传递给子进程的监听参数
+// 返回: 命令行参数
+func (e *processEndpoint) args() []string {
+	if e.network == NetworkUnix {
+		return []string{"--socket=" + e.address}
+	}
+	
+	_, port, _ := net.SplitHostPort(e.address)
+	return []string{"--port=" + port}
+}
+
+// url 返回上游基础地址
+// 返回: 上游URL
+func (e *processEndpoint) url() *url.URL {
+	if e.network == NetworkUnix {
+		// 主机名仅用于构造请求，实际连接由transport拨号到套接字
+		return &url.URL{Scheme: "http", Host: "unix"}
+	}
+	return &url.URL{Scheme: "http", Host: e.address}
+}
+
+// transport 返回连接到端点的HTTP传输层
+// 返回: HTTP传输层
+func (e *processEndpoint) transport() *http.Transport {
+	transport := newPluginTransport()
+	if e.network == NetworkUnix {
+		dialer := &net.Dialer{Timeout: 5 * time.Second}
+		socketPath := e.address
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+	}
+	return transport
+}
+
+// cleanup 清理端点占用的资源
+func (e *processEndpoint) cleanup() {
+	if e.network == NetworkUnix {
+		_ = os.Remove(e.address)
+	}
+}
+
+// String 返回端点描述
+// 返回: 端点描述
+func (e *processEndpoint) String() string {
+	return e.network + "://" + e.address
+}
+
+// pluginProcess 运行中的插件子进程
+type pluginProcess struct {
+	// cmd 子进程命令
+	cmd *exec.Cmd
+	
+	// endpoint 监听端点
+	endpoint *processEndpoint
+	
+	// startedAt 启动时间
+	startedAt time.Time
+	
+	// handshake 收到就绪握手行时关闭
+	handshake chan struct{}
+	
+	// done 进程退出时关闭
+	done chan struct{}
+	
+	// waitErr cmd.Wait的返回值，done关闭后可读
+	waitErr error
+	
+	// mu 保护reaped，确保只在组长未被回收时向进程组发送信号
+	mu sync.Mutex
+	
+	// reaped 组长进程是否已被回收，回收后其ID可能被复用为其他插件的进程组ID
+	reaped bool
+	
+	// handshakeOnce 保证handshake只关闭一次
+	handshakeOnce sync.Once
+}
+
+// startPluginProcess 启动插件子进程
+// 子进程在隔离环境中启动，退出后释放隔离环境
+// execPath: 可执行文件路径
+// workDir: 工作目录
+// endpoint: 监听端点
+// extraArgs: 追加的命令行参数
+// env: 子进程的环境变量，就绪握手变量会自动追加
+// sandbox: 隔离环境
+// sink: 子进程输出接收器
+// 返回: 插件子进程和错误信息
+func startPluginProcess(execPath, workDir string, endpoint *processEndpoint, extraArgs, env []string, sandbox *processSandbox, sink *pluginLogSink) (*pluginProcess, error) {
+	args := append([]string{"--mode=gateway"}, endpoint.args()...)
+	args = append(args, extraArgs...)
+	cmd := exec.Command(execPath, args...)
+	cmd.Dir = workDir
+	cmd.Env = append(append([]string(nil), env...), readyHandshakeEnv+"=1")
+	setProcessGroup(cmd)
+	if err := sandbox.apply(cmd); err != nil {
+		sandbox.release()
+		return nil, fmt.Errorf("failed to isolate VKP plugin: %w", err)
+	}
+	
+	// 使用独立管道而非StdoutPipe，避免cmd.Wait在读取完成前关闭管道
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		sandbox.release()
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	stderrReader, stderrWriter, err := os.Pipe()
+	if err != nil {
+		stdoutReader.Close()
+		stdoutWriter.Close()
+		sandbox.release()
+		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+	cmd.Stdout = stdoutWriter
+	cmd.Stderr = stderrWriter
+	
+	if err := cmd.Start(); err != nil {
+		stdoutReader.Close()
+		stdoutWriter.Close()
+		stderrReader.Close()
+		stderrWriter.Close()
+		sandbox.release()
+		return nil, fmt.Errorf("failed to start VKP plugin: %w", err)
+	}
+	stdoutWriter.Close()
+	stderrWriter.Close()
+	
+	sandbox.started()
+	
+	proc := &pluginProcess{
+		cmd:       cmd,
+		endpoint:  endpoint,
+		startedAt: time.Now(),
+		handshake: make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	
+	go proc.readStdout(stdoutReader, sink)
+	go proc.readStderr(stderrReader, sink)
+	go proc.wait(sandbox)
+	
+	return proc, nil
+}
+
+// wait 等待子进程退出并回收
+// sandbox: 隔离环境，回收后释放
+func (pp *pluginProcess) wait(sandbox *processSandbox) {
+	pp.reap(waitExited(pp.pid()))
+	sandbox.release()
+	close(pp.done)
+}
+
+// reap 回收子进程并标记为已回收
+// 已确认组长退出时（Linux），在锁内结束进程组中残留的子孙进程后回收，此时组长ID尚未释放，
+// 不会误杀其他进程组，且回收不会阻塞；否则不持锁等待回收，避免terminate和kill在插件运行期间一直阻塞，
+// 代价是回收与标记之间存在短暂窗口
+// exited: 组长是否已退出但尚未回收
+func (pp *pluginProcess) reap(exited bool) {
+	if exited {
+		pp.mu.Lock()
+		_ = killProcessGroup(pp.pid())
+		pp.waitErr = pp.cmd.Wait()
+		pp.reaped = true
+		pp.mu.Unlock()
+		return
+	}
+	
+	err := pp.cmd.Wait()
+	pp.mu.Lock()
+	pp.waitErr = err
+	pp.reaped = true
+	pp.mu.Unlock()
+}
+
+// signalGroup 在组长未被回收时向进程组发送信号，已回收时忽略
+// send: 发送信号的函数
+// 返回: 错误信息
+func (pp *pluginProcess) signalGroup(send func(pid int) error) error {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	
+	if pp.reaped {
+		return nil
+	}
+	return send(pp.pid())
+}
+
+// readStdout 读取子进程标准输出，识别就绪握手行并转发其余输出
+// r: 标准输出读取端
+// sink: 子进程输出接收器
+func (pp *pluginProcess) readStdout(r io.ReadCloser, sink *pluginLogSink) {
+	defer r.Close()
+	
+	sink.consume(r, StreamStdout, pp.pid(), func(line string) bool {
+		if !strings.HasPrefix(line, readyHandshakePrefix) {
+			return true
+		}
+		sink.logger.Debug("Plugin handshake received", 
+			zap.Int("pid", pp.pid()),
+			zap.String("line", line))
+		pp.handshakeOnce.Do(func() { close(pp.handshake) })
+		return false
+	})
+}
+
+// readStderr 读取子进程标准错误并转发
+// r: 标准错误读取端
+// sink: 子进程输出接收器
+func (pp *pluginProcess) readStderr(r io.ReadCloser, sink *pluginLogSink) {
+	defer r.Close()
+	
+	sink.consume(r, StreamStderr, pp.pid(), nil)
+}
+
+// pid 返回子进程ID
+// 返回: 进程ID
+func (pp *pluginProcess) pid() int {
+	if pp.cmd.Process == nil {
+		return 0
+	}
+	return pp.cmd.Process.Pid
+}
+
+// exited 子进程是否已退出
+// 返回: 是否已退出
+func (pp *pluginProcess) exited() bool {
+	select {
+	case <-pp.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// exitCode 返回子进程退出码，进程未退出或被信号终止时返回-1
+// 返回: 退出码
+func (pp *pluginProcess) exitCode() int {
+	if !pp.exited() || pp.cmd.ProcessState == nil {
+		return -1
+	}
+	return pp.cmd.ProcessState.ExitCode()
+}
+
+// waitReady 等待子进程就绪
+// 收到握手行或/health返回200即视为就绪
+// ctx: 上下文
+// timeout: 超时时间
+// 返回: 错误信息
+func (pp *pluginProcess) waitReady(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	
+	healthURL := pp.endpoint.url().JoinPath("health").String()
+	client := &http.Client{
+		Transport: pp.endpoint.transport(),
+		Timeout:   time.Second,
+	}
+	defer client.CloseIdleConnections()
+	
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-pp.handshake:
+			return nil
+		case <-pp.done:
+			return fmt.Errorf("%w: %v", errExitedBeforeReady, pp.waitErr)
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("plugin not ready within %s on %s", timeout, pp.endpoint)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+			if checkHealth(ctx, client, healthURL) {
+				return nil
+			}
+		}
+	}
+}
+
+// checkHealth 请求子进程健康检查接口
+// ctx: 上下文
+// client: HTTP客户端
+// healthURL: 健康检查地址
+// 返回: 是否健康
+func checkHealth(ctx context.Context, client *http.Client, healthURL string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
+	if err != nil {
+		return false
+	}
+	
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	
+	return resp.StatusCode == http.StatusOK
+}
+
+// kill 强制结束子进程所在进程组并等待回收
+func (pp *pluginProcess) kill() {
+	_ = pp.signalGroup(killProcessGroup)
+	<-pp.done
+	pp.endpoint.cleanup()
+}
+
+// terminate 优雅停止子进程
+// 向进程组发送SIGTERM并等待退出，ctx到期后升级为SIGKILL；
+// 组长退出后残留的子孙进程由wait在回收前清理
+// ctx: 上下文，其截止时间即优雅退出时限
+// 返回: 退出状态、是否被强制结束和错误信息
+func (pp *pluginProcess) terminate(ctx context.Context) (*os.ProcessState, bool, error) {
+	defer pp.endpoint.cleanup()
+	
+	forced := false
+	if !pp.exited() {
+		if err := pp.signalGroup(terminateProcessGroup); err != nil {
+			return nil, false, fmt.Errorf("failed to send SIGTERM: %w", err)
+		}
+		
+		select {
+		case <-pp.done:
+		case <-ctx.Done():
+			forced = true
+			if err := pp.signalGroup(killProcessGroup); err != nil {
+				return nil, true, fmt.Errorf("failed to send SIGKILL: %w", err)
+			}
+			<-pp.done
+		}
+	}
+	return pp.cmd.ProcessState, forced, nil
+}
+
+// parseListenArgs 从命令行参数解析监听地址
+// 支持 --port N、--port=N、--socket PATH、--socket=PATH；--mode=gateway时TCP只监听127.0.0.1
+// args: 命令行参数
+// defaultPort: 默认端口
+// 返回: 网络类型和监听地址
+func parseListenArgs(args []string, defaultPort int) (string, string) {
+	// 网关模式下子进程只接受网关的连接，仅监听回环地址
+	host := ""
+	for _, arg := range args {
+		if arg == "--mode=gateway" {
+			host = "127.0.0.1"
+		}
+	}
+	network, address := NetworkTCP, fmt.Sprintf("%s:%d", host, defaultPort)
+	
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if !hasValue && i+1 < len(args) && (name == "--port" || name == "--socket") {
+			value = args[i+1]
+			i++
+		}
+		
+		switch name {
+		case "--port":
+			if p, err := strconv.Atoi(value); err == nil {
+				network, address = NetworkTCP, fmt.Sprintf("%s:%d", host, p)
+			}
+		case "--socket":
+			if value != "" {
+				network, address = NetworkUnix, value
+			}
+		}
+	}
+	
+	return network, address
+}
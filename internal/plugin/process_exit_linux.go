@@ -0,0 +1,29 @@
+//go:build linux
+
+package plugin
+
+import (
+	"errors"
+	"syscall"
+	"unsafe"
+)
+
+// pidTypePID waitid的P_PID，syscall包未导出该常量
+const pidTypePID = 1
+
+// waitExited 等待子进程退出但不回收，进程ID在回收前不会被复用
+// pid: 子进程ID
+// 返回: 是否已确认退出，失败时返回false
+func waitExited(pid int) bool {
+	// siginfo_t固定为128字节
+	var info [128]byte
+	for {
+		_, _, errno := syscall.Syscall6(syscall.SYS_WAITID, pidTypePID, uintptr(pid), uintptr(unsafe.Pointer(&info[0])), syscall.WEXITED|syscall.WNOWAIT, 0, 0)
+		if errno == 0 {
+			return true
+		}
+		if !errors.Is(errno, syscall.EINTR) {
+			return false
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package plugin
+
+// waitExited 当前平台无法在不回收的情况下等待子进程退出
+// pid: 子进程ID
+// 返回: 始终为false
+func waitExited(pid int) bool {
+	return false
+}
@@ -0,0 +1,33 @@
+//go:build !unix
+
+package plugin
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup 非Unix平台不支持进程组，保持默认行为
+// cmd: 子进程命令
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcessGroup 非Unix平台没有SIGTERM，直接结束进程
+// pid: 进程ID
+// 返回: 错误信息
+func terminateProcessGroup(pid int) error {
+	return killProcessGroup(pid)
+}
+
+// killProcessGroup 结束进程
+// pid: 进程ID
+// 返回: 错误信息
+func killProcessGroup(pid int) error {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return nil
+	}
+	if err := proc.Kill(); err != nil && err != os.ErrProcessDone {
+		return err
+	}
+	return nil
+}
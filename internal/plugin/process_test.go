@@ -0,0 +1,448 @@
+//go:build unix
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// helperProcessEnv 设置后TestHelperProcess作为插件子进程运行，值为运行模式
+	helperProcessEnv = "VKP_TEST_HELPER_PROCESS"
+
+	// helperDirEnv 子进程记录启动参数的目录
+	helperDirEnv = "VKP_TEST_HELPER_DIR"
+)
+
+// TestHelperProcess 不是真正的测试，由writeHelperPlugin生成的脚本调用，模拟插件子进程
+// 运行模式:
+//   - ready: 输出日志后输出握手行，一直运行到被信号结束
+//   - health: 不输出握手行，在分配的端点上提供/health
+//   - hang: 不输出握手行也不监听，一直运行
+//   - ignore-term: 输出握手行后忽略SIGTERM
+//   - exit:N: 立即以退出码N退出
+//   - fail-first: 第一次启动以退出码3退出，之后同ready
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv(helperProcessEnv)
+	if mode == "" {
+		return
+	}
+	os.Exit(runHelperProcess(mode, flag.Args()))
+}
+
+// runHelperProcess 按运行模式模拟插件子进程
+// mode: 运行模式
+// args: 网关传给插件的命令行参数
+// 返回: 退出码
+func runHelperProcess(mode string, args []string) int {
+	starts := 0
+	if dir := os.Getenv(helperDirEnv); dir != "" {
+		path := filepath.Join(dir, "starts")
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err == nil {
+			fmt.Fprintln(f, strings.Join(args, " "))
+			f.Close()
+		}
+		data, _ := os.ReadFile(path)
+		starts = strings.Count(string(data), "\n")
+	}
+
+	if code, ok := strings.CutPrefix(mode, "exit:"); ok {
+		n, _ := strconv.Atoi(code)
+		return n
+	}
+
+	switch mode {
+	case "fail-first":
+		if starts <= 1 {
+			fmt.Fprintln(os.Stderr, "address already in use")
+			return 3
+		}
+	case "ignore-term":
+		signal.Ignore(syscall.SIGTERM)
+	case "health":
+		network, address := parseListenArgs(args, 0)
+		ln, err := net.Listen(network, address)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		go http.Serve(ln, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/health" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		time.Sleep(time.Hour)
+		return 0
+	case "hang":
+		time.Sleep(time.Hour)
+		return 0
+	}
+
+	fmt.Fprintln(os.Stderr, "warming up")
+	fmt.Println("booting")
+	fmt.Println(readyHandshakePrefix + " pid=" + strconv.Itoa(os.Getpid()))
+	fmt.Println("serving")
+	time.Sleep(time.Hour)
+	return 0
+}
+
+// writeHelperPlugin 写入以测试二进制模拟插件的可执行脚本
+// mode: TestHelperProcess运行模式
+// 返回: 脚本路径，子进程的启动参数记录在同一目录的starts文件中
+func writeHelperPlugin(t *testing.T, mode string) string {
+	t.Helper()
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plugin")
+	script := fmt.Sprintf("#!/bin/sh\n%s=%q %s=%q exec %q -test.run='^TestHelperProcess$' -- \"$@\"\n",
+		helperProcessEnv, mode, helperDirEnv, dir, exe)
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// helperStarts 返回脚本所模拟插件每次启动时的命令行参数
+// path: writeHelperPlugin返回的脚本路径
+// 返回: 每次启动的参数
+func helperStarts(t *testing.T, path string) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(path), "starts"))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+// startHelperProcess 启动模拟插件子进程，测试结束时强制结束
+// mode: TestHelperProcess运行模式
+// network: 网络类型
+// 返回: 插件子进程和日志缓冲区
+func startHelperProcess(t *testing.T, mode, network string) (*pluginProcess, *LogBuffer) {
+	t.Helper()
+
+	workDir := t.TempDir()
+	endpoint, err := allocateEndpoint(network, workDir)
+	if err != nil {
+		t.Fatalf("allocateEndpoint() error: %v", err)
+	}
+	sandbox, err := newProcessSandbox("demo", IsolationOptions{}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs := NewLogBuffer(0)
+	sink := newPluginLogSink(zap.NewNop(), "demo", "1.0.0", logs)
+	proc, err := startPluginProcess(writeHelperPlugin(t, mode), workDir, endpoint, nil, nil, sandbox, sink)
+	if err != nil {
+		t.Fatalf("startPluginProcess() error: %v", err)
+	}
+	t.Cleanup(proc.kill)
+	return proc, logs
+}
+
+func TestAllocateEndpoint(t *testing.T) {
+	dir := t.TempDir()
+
+	tcp, err := allocateEndpoint(NetworkTCP, dir)
+	if err != nil {
+		t.Fatalf("allocateEndpoint(tcp) error: %v", err)
+	}
+	host, port, err := net.SplitHostPort(tcp.address)
+	if err != nil || host != "127.0.0.1" || port == "0" {
+		t.Fatalf("tcp address = %q", tcp.address)
+	}
+	if got := tcp.args(); len(got) != 1 || got[0] != "--port="+port {
+		t.Errorf("tcp args = %q", got)
+	}
+
+	stale := filepath.Join(dir, "plugin.sock")
+	if err := os.WriteFile(stale, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	unix, err := allocateEndpoint(NetworkUnix, dir)
+	if err != nil {
+		t.Fatalf("allocateEndpoint(unix) error: %v", err)
+	}
+	if unix.address != stale || unix.url().Host != "unix" {
+		t.Errorf("unix endpoint = %s, url %s", unix, unix.url())
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale socket was not removed: %v", err)
+	}
+
+	if _, err := allocateEndpoint(NetworkUnix, filepath.Join(dir, strings.Repeat("x", maxUnixSocketPath))); err == nil {
+		t.Error("allocateEndpoint() accepted a socket path that is too long")
+	}
+	if _, err := allocateEndpoint("udp", dir); err == nil {
+		t.Error("allocateEndpoint() accepted an unsupported network")
+	}
+}
+
+func TestWaitReady(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		network string
+		timeout time.Duration
+		wantErr string
+	}{
+		{name: "handshake", mode: "ready", network: NetworkTCP, timeout: 10 * time.Second},
+		{name: "health over tcp", mode: "health", network: NetworkTCP, timeout: 10 * time.Second},
+		{name: "health over unix socket", mode: "health", network: NetworkUnix, timeout: 10 * time.Second},
+		{name: "timeout", mode: "hang", network: NetworkTCP, timeout: 300 * time.Millisecond, wantErr: "not ready within 300ms"},
+		{name: "exited before ready", mode: "exit:2", network: NetworkTCP, timeout: 10 * time.Second, wantErr: errExitedBeforeReady.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proc, _ := startHelperProcess(t, tt.mode, tt.network)
+			err := proc.waitReady(context.Background(), tt.timeout)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("waitReady() error: %v", err)
+				}
+				if proc.exited() {
+					t.Fatal("process exited after becoming ready")
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("waitReady() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWaitReadyCancelled(t *testing.T) {
+	proc, _ := startHelperProcess(t, "hang", NetworkTCP)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := proc.waitReady(ctx, 10*time.Second); !errors.Is(err, context.Canceled) {
+		t.Fatalf("waitReady() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestHandshakeLineIsNotLogged(t *testing.T) {
+	proc, logs := startHelperProcess(t, "ready", NetworkTCP)
+	if err := proc.waitReady(context.Background(), 10*time.Second); err != nil {
+		t.Fatalf("waitReady() error: %v", err)
+	}
+
+	var entries []LogEntry
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if entries = logs.Entries(0); len(entries) == 3 {
+			break
+		}
+	}
+	got := make(map[string]string)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Message, readyHandshakePrefix) {
+			t.Errorf("handshake line was logged: %+v", entry)
+		}
+		if entry.PID != proc.pid() {
+			t.Errorf("entry pid = %d, want %d", entry.PID, proc.pid())
+		}
+		got[entry.Message] = entry.Stream + "/" + entry.Level
+	}
+	want := map[string]string{
+		"booting":    StreamStdout + "/info",
+		"serving":    StreamStdout + "/info",
+		"warming up": StreamStderr + "/warn",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("logged lines = %v, want %v", got, want)
+	}
+}
+
+func TestSpawnRetriesOnNewPort(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       string
+		network    string
+		wantStarts int
+		wantErr    bool
+	}{
+		{name: "retry after early exit", mode: "fail-first", network: NetworkTCP, wantStarts: 2},
+		{name: "give up", mode: "exit:3", network: NetworkTCP, wantStarts: maxSpawnAttempts, wantErr: true},
+		{name: "unix socket does not retry", mode: "exit:3", network: NetworkUnix, wantStarts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := writeHelperPlugin(t, tt.mode)
+			p := &VKPPlugin{
+				execPath: script,
+				metadata: &PluginMetadata{Name: "demo", Version: "1.0.0"},
+				logger:   zap.NewNop(),
+				workDir:  t.TempDir(),
+				options:  ProcessOptions{Network: tt.network, ReadyTimeout: 10 * time.Second}.withDefaults(),
+				logs:     NewLogBuffer(0),
+			}
+
+			proc, err := p.spawn(context.Background())
+			if proc != nil {
+				t.Cleanup(proc.kill)
+			}
+			starts := helperStarts(t, script)
+			if len(starts) != tt.wantStarts {
+				t.Fatalf("plugin started %d times, want %d: %q", len(starts), tt.wantStarts, starts)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, errExitedBeforeReady) {
+					t.Fatalf("spawn() error = %v, want %v", err, errExitedBeforeReady)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("spawn() error: %v", err)
+			}
+
+			// 每次启动都分配端口，就绪的子进程监听最后一次分配的端口
+			for _, args := range starts {
+				if !strings.Contains(args, "--port=") {
+					t.Errorf("start without a port: %q", args)
+				}
+			}
+			if last := starts[len(starts)-1]; !strings.Contains(last, strings.Join(proc.endpoint.args(), " ")) {
+				t.Errorf("ready process endpoint %s, last start %q", proc.endpoint, last)
+			}
+		})
+	}
+}
+
+// startBlockingWaitHelper 启动模拟插件子进程，回收时不等待组长退出（同waitid不可用的平台），测试结束时强制结束
+// mode: TestHelperProcess运行模式
+// 返回: 插件子进程
+func startBlockingWaitHelper(t *testing.T, mode string) *pluginProcess {
+	t.Helper()
+
+	workDir := t.TempDir()
+	endpoint, err := allocateEndpoint(NetworkTCP, workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(writeHelperPlugin(t, mode), endpoint.args()...)
+	cmd.Stdout = stdoutWriter
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	stdoutWriter.Close()
+
+	proc := &pluginProcess{
+		cmd:       cmd,
+		endpoint:  endpoint,
+		startedAt: time.Now(),
+		handshake: make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go proc.readStdout(stdoutReader, newPluginLogSink(zap.NewNop(), "demo", "1.0.0", NewLogBuffer(0)))
+	go func() {
+		proc.reap(false)
+		close(proc.done)
+	}()
+	t.Cleanup(proc.kill)
+	return proc
+}
+
+func TestTerminateWhileWaiting(t *testing.T) {
+	starters := map[string]func(t *testing.T, mode string) *pluginProcess{
+		"wait for exit": func(t *testing.T, mode string) *pluginProcess {
+			proc, _ := startHelperProcess(t, mode, NetworkTCP)
+			return proc
+		},
+		"blocking wait": startBlockingWaitHelper,
+	}
+	tests := []struct {
+		name       string
+		mode       string
+		wantForced bool
+		wantSignal syscall.Signal
+	}{
+		{name: "graceful", mode: "ready", wantSignal: syscall.SIGTERM},
+		{name: "ignores SIGTERM", mode: "ignore-term", wantForced: true, wantSignal: syscall.SIGKILL},
+	}
+	for starter, start := range starters {
+		for _, tt := range tests {
+			t.Run(starter+"/"+tt.name, func(t *testing.T) {
+				proc := start(t, tt.mode)
+				if err := proc.waitReady(context.Background(), 10*time.Second); err != nil {
+					t.Fatalf("waitReady() error: %v", err)
+				}
+
+				ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+				defer cancel()
+				stopped := make(chan struct{})
+				var state *os.ProcessState
+				var forced bool
+				var err error
+				go func() {
+					state, forced, err = proc.terminate(ctx)
+					close(stopped)
+				}()
+				select {
+				case <-stopped:
+				case <-time.After(5 * time.Second):
+					t.Fatal("terminate() blocked while the process was being waited for")
+				}
+
+				if err != nil {
+					t.Fatalf("terminate() error: %v", err)
+				}
+				if forced != tt.wantForced {
+					t.Errorf("terminate() forced = %v, want %v", forced, tt.wantForced)
+				}
+				status, ok := state.Sys().(syscall.WaitStatus)
+				if !ok || !status.Signaled() || status.Signal() != tt.wantSignal {
+					t.Errorf("exit status = %v, want signal %v", state, tt.wantSignal)
+				}
+			})
+		}
+	}
+}
+
+func TestKillAfterExit(t *testing.T) {
+	proc, _ := startHelperProcess(t, "exit:0", NetworkTCP)
+	<-proc.done
+
+	done := make(chan struct{})
+	go func() {
+		proc.kill()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("kill() blocked after the process was reaped")
+	}
+	if code := proc.exitCode(); code != 0 {
+		t.Errorf("exitCode() = %d, want 0", code)
+	}
+	if state, forced, err := proc.terminate(context.Background()); err != nil || forced || !state.Success() {
+		t.Errorf("terminate() after exit = %v, %v, %v", state, forced, err)
+	}
+}
@@ -0,0 +1,46 @@
+//go:build unix
+
+package plugin
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup 让子进程成为新进程组的组长，便于整体发送信号
+// cmd: 子进程命令
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// terminateProcessGroup 向进程组发送SIGTERM
+// pid: 进程组组长ID
+// 返回: 错误信息
+func terminateProcessGroup(pid int) error {
+	return signalProcessGroup(pid, syscall.SIGTERM)
+}
+
+// killProcessGroup 向进程组发送SIGKILL
+// pid: 进程组组长ID
+// 返回: 错误信息
+func killProcessGroup(pid int) error {
+	return signalProcessGroup(pid, syscall.SIGKILL)
+}
+
+// signalProcessGroup 向进程组发送信号，进程组已不存在时忽略
+// pid: 进程组组长ID
+// sig: 信号
+// 返回: 错误信息
+func signalProcessGroup(pid int, sig syscall.Signal) error {
+	if pid <= 0 {
+		return nil
+	}
+	if err := syscall.Kill(-pid, sig); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
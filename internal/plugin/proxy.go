@@ -0,0 +1,119 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	"github.com/vera-byte/vgo-gateway/pkg/model"
+	"go.uber.org/zap"
+)
+
+// defaultResponseHeaderTimeout 等待插件响应头的默认超时时间
+const defaultResponseHeaderTimeout = 30 * time.Second
+
+// newPluginTransport 创建连接插件子进程的HTTP传输层
+// 返回: HTTP传输层实例
+func newPluginTransport() *http.Transport {
+	return &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   100,
+		IdleConnTimeout:       90 * time.Second,
+		ResponseHeaderTimeout: defaultResponseHeaderTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// newReverseProxy 创建转发到插件子进程的反向代理
+// 逐跳头部（Connection、Keep-Alive、Upgrade等）由httputil.ReverseProxy按RFC 7230剔除，
+// 请求路径与查询参数原样转发，响应立即刷新以支持流式输出
+// name: 插件名称
+// target: 上游地址
+// transport: HTTP传输层
+// logger: 日志记录器
+// 返回: 反向代理实例
+func newReverseProxy(name string, target *url.URL, transport http.RoundTripper, logger *zap.Logger) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			
+			// 保留上游代理链中的X-Forwarded-For，并追加当前客户端地址
+			r.Out.Header["X-Forwarded-For"] = r.In.Header["X-Forwarded-For"]
+			r.SetXForwarded()
+			r.Out.Header.Set("X-Forwarded-Prefix", "/api/v1/"+name)
+		},
+		Transport:     transport,
+		FlushInterval: -1,
+		ErrorHandler:  proxyErrorHandler(name, logger),
+	}
+}
+
+// proxyErrorHandler 创建代理错误处理器
+// 将上游错误映射为model.ErrorResponse，超时返回504，其余返回502
+// 上游错误只写入日志，响应中不包含套接字路径、端口等内部信息
+// name: 插件名称
+// logger: 日志记录器
+// 返回: 错误处理函数
+func proxyErrorHandler(name string, logger *zap.Logger) func(http.ResponseWriter, *http.Request, error) {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		// 客户端主动断开，无需响应
+		if errors.Is(err, context.Canceled) && r.Context().Err() != nil {
+			logger.Debug("Client cancelled plugin request",
+				zap.String("plugin", name),
+				zap.String("path", r.URL.Path))
+			return
+		}
+		
+		status := http.StatusBadGateway
+		message := "Plugin upstream unavailable"
+		if isTimeoutError(err) {
+			status = http.StatusGatewayTimeout
+			message = "Plugin upstream timeout"
+		}
+		
+		logger.Warn("Plugin proxy error",
+			zap.String("plugin", name),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Int("status", status),
+			zap.Error(err))
+		
+		writeErrorResponse(w, status, message)
+	}
+}
+
+// isTimeoutError 判断是否为超时错误
+// err: 错误信息
+// 返回: 是否超时
+func isTimeoutError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// writeErrorResponse 写入JSON格式的错误响应
+// w: 响应写入器
+// status: HTTP状态码
+// message: 错误消息
+func writeErrorResponse(w http.ResponseWriter, status int, message string) {
+	resp := model.ErrorResponse{
+		Code:    status,
+		Message: message,
+	}
+	
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,90 @@
+package plugin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vera-byte/vgo-gateway/pkg/model"
+	"go.uber.org/zap"
+)
+
+func TestReverseProxyForwards(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.RequestURI())
+		w.Header().Set("X-Prefix", r.Header.Get("X-Forwarded-Prefix"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer upstream.Close()
+
+	target, _ := url.Parse(upstream.URL)
+	proxy := newReverseProxy("demo", target, newPluginTransport(), zap.NewNop())
+
+	w := httptest.NewRecorder()
+	proxy.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/demo/items?page=2", nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if got := w.Header().Get("X-Path"); got != "/api/v1/demo/items?page=2" {
+		t.Errorf("upstream path = %q", got)
+	}
+	if got := w.Header().Get("X-Prefix"); got != "/api/v1/demo" {
+		t.Errorf("X-Forwarded-Prefix = %q", got)
+	}
+}
+
+func TestReverseProxyErrors(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedURL := closed.URL
+	closed.Close()
+
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	tests := []struct {
+		name        string
+		upstream    string
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "unreachable", upstream: closedURL, wantStatus: http.StatusBadGateway, wantMessage: "Plugin upstream unavailable"},
+		{name: "timeout", upstream: slow.URL, wantStatus: http.StatusGatewayTimeout, wantMessage: "Plugin upstream timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, _ := url.Parse(tt.upstream)
+			transport := newPluginTransport()
+			transport.ResponseHeaderTimeout = 50 * time.Millisecond
+			defer transport.CloseIdleConnections()
+			proxy := newReverseProxy("demo", target, transport, zap.NewNop())
+
+			w := httptest.NewRecorder()
+			proxy.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/demo/items", nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			var resp model.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid error response %q: %v", w.Body.String(), err)
+			}
+			if resp.Code != tt.wantStatus || resp.Message != tt.wantMessage || resp.Error != "" {
+				t.Errorf("response = %+v", resp)
+			}
+			if strings.Contains(w.Body.String(), target.Host) {
+				t.Errorf("response leaks upstream address: %s", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,476 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// RegistryFormatVersion 当前的仓库索引格式版本
+	RegistryFormatVersion = 1
+
+	// maxRegistryIndexSize 仓库索引的大小上限
+	maxRegistryIndexSize = 16 << 20
+)
+
+var (
+	// ErrRegistryNotConfigured 未配置插件仓库
+	ErrRegistryNotConfigured = errors.New("plugin registry not configured")
+
+	// ErrNoMatchingVersion 仓库中没有满足版本范围且适用于当前平台的版本
+	ErrNoMatchingVersion = errors.New("no matching plugin version")
+
+	// ErrRegistryUnavailable 无法获取或解析仓库索引
+	ErrRegistryUnavailable = errors.New("plugin registry unavailable")
+)
+
+// RegistryIndex 插件仓库索引
+// 以JSON文档发布，列出每个插件的版本以及各平台的插件包
+type RegistryIndex struct {
+	// FormatVersion 索引格式版本
+	FormatVersion int `json:"format_version"`
+
+	// GeneratedAt 索引生成时间
+	GeneratedAt time.Time `json:"generated_at"`
+
+	// Plugins 插件列表
+	Plugins []RegistryPlugin `json:"plugins"`
+}
+
+// RegistryPlugin 仓库中的插件
+type RegistryPlugin struct {
+	// Name 插件名称，与插件清单中的名称一致
+	Name string `json:"name"`
+
+	// Description 插件描述
+	Description string `json:"description,omitempty"`
+
+	// Versions 插件版本
+	Versions []RegistryVersion `json:"versions"`
+}
+
+// RegistryVersion 插件的一个版本
+type RegistryVersion struct {
+	// Version 语义化版本
+	Version string `json:"version"`
+
+	// Packages 各平台的插件包
+	Packages []RegistryPackage `json:"packages"`
+}
+
+// RegistryPackage 仓库中的插件包
+type RegistryPackage struct {
+	// Platform 插件包的目标平台，OS或Arch为空表示不限
+	Platform Platform `json:"platform"`
+
+	// URL 下载地址，相对地址相对于索引地址解析
+	URL string `json:"url"`
+
+	// SHA256 插件包的SHA-256（64位十六进制），必填，安装时校验
+	SHA256 string `json:"sha256"`
+
+	// Size 插件包大小（字节）
+	Size int64 `json:"size,omitempty"`
+
+	// Signature 包内清单签名的副本，用于展示签名者；安装时仍按签名策略校验包内签名
+	Signature *Signature `json:"signature,omitempty"`
+}
+
+// RegistryMatch 按名称和版本范围解析出的插件包
+type RegistryMatch struct {
+	// Name 插件名称
+	Name string
+
+	// Version 插件版本
+	Version SemVer
+
+	// Package 插件包
+	Package RegistryPackage
+}
+
+// Filename 返回安装后的插件文件名
+// 按 <服务名称>_<os>_<arch>_v<版本>.vkp 命名，不依赖下载地址中的文件名
+// 返回: 插件文件名
+func (m *RegistryMatch) Filename() string {
+	osName, arch := m.Package.Platform.OS, m.Package.Platform.Arch
+	if osName == "" {
+		osName = "any"
+	}
+	if arch == "" {
+		arch = "any"
+	}
+	return fmt.Sprintf("%s_%s_%s_v%s.vkp", m.Name, osName, arch, m.Version)
+}
+
+// Validate 校验索引格式
+// 返回: 错误信息
+func (idx *RegistryIndex) Validate() error {
+	if idx.FormatVersion != RegistryFormatVersion {
+		return fmt.Errorf("unsupported registry index format version %d", idx.FormatVersion)
+	}
+	for _, p := range idx.Plugins {
+		if p.Name == "" {
+			return fmt.Errorf("registry index contains a plugin without name")
+		}
+		for _, v := range p.Versions {
+			for _, pkg := range v.Packages {
+				if pkg.URL == "" {
+					return fmt.Errorf("registry index: %s %s has a package without url", p.Name, v.Version)
+				}
+				if pkg.SHA256 == "" || validateSHA256(pkg.SHA256) != nil {
+					return fmt.Errorf("registry index: %s %s has a package without a valid sha256", p.Name, v.Version)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Resolve 查找满足版本范围且适用于指定平台的最高版本
+// 预发布版本仅在版本范围中包含预发布版本时才会选中；同一版本有多个适用的包时优先选择平台限定更具体的包
+// name: 插件名称
+// constraint: 版本范围
+// platform: 运行平台
+// 返回: 解析结果和错误信息，插件不存在时返回ErrPluginNotFound，没有匹配的版本时返回ErrNoMatchingVersion
+func (idx *RegistryIndex) Resolve(name string, constraint VersionConstraint, platform Platform) (*RegistryMatch, error) {
+	var entry *RegistryPlugin
+	for i := range idx.Plugins {
+		if idx.Plugins[i].Name == name {
+			entry = &idx.Plugins[i]
+			break
+		}
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("%w: %s is not in the registry", ErrPluginNotFound, name)
+	}
+
+	allowPrerelease := strings.Contains(constraint.String(), "-")
+	var best *RegistryMatch
+	for _, v := range entry.Versions {
+		version, err := ParseSemVer(v.Version)
+		if err != nil || !constraint.Allows(version) {
+			continue
+		}
+		if version.Prerelease != "" && !allowPrerelease {
+			continue
+		}
+		if best != nil && version.Compare(best.Version) <= 0 {
+			continue
+		}
+		if pkg, ok := selectRegistryPackage(v.Packages, platform); ok {
+			best = &RegistryMatch{Name: name, Version: version, Package: pkg}
+		}
+	}
+	if best == nil {
+		if constraint.IsAny() {
+			return nil, fmt.Errorf("%w: %s has no version for %s", ErrNoMatchingVersion, name, platform)
+		}
+		return nil, fmt.Errorf("%w: %s has no version matching %q for %s", ErrNoMatchingVersion, name, constraint, platform)
+	}
+	return best, nil
+}
+
+// selectRegistryPackage 选择适用于指定平台的插件包，优先选择同时限定OS和架构的包
+// packages: 插件包列表
+// platform: 运行平台
+// 返回: 插件包和是否找到
+func selectRegistryPackage(packages []RegistryPackage, platform Platform) (RegistryPackage, bool) {
+	best, bestScore := RegistryPackage{}, -1
+	for _, pkg := range packages {
+		if !pkg.Platform.Supports(platform) {
+			continue
+		}
+		score := 0
+		if pkg.Platform.OS != "" {
+			score++
+		}
+		if pkg.Platform.Arch != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = pkg, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+// AvailablePlugin 仓库中可用的插件
+type AvailablePlugin struct {
+	// Name 插件名称
+	Name string `json:"name"`
+
+	// Description 插件描述
+	Description string `json:"description,omitempty"`
+
+	// Latest 适用于当前平台的最高稳定版本
+	Latest string `json:"latest,omitempty"`
+
+	// Installed 已安装的版本
+	Installed string `json:"installed,omitempty"`
+
+	// Versions 版本列表，按版本从高到低排序
+	Versions []AvailableVersion `json:"versions"`
+}
+
+// AvailableVersion 仓库中插件的一个版本
+type AvailableVersion struct {
+	// Version 版本
+	Version string `json:"version"`
+
+	// Platforms 提供插件包的平台（os/arch）
+	Platforms []string `json:"platforms"`
+
+	// Supported 是否有适用于当前平台的插件包
+	Supported bool `json:"supported"`
+
+	// Signed 适用于当前平台的插件包是否已签名
+	Signed bool `json:"signed"`
+
+	// KeyID 签名公钥标识
+	KeyID string `json:"key_id,omitempty"`
+}
+
+// Available 汇总索引中适用于指定平台的插件
+// platform: 运行平台
+// 返回: 可用插件列表，按名称排序
+func (idx *RegistryIndex) Available(platform Platform) []AvailablePlugin {
+	plugins := make([]AvailablePlugin, 0, len(idx.Plugins))
+	for _, entry := range idx.Plugins {
+		available := AvailablePlugin{Name: entry.Name, Description: entry.Description}
+		if latest, err := idx.Resolve(entry.Name, VersionConstraint{}, platform); err == nil {
+			available.Latest = latest.Version.String()
+		}
+
+		for _, v := range entry.Versions {
+			version := AvailableVersion{Version: v.Version, Platforms: []string{}}
+			for _, pkg := range v.Packages {
+				version.Platforms = append(version.Platforms, pkg.Platform.String())
+			}
+			if pkg, ok := selectRegistryPackage(v.Packages, platform); ok {
+				version.Supported = true
+				if pkg.Signature != nil {
+					version.Signed, version.KeyID = true, pkg.Signature.KeyID
+				}
+			}
+			available.Versions = append(available.Versions, version)
+		}
+		sort.SliceStable(available.Versions, func(a, b int) bool {
+			va, errA := ParseSemVer(available.Versions[a].Version)
+			vb, errB := ParseSemVer(available.Versions[b].Version)
+			if errA != nil || errB != nil {
+				return errA == nil
+			}
+			return va.Compare(vb) > 0
+		})
+		plugins = append(plugins, available)
+	}
+	sort.Slice(plugins, func(a, b int) bool {
+		return plugins[a].Name < plugins[b].Name
+	})
+	return plugins
+}
+
+// RegistryClient 插件仓库客户端
+// 索引地址支持http、https和file
+type RegistryClient struct {
+	// indexURL 索引地址
+	indexURL *url.URL
+
+	// httpClient HTTP客户端
+	httpClient *http.Client
+}
+
+// NewRegistryClient 创建插件仓库客户端
+// indexURL: 索引地址，不带协议的路径按本地文件处理
+// 返回: 仓库客户端和错误信息
+func NewRegistryClient(indexURL string) (*RegistryClient, error) {
+	parsed, err := url.Parse(indexURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry url: %w", err)
+	}
+
+	switch parsed.Scheme {
+	case "http", "https":
+		if parsed.Host == "" {
+			return nil, fmt.Errorf("invalid registry url: %s", indexURL)
+		}
+	case "file":
+	case "":
+		path, err := filepath.Abs(indexURL)
+		if err != nil {
+			return nil, err
+		}
+		parsed = &url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	default:
+		return nil, fmt.Errorf("unsupported registry url scheme: %s", parsed.Scheme)
+	}
+
+	return &RegistryClient{
+		indexURL:   parsed,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// IndexURL 返回索引地址，其中的密码会被隐藏
+// 返回: 索引地址
+func (r *RegistryClient) IndexURL() string {
+	return r.indexURL.Redacted()
+}
+
+// FetchIndex 获取并校验仓库索引
+// ctx: 上下文
+// 返回: 仓库索引和错误信息，失败时返回包装了ErrRegistryUnavailable的错误
+func (r *RegistryClient) FetchIndex(ctx context.Context) (*RegistryIndex, error) {
+	body, err := r.open(ctx, r.indexURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to fetch %s: %v", ErrRegistryUnavailable, r.indexURL.Redacted(), err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, maxRegistryIndexSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read index: %v", ErrRegistryUnavailable, err)
+	}
+	if len(data) > maxRegistryIndexSize {
+		return nil, fmt.Errorf("%w: index exceeds %d bytes", ErrRegistryUnavailable, maxRegistryIndexSize)
+	}
+
+	var index RegistryIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse index: %v", ErrRegistryUnavailable, err)
+	}
+	if err := index.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRegistryUnavailable, err)
+	}
+	return &index, nil
+}
+
+// resolveURL 将插件包地址解析为绝对地址
+// 只有本地索引中的插件包可以使用file地址，避免远程索引让网关读取本地文件
+// ref: 索引中的地址
+// 返回: 绝对地址和错误信息
+func (r *RegistryClient) resolveURL(ref string) (*url.URL, error) {
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid package url %q: %w", ref, err)
+	}
+	resolved := r.indexURL.ResolveReference(parsed)
+	switch resolved.Scheme {
+	case "http", "https":
+		return resolved, nil
+	case "file":
+		if r.indexURL.Scheme == "file" {
+			return resolved, nil
+		}
+		return nil, fmt.Errorf("package url %q: file urls are only allowed in a local registry", ref)
+	default:
+		return nil, fmt.Errorf("unsupported package url scheme: %s", resolved.Scheme)
+	}
+}
+
+// open 打开索引或插件包
+// ctx: 上下文
+// target: 地址
+// 返回: 内容和错误信息
+func (r *RegistryClient) open(ctx context.Context, target *url.URL) (io.ReadCloser, error) {
+	if target.Scheme == "file" {
+		return os.Open(filepath.FromSlash(target.Path))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "vgo-gateway-plugin-installer/1.0")
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// BuildRegistryIndex 根据目录中的VKP包生成仓库索引
+// 插件包地址为baseURL加上包文件名，baseURL为空时使用相对于索引的文件名
+// dir: 插件包目录
+// baseURL: 插件包的基础地址
+// 返回: 仓库索引和错误信息
+func BuildRegistryIndex(dir, baseURL string) (*RegistryIndex, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.vkp"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	index := &RegistryIndex{FormatVersion: RegistryFormatVersion, GeneratedAt: time.Now().UTC(), Plugins: []RegistryPlugin{}}
+	positions := make(map[string]int)
+	for _, path := range files {
+		desc, err := readPackageFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		manifest := desc.manifest
+		if manifest.IsLegacy() {
+			return nil, fmt.Errorf("%s: legacy packages without manifest cannot be indexed", filepath.Base(path))
+		}
+		size, sum, err := hashFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		pkg := RegistryPackage{
+			Platform: manifest.Platform,
+			URL:      filepath.Base(path),
+			SHA256:   sum,
+			Size:     size,
+		}
+		if baseURL != "" {
+			pkg.URL = strings.TrimSuffix(baseURL, "/") + "/" + url.PathEscape(filepath.Base(path))
+		}
+		if len(desc.signatureData) > 0 {
+			var signature Signature
+			if err := json.Unmarshal(desc.signatureData, &signature); err == nil {
+				pkg.Signature = &signature
+			}
+		}
+
+		name := manifest.Plugin.Name
+		pos, exists := positions[name]
+		if !exists {
+			pos = len(index.Plugins)
+			positions[name] = pos
+			index.Plugins = append(index.Plugins, RegistryPlugin{Name: name, Description: manifest.Plugin.Description})
+		}
+		entry := &index.Plugins[pos]
+
+		added := false
+		for i := range entry.Versions {
+			if entry.Versions[i].Version == manifest.Plugin.Version {
+				entry.Versions[i].Packages = append(entry.Versions[i].Packages, pkg)
+				added = true
+				break
+			}
+		}
+		if !added {
+			entry.Versions = append(entry.Versions, RegistryVersion{Version: manifest.Plugin.Version, Packages: []RegistryPackage{pkg}})
+		}
+	}
+
+	sort.Slice(index.Plugins, func(a, b int) bool {
+		return index.Plugins[a].Name < index.Plugins[b].Name
+	})
+	return index, nil
+}
@@ -0,0 +1,290 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const testSHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func TestRegistryIndexValidate(t *testing.T) {
+	pkg := func(sum string) RegistryIndex {
+		return RegistryIndex{
+			FormatVersion: RegistryFormatVersion,
+			Plugins: []RegistryPlugin{{
+				Name:     "demo",
+				Versions: []RegistryVersion{{Version: "1.0.0", Packages: []RegistryPackage{{URL: "demo.vkp", SHA256: sum}}}},
+			}},
+		}
+	}
+	tests := []struct {
+		name      string
+		index     RegistryIndex
+		wantError bool
+	}{
+		{name: "valid", index: pkg(testSHA256)},
+		{name: "uppercase sha256", index: pkg(strings.ToUpper(testSHA256))},
+		{name: "missing sha256", index: pkg(""), wantError: true},
+		{name: "short sha256", index: pkg(testSHA256[:32]), wantError: true},
+		{name: "non-hex sha256", index: pkg(strings.Repeat("z", 64)), wantError: true},
+		{name: "unsupported format", index: RegistryIndex{FormatVersion: 2}, wantError: true},
+		{name: "plugin without name", index: RegistryIndex{FormatVersion: RegistryFormatVersion, Plugins: []RegistryPlugin{{}}}, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.index.Validate(); (err != nil) != tt.wantError {
+				t.Fatalf("Validate() error = %v, wantError %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestRegistryIndexResolve(t *testing.T) {
+	linux := Platform{OS: "linux", Arch: "amd64"}
+	index := &RegistryIndex{
+		FormatVersion: RegistryFormatVersion,
+		Plugins: []RegistryPlugin{{
+			Name: "demo",
+			Versions: []RegistryVersion{
+				{Version: "1.0.0", Packages: []RegistryPackage{{URL: "any-1.0.0.vkp"}}},
+				{Version: "1.2.0", Packages: []RegistryPackage{
+					{URL: "any-1.2.0.vkp"},
+					{Platform: Platform{OS: "linux"}, URL: "linux-1.2.0.vkp"},
+					{Platform: linux, URL: "linux-amd64-1.2.0.vkp"},
+					{Platform: Platform{OS: "darwin", Arch: "arm64"}, URL: "darwin-arm64-1.2.0.vkp"},
+				}},
+				{Version: "1.3.0", Packages: []RegistryPackage{{Platform: Platform{OS: "windows"}, URL: "windows-1.3.0.vkp"}}},
+				{Version: "2.0.0-beta.1", Packages: []RegistryPackage{{URL: "any-2.0.0-beta.1.vkp"}}},
+				{Version: "not-a-version", Packages: []RegistryPackage{{URL: "broken.vkp"}}},
+			},
+		}},
+	}
+
+	tests := []struct {
+		name       string
+		plugin     string
+		constraint string
+		platform   Platform
+		wantURL    string
+		wantErr    error
+	}{
+		{name: "latest stable", plugin: "demo", platform: linux, wantURL: "linux-amd64-1.2.0.vkp"},
+		{name: "most specific package", plugin: "demo", constraint: "1.2.0", platform: Platform{OS: "linux", Arch: "arm64"}, wantURL: "linux-1.2.0.vkp"},
+		{name: "platform independent package", plugin: "demo", platform: Platform{OS: "freebsd", Arch: "amd64"}, wantURL: "any-1.2.0.vkp"},
+		{name: "platform specific version", plugin: "demo", platform: Platform{OS: "windows", Arch: "amd64"}, wantURL: "windows-1.3.0.vkp"},
+		{name: "constraint", plugin: "demo", constraint: "<1.2.0", platform: linux, wantURL: "any-1.0.0.vkp"},
+		{name: "prerelease requested", plugin: "demo", constraint: ">=2.0.0-beta.1", platform: linux, wantURL: "any-2.0.0-beta.1.vkp"},
+		{name: "no matching version", plugin: "demo", constraint: ">=3.0.0", platform: linux, wantErr: ErrNoMatchingVersion},
+		{name: "unknown plugin", plugin: "missing", platform: linux, wantErr: ErrPluginNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			constraint, err := ParseVersionConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("ParseVersionConstraint(%q) error: %v", tt.constraint, err)
+			}
+			match, err := index.Resolve(tt.plugin, constraint, tt.platform)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error: %v", err)
+			}
+			if match.Package.URL != tt.wantURL {
+				t.Fatalf("Resolve() selected %s, want %s", match.Package.URL, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestRegistryMatchFilename(t *testing.T) {
+	match := &RegistryMatch{Name: "demo", Version: SemVer{Major: 1, Minor: 2}, Package: RegistryPackage{Platform: Platform{OS: "linux"}}}
+	if got, want := match.Filename(), "demo_linux_any_v1.2.0.vkp"; got != want {
+		t.Fatalf("Filename() = %q, want %q", got, want)
+	}
+}
+
+func TestRegistryClientResolveURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		index     string
+		ref       string
+		want      string
+		wantError bool
+	}{
+		{name: "relative to http index", index: "https://plugins.example.com/repo/index.json", ref: "demo.vkp", want: "https://plugins.example.com/repo/demo.vkp"},
+		{name: "absolute http", index: "https://plugins.example.com/repo/index.json", ref: "http://cdn.example.com/demo.vkp", want: "http://cdn.example.com/demo.vkp"},
+		{name: "file from http index", index: "https://plugins.example.com/repo/index.json", ref: "file:///etc/passwd", wantError: true},
+		{name: "relative to file index", index: "file:///srv/repo/index.json", ref: "demo.vkp", want: "file:///srv/repo/demo.vkp"},
+		{name: "file from file index", index: "file:///srv/repo/index.json", ref: "file:///srv/other/demo.vkp", want: "file:///srv/other/demo.vkp"},
+		{name: "http from file index", index: "file:///srv/repo/index.json", ref: "https://cdn.example.com/demo.vkp", want: "https://cdn.example.com/demo.vkp"},
+		{name: "unsupported scheme", index: "https://plugins.example.com/repo/index.json", ref: "ftp://example.com/demo.vkp", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewRegistryClient(tt.index)
+			if err != nil {
+				t.Fatalf("NewRegistryClient(%q) error: %v", tt.index, err)
+			}
+			got, err := client.resolveURL(tt.ref)
+			if tt.wantError {
+				if err == nil {
+					t.Fatalf("resolveURL(%q) = %s, want error", tt.ref, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveURL(%q) error: %v", tt.ref, err)
+			}
+			if got.String() != tt.want {
+				t.Fatalf("resolveURL(%q) = %s, want %s", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+// writeTestRegistry 在dir中生成demo插件1.0.0、1.1.0和2.0.0的包及索引
+// 返回: 索引路径
+func writeTestRegistry(t *testing.T, dir string) string {
+	t.Helper()
+	for _, version := range []string{"1.0.0", "1.1.0", "2.0.0"} {
+		writeTestPackage(t, dir, "demo", version, CurrentPlatform(), nil)
+	}
+	index, err := BuildRegistryIndex(dir, "")
+	if err != nil {
+		t.Fatalf("BuildRegistryIndex() error: %v", err)
+	}
+	return writeTestIndex(t, dir, index)
+}
+
+// writeTestIndex 将索引写入dir/index.json
+// 返回: 索引路径
+func writeTestIndex(t *testing.T, dir string, index *RegistryIndex) string {
+	t.Helper()
+	data, err := json.Marshal(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "index.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestInstallByName(t *testing.T) {
+	repo := t.TempDir()
+	indexPath := writeTestRegistry(t, repo)
+	server := httptest.NewServer(http.FileServer(http.Dir(repo)))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		indexURL    string
+		constraint  string
+		wantVersion string
+	}{
+		{name: "file registry latest", indexURL: indexPath, wantVersion: "2.0.0"},
+		{name: "file registry constraint", indexURL: "file://" + filepath.ToSlash(indexPath), constraint: "^1.0.0", wantVersion: "1.1.0"},
+		{name: "http registry latest", indexURL: server.URL + "/index.json", wantVersion: "2.0.0"},
+		{name: "http registry constraint", indexURL: server.URL + "/index.json", constraint: "~1.0.0", wantVersion: "1.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewRegistryClient(tt.indexURL)
+			if err != nil {
+				t.Fatalf("NewRegistryClient() error: %v", err)
+			}
+			installer := NewPluginInstaller(t.TempDir(), zap.NewNop())
+			installer.SetRegistry(client)
+
+			path, err := installer.InstallByName(context.Background(), "demo", tt.constraint, InstallOptions{})
+			if err != nil {
+				t.Fatalf("InstallByName() error: %v", err)
+			}
+			platform := CurrentPlatform()
+			want := "demo_" + platform.OS + "_" + platform.Arch + "_v" + tt.wantVersion + ".vkp"
+			if filepath.Base(path) != want {
+				t.Fatalf("InstallByName() installed %s, want %s", filepath.Base(path), want)
+			}
+			manifest, _, err := ReadManifestFromVKP(path)
+			if err != nil {
+				t.Fatalf("ReadManifestFromVKP() error: %v", err)
+			}
+			if manifest.Plugin.Version != tt.wantVersion {
+				t.Fatalf("installed version %s, want %s", manifest.Plugin.Version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestInstallByNameRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(index *RegistryIndex, repo string)
+		wantErr error
+	}{
+		{
+			name: "checksum mismatch",
+			edit: func(index *RegistryIndex, repo string) {
+				index.Plugins[0].Versions[2].Packages[0].SHA256 = testSHA256
+			},
+			wantErr: ErrChecksumMismatch,
+		},
+		{
+			name: "missing checksum",
+			edit: func(index *RegistryIndex, repo string) {
+				index.Plugins[0].Versions[2].Packages[0].SHA256 = ""
+			},
+			wantErr: ErrRegistryUnavailable,
+		},
+		{
+			name: "file url in remote index",
+			edit: func(index *RegistryIndex, repo string) {
+				pkg := &index.Plugins[0].Versions[2].Packages[0]
+				pkg.URL = "file://" + filepath.ToSlash(filepath.Join(repo, pkg.URL))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := t.TempDir()
+			writeTestRegistry(t, repo)
+			index, err := BuildRegistryIndex(repo, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.edit(index, repo)
+			writeTestIndex(t, repo, index)
+
+			server := httptest.NewServer(http.FileServer(http.Dir(repo)))
+			defer server.Close()
+			client, err := NewRegistryClient(server.URL + "/index.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			installer := NewPluginInstaller(t.TempDir(), zap.NewNop())
+			installer.SetRegistry(client)
+
+			path, err := installer.InstallByName(context.Background(), "demo", "", InstallOptions{})
+			if err == nil {
+				t.Fatalf("InstallByName() installed %s, want error", path)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("InstallByName() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vera-byte/vgo-gateway/pkg/model"
+)
+
+const (
+	// pluginRoutePrefix 插件路由前缀，路径第一段为插件名称
+	pluginRoutePrefix = "/api/v1/"
+
+	// DefaultDrainTimeout 卸载或升级插件时等待进行中请求完成的默认时间
+	DefaultDrainTimeout = 30 * time.Second
+)
+
+// ErrRouteConflict 插件名称与网关或模块静态注册的路由前缀冲突，插件路由会被静态路由遮蔽
+var ErrRouteConflict = errors.New("plugin route conflict")
+
+// routeEntry 路由表中单个插件的处理器
+// 记录进行中的请求数，下线后不再接受新请求，进行中的请求全部完成时关闭idle
+type routeEntry struct {
+	// handler 插件路由处理器，为nil表示插件正在重新加载
+	handler http.Handler
+
+	// mu 保护active和retired
+	mu sync.Mutex
+
+	// active 进行中的请求数
+	active int
+
+	// retired 是否已下线
+	retired bool
+
+	// idle 下线且没有进行中的请求时关闭
+	idle chan struct{}
+}
+
+// newRouteEntry 创建路由表条目
+// handler: 插件路由处理器，为nil表示插件正在重新加载
+// 返回: 路由表条目
+func newRouteEntry(handler http.Handler) *routeEntry {
+	return &routeEntry{handler: handler, idle: make(chan struct{})}
+}
+
+// acquire 开始处理一个请求
+// 返回: 条目已下线时返回false
+func (e *routeEntry) acquire() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.retired {
+		return false
+	}
+	e.active++
+	return true
+}
+
+// release 结束处理一个请求
+func (e *routeEntry) release() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.active--
+	if e.retired && e.active == 0 {
+		close(e.idle)
+	}
+}
+
+// drain 下线条目并等待进行中的请求完成
+// ctx: 上下文，其截止时间即最长等待时间
+// 返回: 超时时返回错误和仍在进行中的请求数
+func (e *routeEntry) drain(ctx context.Context) error {
+	e.mu.Lock()
+	if !e.retired {
+		e.retired = true
+		if e.active == 0 {
+			close(e.idle)
+		}
+	}
+	e.mu.Unlock()
+
+	select {
+	case <-e.idle:
+		return nil
+	case <-ctx.Done():
+		e.mu.Lock()
+		active := e.active
+		e.mu.Unlock()
+		return fmt.Errorf("%d in-flight requests did not finish: %w", active, ctx.Err())
+	}
+}
+
+// routeTable 插件路由表
+// 网关在/api/v1/下通过NoRoute挂载一个分发处理器，按路径第一段的插件名称查表转发；
+// 路由表写时复制并通过原子指针整体替换，请求处理时无需加锁，插件可在运行时挂载、替换和卸载
+type routeTable struct {
+	// routes 插件名称到路由条目的映射，只读，修改时整体替换
+	routes atomic.Pointer[map[string]*routeEntry]
+
+	// reserved 已被静态路由占用的/api/v1/下的第一段路径，插件不能使用这些名称
+	reserved map[string]bool
+
+	// mu 串行化路由表的修改，保护reserved
+	mu sync.Mutex
+}
+
+// newRouteTable 创建空的插件路由表
+// 返回: 路由表实例
+func newRouteTable() *routeTable {
+	t := &routeTable{}
+	t.routes.Store(&map[string]*routeEntry{})
+	return t
+}
+
+// swap 替换或删除插件的路由条目
+// name: 插件名称
+// entry: 新条目，为nil时删除
+// 返回: 原条目（不存在时为nil）
+func (t *routeTable) swap(name string, entry *routeEntry) *routeEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current := *t.routes.Load()
+	next := make(map[string]*routeEntry, len(current)+1)
+	for k, v := range current {
+		next[k] = v
+	}
+	previous := next[name]
+	if entry == nil {
+		delete(next, name)
+	} else {
+		next[name] = entry
+	}
+	t.routes.Store(&next)
+	return previous
+}
+
+// mount 挂载插件路由，替换同名插件的现有路由
+// name: 插件名称
+// handler: 插件路由处理器
+// 返回: 被替换的条目（不存在时为nil）
+func (t *routeTable) mount(name string, handler http.Handler) *routeEntry {
+	return t.swap(name, newRouteEntry(handler))
+}
+
+// suspend 暂停插件路由，重新加载期间的请求返回503
+// name: 插件名称
+// 返回: 被替换的条目（不存在时为nil）
+func (t *routeTable) suspend(name string) *routeEntry {
+	return t.swap(name, newRouteEntry(nil))
+}
+
+// unmount 移除插件路由，之后的请求返回404
+// name: 插件名称
+// 返回: 被移除的条目（不存在时为nil）
+func (t *routeTable) unmount(name string) *routeEntry {
+	return t.swap(name, nil)
+}
+
+// reserve 记录静态路由占用的插件名称
+// names: 路径第一段
+func (t *routeTable) reserve(names []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.reserved == nil {
+		t.reserved = make(map[string]bool, len(names))
+	}
+	for _, name := range names {
+		t.reserved[name] = true
+	}
+}
+
+// isReserved 插件名称是否已被静态路由占用
+// name: 插件名称
+// 返回: 是否已占用
+func (t *routeTable) isReserved(name string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.reserved[name]
+}
+
+// staticRouteNames 列出路由器中/api/v1/下静态注册的第一段路径
+// 以参数或通配符开头的路径不占用具体名称，不计入
+// router: Gin路由器
+// 返回: 路径第一段列表
+func staticRouteNames(router *gin.Engine) []string {
+	var names []string
+	for _, route := range router.Routes() {
+		name, ok := pluginNameFromPath(route.Path)
+		if ok && !strings.HasPrefix(name, ":") && !strings.HasPrefix(name, "*") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// lookup 查找插件的路由条目
+// name: 插件名称
+// 返回: 路由条目（不存在时为nil）
+func (t *routeTable) lookup(name string) *routeEntry {
+	return (*t.routes.Load())[name]
+}
+
+// pluginNameFromPath 从请求路径中解析插件名称
+// path: 请求路径，如 /api/v1/<name>/...
+// 返回: 插件名称和是否为插件路径
+func pluginNameFromPath(path string) (string, bool) {
+	rest, ok := strings.CutPrefix(path, pluginRoutePrefix)
+	if !ok {
+		return "", false
+	}
+	name, _, _ := strings.Cut(rest, "/")
+	return name, name != ""
+}
+
+// handle 分发处理器，作为gin的NoRoute处理器挂载
+// 静态注册的路由优先匹配，未匹配的/api/v1/<name>/...请求转发到已挂载的插件
+// c: Gin上下文
+func (t *routeTable) handle(c *gin.Context) {
+	name, ok := pluginNameFromPath(c.Request.URL.Path)
+	if !ok {
+		return
+	}
+
+	// 查表与acquire之间条目可能被替换，此时重新查表
+	for attempt := 0; attempt < 3; attempt++ {
+		entry := t.lookup(name)
+		if entry == nil {
+			return
+		}
+		if entry.handler == nil {
+			break
+		}
+		if !entry.acquire() {
+			continue
+		}
+
+		defer entry.release()
+		// NoRoute处理器的默认状态码为404，交给插件前重置
+		c.Status(http.StatusOK)
+		entry.handler.ServeHTTP(c.Writer, c.Request)
+		c.Abort()
+		return
+	}
+
+	c.Header("Retry-After", "1")
+	c.AbortWithStatusJSON(http.StatusServiceUnavailable, model.ErrorResponse{
+		Code:    http.StatusServiceUnavailable,
+		Message: "Plugin reloading",
+		Error:   fmt.Sprintf("plugin '%s' is being reloaded, retry later", name),
+	})
+}
@@ -0,0 +1,330 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// get 向路由器发送GET请求
+// 返回: 响应记录
+func get(router http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+// textHandler 返回固定文本的处理器
+func textHandler(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	})
+}
+
+func TestRouteTableDispatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	table := newRouteTable()
+	router := gin.New()
+	router.GET("/api/v1/static", func(c *gin.Context) { c.String(http.StatusOK, "static") })
+	router.NoRoute(table.handle)
+
+	steps := []struct {
+		name       string
+		apply      func()
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "not mounted", apply: func() {}, path: "/api/v1/demo/x", wantStatus: http.StatusNotFound},
+		{name: "mounted", apply: func() { table.mount("demo", textHandler("v1")) }, path: "/api/v1/demo/x", wantStatus: http.StatusOK, wantBody: "v1"},
+		{name: "swapped", apply: func() { table.mount("demo", textHandler("v2")) }, path: "/api/v1/demo/x", wantStatus: http.StatusOK, wantBody: "v2"},
+		{name: "static route wins", apply: func() { table.mount("static", textHandler("plugin")) }, path: "/api/v1/static", wantStatus: http.StatusOK, wantBody: "static"},
+		{name: "outside prefix", apply: func() {}, path: "/demo/x", wantStatus: http.StatusNotFound},
+		{name: "suspended", apply: func() { table.suspend("demo") }, path: "/api/v1/demo/x", wantStatus: http.StatusServiceUnavailable},
+		{name: "resumed", apply: func() { table.mount("demo", textHandler("v3")) }, path: "/api/v1/demo", wantStatus: http.StatusOK, wantBody: "v3"},
+		{name: "unmounted", apply: func() { table.unmount("demo") }, path: "/api/v1/demo/x", wantStatus: http.StatusNotFound},
+	}
+	for _, step := range steps {
+		step.apply()
+		w := get(router, step.path)
+		if w.Code != step.wantStatus {
+			t.Fatalf("%s: status = %d, want %d", step.name, w.Code, step.wantStatus)
+		}
+		if step.wantBody != "" && w.Body.String() != step.wantBody {
+			t.Fatalf("%s: body = %q, want %q", step.name, w.Body.String(), step.wantBody)
+		}
+		if step.wantStatus == http.StatusServiceUnavailable && w.Header().Get("Retry-After") == "" {
+			t.Fatalf("%s: missing Retry-After header", step.name)
+		}
+	}
+}
+
+func TestRouteEntryDrain(t *testing.T) {
+	entry := newRouteEntry(textHandler("ok"))
+	if !entry.acquire() {
+		t.Fatal("acquire() on a live entry failed")
+	}
+
+	drained := make(chan error, 1)
+	go func() { drained <- entry.drain(context.Background()) }()
+
+	select {
+	case err := <-drained:
+		t.Fatalf("drain() returned %v while a request was in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if entry.acquire() {
+		t.Fatal("acquire() on a retired entry succeeded")
+	}
+
+	entry.release()
+	select {
+	case err := <-drained:
+		if err != nil {
+			t.Fatalf("drain() error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("drain() did not return after the request finished")
+	}
+}
+
+func TestRouteEntryDrainTimeout(t *testing.T) {
+	entry := newRouteEntry(textHandler("ok"))
+	entry.acquire()
+	defer entry.release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := entry.drain(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "1 in-flight") {
+		t.Fatalf("drain() error = %v, want a timeout with 1 in-flight request", err)
+	}
+}
+
+// routeTestPlugin 可以让请求停在处理器中的测试插件
+// GET /api/v1/<name>/wait 在release关闭前不返回，其他路径立即返回插件版本
+type routeTestPlugin struct {
+	depsTestPlugin
+
+	// entered 请求进入wait处理器时写入
+	entered chan struct{}
+
+	// release 关闭后wait处理器返回
+	release chan struct{}
+}
+
+// newRouteTestPlugin 创建路由测试插件
+// 返回: 测试插件
+func newRouteTestPlugin(name string) *routeTestPlugin {
+	return &routeTestPlugin{
+		depsTestPlugin: depsTestPlugin{metadata: PluginMetadata{Name: name, Version: "1.0.0"}},
+		entered:        make(chan struct{}, 1),
+		release:        make(chan struct{}),
+	}
+}
+
+func (p *routeTestPlugin) RegisterRoutes(router *gin.Engine) error {
+	prefix := pluginRoutePrefix + p.GetName()
+	router.GET(prefix+"/wait", func(c *gin.Context) {
+		p.entered <- struct{}{}
+		<-p.release
+		c.String(http.StatusOK, "done")
+	})
+	router.GET(prefix+"/version", func(c *gin.Context) {
+		c.String(http.StatusOK, p.GetVersion())
+	})
+	return nil
+}
+
+// newRoutedManager 创建挂载了插件路由的管理器，路由器上有静态路由 /api/v1/plugins/installed
+// plugins: 预先注册的插件
+// 返回: 管理器、路由器和RegisterAllRoutes的错误
+func newRoutedManager(t *testing.T, plugins ...Plugin) (*Manager, *gin.Engine, error) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/plugins/installed", func(c *gin.Context) { c.String(http.StatusOK, "installed") })
+
+	m := NewManager(zap.NewNop(), t.TempDir())
+	m.SetLoader(newTestLoader())
+	for _, p := range plugins {
+		if err := m.RegisterPlugin(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.InitializeAll(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	return m, router, m.RegisterAllRoutes(router)
+}
+
+func TestManagerSwapsRoutes(t *testing.T) {
+	m, router, err := newRoutedManager(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packages := t.TempDir()
+	install := func(version string) error {
+		path, _ := writeTestPackage(t, packages, "demo", version, CurrentPlatform(), nil)
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		_, err = m.InstallAndLoadPluginFromReader(context.Background(), filepath.Base(path), file, InstallOptions{})
+		return err
+	}
+
+	steps := []struct {
+		name       string
+		apply      func() error
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "not installed", apply: func() error { return nil }, wantStatus: http.StatusNotFound},
+		{name: "install", apply: func() error { return install("1.0.0") }, wantStatus: http.StatusOK, wantBody: "1.0.0"},
+		{name: "upgrade", apply: func() error { return install("1.1.0") }, wantStatus: http.StatusOK, wantBody: "1.1.0"},
+		{name: "stop", apply: func() error { return m.StopPlugin(context.Background(), "demo") }, wantStatus: http.StatusNotFound},
+		{name: "start", apply: func() error { _, err := m.StartPlugin(context.Background(), "demo"); return err }, wantStatus: http.StatusOK, wantBody: "1.1.0"},
+		{name: "restart", apply: func() error { return m.RestartPlugin(context.Background(), "demo") }, wantStatus: http.StatusOK, wantBody: "1.1.0"},
+	}
+	for _, step := range steps {
+		if err := step.apply(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		w := get(router, "/api/v1/demo/version")
+		if w.Code != step.wantStatus || (step.wantBody != "" && w.Body.String() != step.wantBody) {
+			t.Fatalf("%s: GET = %d %q, want %d %q", step.name, w.Code, w.Body.String(), step.wantStatus, step.wantBody)
+		}
+	}
+}
+
+func TestManagerDrainsInFlightRequests(t *testing.T) {
+	tests := []struct {
+		name string
+		// retire 下线插件路由的操作
+		retire func(m *Manager) error
+		// wantDuring 下线期间新请求的状态码
+		wantDuring int
+		// wantAfter 操作完成后新请求的状态码
+		wantAfter int
+	}{
+		{
+			name:       "stop",
+			retire:     func(m *Manager) error { return m.StopPlugin(context.Background(), "slow") },
+			wantDuring: http.StatusNotFound,
+			wantAfter:  http.StatusNotFound,
+		},
+		{
+			name:       "restart",
+			retire:     func(m *Manager) error { return m.RestartPlugin(context.Background(), "slow") },
+			wantDuring: http.StatusServiceUnavailable,
+			wantAfter:  http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newRouteTestPlugin("slow")
+			m, router, err := newRoutedManager(t, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			inflight := make(chan *httptest.ResponseRecorder, 1)
+			go func() { inflight <- get(router, "/api/v1/slow/wait") }()
+			<-p.entered
+
+			retired := make(chan error, 1)
+			go func() { retired <- tt.retire(m) }()
+
+			// 进行中的请求完成前操作不返回，新请求不再交给插件
+			deadline := time.Now().Add(time.Second)
+			for get(router, "/api/v1/slow/version").Code != tt.wantDuring {
+				if time.Now().After(deadline) {
+					t.Fatalf("requests during %s never got %d", tt.name, tt.wantDuring)
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+			select {
+			case err := <-retired:
+				t.Fatalf("%s returned %v before the in-flight request finished", tt.name, err)
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			close(p.release)
+			if w := <-inflight; w.Code != http.StatusOK || w.Body.String() != "done" {
+				t.Fatalf("in-flight request = %d %q", w.Code, w.Body.String())
+			}
+			if err := <-retired; err != nil {
+				t.Fatalf("%s error: %v", tt.name, err)
+			}
+			if w := get(router, "/api/v1/slow/version"); w.Code != tt.wantAfter {
+				t.Fatalf("after %s: status = %d, want %d", tt.name, w.Code, tt.wantAfter)
+			}
+		})
+	}
+}
+
+func TestManagerDrainTimeout(t *testing.T) {
+	p := newRouteTestPlugin("slow")
+	m, router, err := newRoutedManager(t, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetDrainTimeout(50 * time.Millisecond)
+
+	inflight := make(chan *httptest.ResponseRecorder, 1)
+	go func() { inflight <- get(router, "/api/v1/slow/wait") }()
+	<-p.entered
+	defer func() {
+		close(p.release)
+		<-inflight
+	}()
+
+	start := time.Now()
+	if err := m.StopPlugin(context.Background(), "slow"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("StopPlugin() waited %v for a stuck request", elapsed)
+	}
+}
+
+func TestManagerRejectsShadowedPluginName(t *testing.T) {
+	m, router, err := newRoutedManager(t, newRouteTestPlugin("plugins"), newRouteTestPlugin("demo"))
+	if !errors.Is(err, ErrRouteConflict) || !strings.Contains(err.Error(), "'plugins'") {
+		t.Fatalf("RegisterAllRoutes() error = %v, want ErrRouteConflict for plugins", err)
+	}
+	if state, _ := m.states.state("plugins"); state != StateFailed {
+		t.Errorf("plugins state = %s, want failed", state)
+	}
+
+	// 静态路由和其他插件的路由不受影响
+	if w := get(router, "/api/v1/plugins/installed"); w.Body.String() != "installed" {
+		t.Errorf("static route = %d %q", w.Code, w.Body.String())
+	}
+	if w := get(router, "/api/v1/demo/version"); w.Code != http.StatusOK {
+		t.Errorf("demo route = %d", w.Code)
+	}
+
+	// 运行时加载同名插件同样被拒绝
+	path, _ := writeTestPackage(t, t.TempDir(), "plugins", "1.0.0", CurrentPlatform(), nil)
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := m.InstallAndLoadPluginFromReader(context.Background(), filepath.Base(path), file, InstallOptions{}); !errors.Is(err, ErrRouteConflict) {
+		t.Fatalf("InstallAndLoadPluginFromReader() error = %v, want ErrRouteConflict", err)
+	}
+}
@@ -0,0 +1,283 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// pluginConfigFile 网关在插件解压目录中生成的配置文件名
+	pluginConfigFile = "vkp-runtime-config.json"
+
+	// pluginConfigFlag 网关通过此命令行参数向子进程传递配置文件路径
+	pluginConfigFlag = "--config"
+
+	// pluginSecretEnvPrefix 敏感配置项通过以此为前缀的环境变量传递，不写入配置文件
+	pluginSecretEnvPrefix = "VKP_SECRET_"
+)
+
+// runtimeConfigFile 网关生成的插件配置文件
+type runtimeConfigFile struct {
+	// Config 插件配置，不含敏感配置项
+	Config interface{} `json:"config"`
+
+	// Secrets 通过环境变量传递的敏感配置项
+	Secrets []secretRef `json:"secrets,omitempty"`
+}
+
+// secretRef 敏感配置项在配置中的位置及对应的环境变量
+type secretRef struct {
+	// Path 配置项路径，逐级的对象键，数组元素以十进制下标表示
+	Path []string `json:"path"`
+
+	// Env 环境变量名称
+	Env string `json:"env"`
+
+	// JSON 环境变量的值是否为JSON编码（非字符串的配置项）
+	JSON bool `json:"json,omitempty"`
+}
+
+// sensitiveKeys 视为敏感配置项的键名（小写，忽略下划线和连字符）
+var sensitiveKeys = []string{"password", "passwd", "secret", "token", "apikey", "privatekey", "credential"}
+
+// isSensitiveKey 根据键名判断配置项是否敏感
+// key: 配置项键名
+// 返回: 是否敏感
+func isSensitiveKey(key string) bool {
+	normalized := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(normalized, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretEnvName 生成敏感配置项对应的环境变量名称
+// 不同路径可能得到相同的名称（如 db.password 和 db_password），已使用的名称追加序号
+// path: 配置项路径
+// used: 已使用的环境变量名称，生成的名称会加入其中
+// 返回: 环境变量名称，如 db.password 对应 VKP_SECRET_DB_PASSWORD
+func secretEnvName(path []string, used map[string]bool) string {
+	name := pluginSecretEnvPrefix + strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(strings.Join(path, "_")))
+
+	unique := name
+	for n := 2; used[unique]; n++ {
+		unique = name + "_" + strconv.Itoa(n)
+	}
+	used[unique] = true
+	return unique
+}
+
+// splitSecrets 从配置中移出敏感配置项
+// 敏感配置项为配置模式中标记了"secret": true的属性，以及键名包含password、token等的配置项，
+// 包括数组元素中的对象所含的配置项
+// schema: 插件的配置模式，可为nil
+// config: JSON形式的配置，敏感配置项会从中删除
+// 返回: 敏感配置项引用和对应的环境变量（NAME=value形式）
+func splitSecrets(schema map[string]interface{}, config interface{}) ([]secretRef, []string) {
+	var refs []secretRef
+	var env []string
+	used := make(map[string]bool)
+
+	var walk func(schema map[string]interface{}, value interface{}, path []string)
+	walk = func(schema map[string]interface{}, value interface{}, path []string) {
+		if array, ok := value.([]interface{}); ok {
+			items, _ := schema["items"].(map[string]interface{})
+			for i, item := range array {
+				walk(items, item, append(append([]string(nil), path...), strconv.Itoa(i)))
+			}
+			return
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			property, _ := properties[key].(map[string]interface{})
+			itemPath := append(append([]string(nil), path...), key)
+			marked, _ := property["secret"].(bool)
+			if !marked && !isSensitiveKey(key) {
+				walk(property, object[key], itemPath)
+				continue
+			}
+
+			item := object[key]
+			ref := secretRef{Path: itemPath, Env: secretEnvName(itemPath, used)}
+			text, isString := item.(string)
+			if !isString {
+				// 非字符串（包括对象形式）的敏感配置项整体以JSON传递
+				data, err := json.Marshal(item)
+				if err != nil {
+					continue
+				}
+				text, ref.JSON = string(data), true
+			}
+			refs = append(refs, ref)
+			env = append(env, ref.Env+"="+text)
+			delete(object, key)
+		}
+	}
+	walk(schema, config, nil)
+	return refs, env
+}
+
+// redactedValue 脱敏后的敏感配置项的值
+const redactedValue = "***"
+
+// RedactSecrets 返回配置的副本，键名包含password、token等的配置项的值替换为"***"
+// 用于在管理接口中展示配置
+// config: 配置数据
+// 返回: 脱敏后的JSON形式的配置，配置无法转换为JSON时返回nil
+func RedactSecrets(config interface{}) interface{} {
+	value, err := normalizeJSON(config)
+	if err != nil {
+		return nil
+	}
+
+	var redact func(value interface{}) interface{}
+	redact = func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, item := range v {
+				if isSensitiveKey(key) {
+					v[key] = redactedValue
+				} else {
+					v[key] = redact(item)
+				}
+			}
+		case []interface{}:
+			for i, item := range v {
+				v[i] = redact(item)
+			}
+		}
+		return value
+	}
+	return redact(value)
+}
+
+// writeRuntimeConfig 在插件目录中生成配置文件，敏感配置项改为通过环境变量传递
+// dir: 插件解压目录
+// metadata: 插件元数据，用于读取配置模式，可为nil
+// config: 插件配置
+// 返回: 配置文件路径、敏感配置项的环境变量和错误信息
+func writeRuntimeConfig(dir string, metadata *PluginMetadata, config interface{}) (string, []string, error) {
+	value, err := normalizeJSON(config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var schema map[string]interface{}
+	if metadata != nil {
+		if rawSchema, err := normalizeJSON(metadata.ConfigSchema); err == nil {
+			schema, _ = rawSchema.(map[string]interface{})
+		}
+	}
+	refs, env := splitSecrets(schema, value)
+
+	data, err := json.MarshalIndent(runtimeConfigFile{Config: value, Secrets: refs}, "", "  ")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode plugin config: %w", err)
+	}
+
+	path := filepath.Join(dir, pluginConfigFile)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return "", nil, fmt.Errorf("failed to write plugin config: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", nil, fmt.Errorf("failed to write plugin config: %w", err)
+	}
+	return path, env, nil
+}
+
+// LoadPluginConfig 读取网关生成的插件配置文件，并从环境变量中取回敏感配置项
+// 供插件子进程在独立运行器中调用，结果作为Plugin.Initialize的config参数
+// path: 配置文件路径（--config参数的值）
+// 返回: 插件配置（对象为map[string]interface{}，数字为float64）和错误信息
+func LoadPluginConfig(path string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin config: %w", err)
+	}
+
+	var file runtimeConfigFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse plugin config %s: %w", path, err)
+	}
+
+	for _, ref := range file.Secrets {
+		raw, ok := os.LookupEnv(ref.Env)
+		if !ok || len(ref.Path) == 0 {
+			continue
+		}
+		if file.Config == nil {
+			file.Config = map[string]interface{}{}
+		}
+		object, err := secretParent(file.Config, ref.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		var value interface{} = raw
+		if ref.JSON {
+			if err := json.Unmarshal([]byte(raw), &value); err != nil {
+				return nil, fmt.Errorf("invalid value in %s: %w", ref.Env, err)
+			}
+		}
+		object[ref.Path[len(ref.Path)-1]] = value
+	}
+	return file.Config, nil
+}
+
+// secretParent 沿敏感配置项的路径找到其所在的对象，缺少的对象会被创建
+// config: 插件配置
+// path: 配置项路径
+// 返回: 配置项所在的对象和错误信息
+func secretParent(config interface{}, path []string) (map[string]interface{}, error) {
+	current := config
+	for i, key := range path[:len(path)-1] {
+		switch container := current.(type) {
+		case map[string]interface{}:
+			next, exists := container[key]
+			if !exists || next == nil {
+				next = map[string]interface{}{}
+				container[key] = next
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(container) {
+				return nil, fmt.Errorf("plugin config has no element %s for secret %s", strings.Join(path[:i+1], "."), strings.Join(path, "."))
+			}
+			current = container[index]
+		default:
+			return nil, fmt.Errorf("plugin config has no object for secret %s", strings.Join(path, "."))
+		}
+	}
+
+	object, ok := current.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("plugin config has no object for secret %s", strings.Join(path, "."))
+	}
+	return object, nil
+}
@@ -0,0 +1,189 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitSecrets(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   map[string]interface{}
+		config   interface{}
+		wantRefs []secretRef
+		wantEnv  []string
+		wantRest interface{}
+	}{
+		{
+			name:     "sensitive key names",
+			config:   map[string]interface{}{"host": "db", "api_key": "k", "Auth-Token": "t"},
+			wantRefs: []secretRef{{Path: []string{"Auth-Token"}, Env: "VKP_SECRET_AUTH_TOKEN"}, {Path: []string{"api_key"}, Env: "VKP_SECRET_API_KEY"}},
+			wantEnv:  []string{"VKP_SECRET_AUTH_TOKEN=t", "VKP_SECRET_API_KEY=k"},
+			wantRest: map[string]interface{}{"host": "db"},
+		},
+		{
+			name: "schema secret flag",
+			schema: map[string]interface{}{"properties": map[string]interface{}{
+				"dsn": map[string]interface{}{"type": "string", "secret": true},
+			}},
+			config:   map[string]interface{}{"dsn": "postgres://u:p@db", "name": "main"},
+			wantRefs: []secretRef{{Path: []string{"dsn"}, Env: "VKP_SECRET_DSN"}},
+			wantEnv:  []string{"VKP_SECRET_DSN=postgres://u:p@db"},
+			wantRest: map[string]interface{}{"name": "main"},
+		},
+		{
+			name: "array elements",
+			config: map[string]interface{}{"servers": []interface{}{
+				map[string]interface{}{"url": "a", "token": "t0"},
+				map[string]interface{}{"url": "b", "token": "t1"},
+			}},
+			wantRefs: []secretRef{
+				{Path: []string{"servers", "0", "token"}, Env: "VKP_SECRET_SERVERS_0_TOKEN"},
+				{Path: []string{"servers", "1", "token"}, Env: "VKP_SECRET_SERVERS_1_TOKEN"},
+			},
+			wantEnv: []string{"VKP_SECRET_SERVERS_0_TOKEN=t0", "VKP_SECRET_SERVERS_1_TOKEN=t1"},
+			wantRest: map[string]interface{}{"servers": []interface{}{
+				map[string]interface{}{"url": "a"},
+				map[string]interface{}{"url": "b"},
+			}},
+		},
+		{
+			name:     "non-string values as json",
+			config:   map[string]interface{}{"credentials": map[string]interface{}{"user": "u"}, "pin_secret": float64(1234)},
+			wantRefs: []secretRef{{Path: []string{"credentials"}, Env: "VKP_SECRET_CREDENTIALS", JSON: true}, {Path: []string{"pin_secret"}, Env: "VKP_SECRET_PIN_SECRET", JSON: true}},
+			wantEnv:  []string{`VKP_SECRET_CREDENTIALS={"user":"u"}`, "VKP_SECRET_PIN_SECRET=1234"},
+			wantRest: map[string]interface{}{},
+		},
+		{
+			name:     "env name collision",
+			config:   map[string]interface{}{"db": map[string]interface{}{"password": "a"}, "db_password": "b"},
+			wantRefs: []secretRef{{Path: []string{"db", "password"}, Env: "VKP_SECRET_DB_PASSWORD"}, {Path: []string{"db_password"}, Env: "VKP_SECRET_DB_PASSWORD_2"}},
+			wantEnv:  []string{"VKP_SECRET_DB_PASSWORD=a", "VKP_SECRET_DB_PASSWORD_2=b"},
+			wantRest: map[string]interface{}{"db": map[string]interface{}{}},
+		},
+		{
+			name:     "no secrets",
+			config:   map[string]interface{}{"port": float64(80)},
+			wantRest: map[string]interface{}{"port": float64(80)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs, env := splitSecrets(tt.schema, tt.config)
+			if !reflect.DeepEqual(refs, tt.wantRefs) {
+				t.Errorf("splitSecrets() refs = %+v, want %+v", refs, tt.wantRefs)
+			}
+			if !reflect.DeepEqual(env, tt.wantEnv) {
+				t.Errorf("splitSecrets() env = %q, want %q", env, tt.wantEnv)
+			}
+			if !reflect.DeepEqual(tt.config, tt.wantRest) {
+				t.Errorf("splitSecrets() left config = %#v, want %#v", tt.config, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestLoadPluginConfigRoundTrip(t *testing.T) {
+	metadata := &PluginMetadata{Name: "demo", ConfigSchema: map[string]interface{}{
+		"properties": map[string]interface{}{
+			"dsn": map[string]interface{}{"type": "string", "secret": true},
+		},
+	}}
+	config := map[string]interface{}{
+		"dsn":         "postgres://u:p@db",
+		"port":        8080,
+		"db":          map[string]interface{}{"password": "pw-a", "host": "db"},
+		"db_password": "pw-b",
+		"servers":     []interface{}{map[string]interface{}{"url": "a", "token": "tok-0"}},
+		"credentials": map[string]interface{}{"user": "svc"},
+	}
+
+	dir := t.TempDir()
+	path, env, err := writeRuntimeConfig(dir, metadata, config)
+	if err != nil {
+		t.Fatalf("writeRuntimeConfig() error: %v", err)
+	}
+	if path != filepath.Join(dir, pluginConfigFile) {
+		t.Fatalf("writeRuntimeConfig() path = %q", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"postgres://", "tok-0", "pw-a", "pw-b", "svc"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("config file contains secret %s:\n%s", secret, data)
+		}
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("config file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+
+	for _, entry := range env {
+		name, value, _ := strings.Cut(entry, "=")
+		t.Setenv(name, value)
+	}
+	got, err := LoadPluginConfig(path)
+	if err != nil {
+		t.Fatalf("LoadPluginConfig() error: %v", err)
+	}
+	want := map[string]interface{}{
+		"dsn":         "postgres://u:p@db",
+		"port":        float64(8080),
+		"db":          map[string]interface{}{"password": "pw-a", "host": "db"},
+		"db_password": "pw-b",
+		"servers":     []interface{}{map[string]interface{}{"url": "a", "token": "tok-0"}},
+		"credentials": map[string]interface{}{"user": "svc"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("LoadPluginConfig() = %#v, want %#v", got, want)
+	}
+}
+
+func TestLoadPluginConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		env     map[string]string
+		wantErr string
+	}{
+		{name: "missing file", path: filepath.Join(dir, "missing.json"), wantErr: "failed to read plugin config"},
+		{name: "malformed", path: write("bad.json", "{"), wantErr: "failed to parse plugin config"},
+		{
+			name:    "invalid json secret",
+			path:    write("json.json", `{"config":{},"secrets":[{"path":["creds"],"env":"VKP_SECRET_TEST_CREDS","json":true}]}`),
+			env:     map[string]string{"VKP_SECRET_TEST_CREDS": "{"},
+			wantErr: "invalid value in VKP_SECRET_TEST_CREDS",
+		},
+		{
+			name:    "missing array element",
+			path:    write("array.json", `{"config":{"servers":[]},"secrets":[{"path":["servers","0","token"],"env":"VKP_SECRET_TEST_TOKEN"}]}`),
+			env:     map[string]string{"VKP_SECRET_TEST_TOKEN": "t"},
+			wantErr: "no element servers.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			_, err := LoadPluginConfig(tt.path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadPluginConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
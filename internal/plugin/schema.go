@@ -0,0 +1,355 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidConfig 插件配置不满足插件声明的配置模式
+var ErrInvalidConfig = errors.New("invalid plugin config")
+
+// ConfigFieldError 单个配置字段的校验错误
+type ConfigFieldError struct {
+	// Field 字段路径，如 timeout、database.host、servers[0]，根对象为(root)
+	Field string `json:"field"`
+
+	// Message 错误描述
+	Message string `json:"message"`
+}
+
+// ConfigValidationError 插件配置校验错误，包含所有字段的错误
+type ConfigValidationError struct {
+	// Plugin 插件名称
+	Plugin string
+
+	// Fields 字段错误，按字段路径排序
+	Fields []ConfigFieldError
+}
+
+// Error 实现error接口
+// 返回: 错误描述
+func (e *ConfigValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		parts = append(parts, field.Field+": "+field.Message)
+	}
+	return fmt.Sprintf("%s for '%s': %s", ErrInvalidConfig, e.Plugin, strings.Join(parts, "; "))
+}
+
+// Unwrap 使errors.Is(err, ErrInvalidConfig)成立
+// 返回: ErrInvalidConfig
+func (e *ConfigValidationError) Unwrap() error {
+	return ErrInvalidConfig
+}
+
+// ValidateConfig 按插件元数据中的配置模式校验配置并填充默认值
+// 支持JSON Schema的常用子集：type、properties、required、default、enum、
+// minimum/maximum、exclusiveMinimum/exclusiveMaximum、minLength/maxLength、pattern、
+// items、minItems/maxItems和additionalProperties: false
+// 未声明配置模式的插件原样返回配置；声明了模式但没有配置时按空对象处理，以便填充默认值
+// name: 插件名称
+// metadata: 插件元数据，可为nil
+// config: 配置数据
+// 返回: 填充默认值后的配置（JSON形式：对象为map[string]interface{}，数字为float64）和错误信息，
+// 校验失败时返回*ConfigValidationError
+func ValidateConfig(name string, metadata *PluginMetadata, config interface{}) (interface{}, error) {
+	if metadata == nil || len(metadata.ConfigSchema) == 0 {
+		return config, nil
+	}
+
+	// Go插件的配置模式可能使用任意Go类型，与配置一样先转换为JSON形式
+	rawSchema, err := normalizeJSON(metadata.ConfigSchema)
+	if err != nil {
+		return nil, fmt.Errorf("%w for '%s': invalid config schema: %v", ErrInvalidConfig, name, err)
+	}
+	schema, _ := rawSchema.(map[string]interface{})
+
+	value, err := normalizeJSON(config)
+	if err != nil {
+		return nil, fmt.Errorf("%w for '%s': %v", ErrInvalidConfig, name, err)
+	}
+	if value == nil {
+		value = map[string]interface{}{}
+	}
+
+	var fields []ConfigFieldError
+	value = validateSchemaValue(schema, value, "", &fields)
+	if len(fields) > 0 {
+		sort.SliceStable(fields, func(a, b int) bool {
+			return fields[a].Field < fields[b].Field
+		})
+		return nil, &ConfigValidationError{Plugin: name, Fields: fields}
+	}
+	return value, nil
+}
+
+// normalizeJSON 通过JSON编解码将任意配置转换为JSON形式
+// value: 配置数据
+// 返回: JSON形式的配置和错误信息
+func normalizeJSON(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("config is not JSON-encodable: %w", err)
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// validateSchemaValue 按模式校验单个值，对象中缺失的属性填充默认值
+// schema: JSON形式的模式
+// value: JSON形式的值
+// path: 字段路径
+// fields: 收集字段错误
+// 返回: 填充默认值后的值
+func validateSchemaValue(schema map[string]interface{}, value interface{}, path string, fields *[]ConfigFieldError) interface{} {
+	fail := func(format string, args ...interface{}) {
+		field := path
+		if field == "" {
+			field = "(root)"
+		}
+		*fields = append(*fields, ConfigFieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if matchesSchemaType(t, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("must be of type %s, got %s", strings.Join(types, " or "), jsonTypeName(value))
+			return value
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		allowed := false
+		for _, candidate := range enum {
+			if reflect.DeepEqual(candidate, value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			fail("must be one of %s", formatEnum(enum))
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if limit, ok := schemaNumber(schema, "minLength"); ok && float64(length) < limit {
+			fail("must be at least %s characters", formatNumber(limit))
+		}
+		if limit, ok := schemaNumber(schema, "maxLength"); ok && float64(length) > limit {
+			fail("must be at most %s characters", formatNumber(limit))
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				fail("invalid schema pattern %q: %v", pattern, err)
+			} else if !re.MatchString(v) {
+				fail("must match pattern %q", pattern)
+			}
+		}
+
+	case float64:
+		if limit, ok := schemaNumber(schema, "minimum"); ok && v < limit {
+			fail("must be >= %s", formatNumber(limit))
+		}
+		if limit, ok := schemaNumber(schema, "maximum"); ok && v > limit {
+			fail("must be <= %s", formatNumber(limit))
+		}
+		if limit, ok := schemaNumber(schema, "exclusiveMinimum"); ok && v <= limit {
+			fail("must be > %s", formatNumber(limit))
+		}
+		if limit, ok := schemaNumber(schema, "exclusiveMaximum"); ok && v >= limit {
+			fail("must be < %s", formatNumber(limit))
+		}
+
+	case []interface{}:
+		if limit, ok := schemaNumber(schema, "minItems"); ok && float64(len(v)) < limit {
+			fail("must have at least %s items", formatNumber(limit))
+		}
+		if limit, ok := schemaNumber(schema, "maxItems"); ok && float64(len(v)) > limit {
+			fail("must have at most %s items", formatNumber(limit))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				v[i] = validateSchemaValue(items, item, fmt.Sprintf("%s[%d]", path, i), fields)
+			}
+		}
+
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		for key, raw := range properties {
+			property, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if _, present := v[key]; present {
+				continue
+			}
+			if def, ok := property["default"]; ok {
+				// 复制默认值，避免多个配置共享模式中的对象
+				v[key], _ = normalizeJSON(def)
+			}
+		}
+
+		for _, raw := range schemaStrings(schema["required"]) {
+			if _, present := v[raw]; !present {
+				*fields = append(*fields, ConfigFieldError{Field: joinFieldPath(path, raw), Message: "is required"})
+			}
+		}
+
+		for key, item := range v {
+			if property, ok := properties[key].(map[string]interface{}); ok {
+				v[key] = validateSchemaValue(property, item, joinFieldPath(path, key), fields)
+				continue
+			}
+			if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+				*fields = append(*fields, ConfigFieldError{Field: joinFieldPath(path, key), Message: "is not allowed"})
+			}
+		}
+	}
+
+	return value
+}
+
+// schemaTypes 解析模式的type字段，支持字符串和字符串数组
+// raw: type字段
+// 返回: 类型列表
+func schemaTypes(raw interface{}) []string {
+	if t, ok := raw.(string); ok {
+		return []string{t}
+	}
+	return schemaStrings(raw)
+}
+
+// schemaStrings 解析模式中的字符串数组
+// raw: 字段值
+// 返回: 字符串列表
+func schemaStrings(raw interface{}) []string {
+	items, _ := raw.([]interface{})
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// schemaNumber 读取模式中的数值约束
+// schema: 模式
+// key: 字段名
+// 返回: 数值和是否存在
+func schemaNumber(schema map[string]interface{}, key string) (float64, bool) {
+	v, ok := schema[key].(float64)
+	return v, ok
+}
+
+// matchesSchemaType 值是否符合JSON Schema类型
+// t: 类型名称
+// value: JSON形式的值
+// 返回: 是否符合
+func matchesSchemaType(t string, value interface{}) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	// 未知类型不做限制
+	return true
+}
+
+// jsonTypeName 返回值的JSON类型名称
+// value: JSON形式的值
+// 返回: 类型名称
+func jsonTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// joinFieldPath 拼接字段路径
+// path: 父路径
+// key: 字段名
+// 返回: 字段路径
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// formatNumber 格式化数值约束，整数不带小数点
+// f: 数值
+// 返回: 格式化后的字符串
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// formatEnum 格式化枚举值
+// values: 枚举值
+// 返回: 格式化后的字符串
+func formatEnum(values []interface{}) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			parts = append(parts, fmt.Sprintf("%v", value))
+			continue
+		}
+		parts = append(parts, string(data))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
@@ -0,0 +1,114 @@
+package plugin
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"required":             []string{"host"},
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"host":    map[string]interface{}{"type": "string", "minLength": 1, "pattern": "^[a-z.]+$"},
+			"port":    map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 65535, "default": 8080},
+			"mode":    map[string]interface{}{"type": "string", "enum": []string{"fast", "safe"}, "default": "safe"},
+			"ratio":   map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 1},
+			"tags":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "maxItems": 2},
+			"options": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"retries": map[string]interface{}{"type": "integer", "default": 3}}},
+		},
+	}
+	metadata := &PluginMetadata{Name: "demo", ConfigSchema: schema}
+
+	tests := []struct {
+		name       string
+		metadata   *PluginMetadata
+		config     interface{}
+		want       interface{}
+		wantFields []ConfigFieldError
+	}{
+		{
+			name:   "defaults",
+			config: map[string]interface{}{"host": "example.com", "options": map[string]interface{}{}},
+			want: map[string]interface{}{
+				"host":    "example.com",
+				"port":    float64(8080),
+				"mode":    "safe",
+				"options": map[string]interface{}{"retries": float64(3)},
+			},
+		},
+		{
+			name:   "go values are normalized",
+			config: map[string]interface{}{"host": "example.com", "port": 9000, "tags": []string{"a"}},
+			want: map[string]interface{}{
+				"host": "example.com",
+				"port": float64(9000),
+				"mode": "safe",
+				"tags": []interface{}{"a"},
+			},
+		},
+		{
+			name:       "missing config uses defaults and reports required",
+			config:     nil,
+			wantFields: []ConfigFieldError{{Field: "host", Message: "is required"}},
+		},
+		{
+			name: "field errors",
+			config: map[string]interface{}{
+				"host":  "Example.com",
+				"port":  70000,
+				"mode":  "slow",
+				"ratio": 1,
+				"tags":  []interface{}{"a", 2, "c"},
+				"extra": true,
+			},
+			wantFields: []ConfigFieldError{
+				{Field: "extra", Message: "is not allowed"},
+				{Field: "host", Message: `must match pattern "^[a-z.]+$"`},
+				{Field: "mode", Message: `must be one of ["fast", "safe"]`},
+				{Field: "port", Message: "must be <= 65535"},
+				{Field: "ratio", Message: "must be < 1"},
+				{Field: "tags", Message: "must have at most 2 items"},
+				{Field: "tags[1]", Message: "must be of type string, got integer"},
+			},
+		},
+		{
+			name:       "wrong root type",
+			config:     []interface{}{"host"},
+			wantFields: []ConfigFieldError{{Field: "(root)", Message: "must be of type object, got array"}},
+		},
+		{
+			name:     "no schema",
+			metadata: &PluginMetadata{Name: "demo"},
+			config:   map[string]interface{}{"anything": 1},
+			want:     map[string]interface{}{"anything": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := tt.metadata
+			if md == nil {
+				md = metadata
+			}
+			got, err := ValidateConfig("demo", md, tt.config)
+			if tt.wantFields != nil {
+				var validationErr *ConfigValidationError
+				if !errors.As(err, &validationErr) || !errors.Is(err, ErrInvalidConfig) {
+					t.Fatalf("ValidateConfig() error = %v, want *ConfigValidationError", err)
+				}
+				if !reflect.DeepEqual(validationErr.Fields, tt.wantFields) {
+					t.Fatalf("ValidateConfig() fields = %+v, want %+v", validationErr.Fields, tt.wantFields)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateConfig() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ValidateConfig() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
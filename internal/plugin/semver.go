@@ -0,0 +1,185 @@
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SemVer 语义化版本（https://semver.org）
+type SemVer struct {
+	// Major 主版本号
+	Major uint64
+
+	// Minor 次版本号
+	Minor uint64
+
+	// Patch 修订号
+	Patch uint64
+
+	// Prerelease 预发布标识（如"beta.1"），为空表示正式版本
+	Prerelease string
+
+	// Build 构建元数据，不参与版本比较
+	Build string
+}
+
+// ParseSemVer 解析语义化版本
+// 允许带"v"前缀，如"v1.2.3-beta.1+build.5"
+// s: 版本字符串
+// 返回: 版本和错误信息
+func ParseSemVer(s string) (SemVer, error) {
+	var v SemVer
+	rest := strings.TrimPrefix(s, "v")
+
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		v.Build = rest[i+1:]
+		rest = rest[:i]
+		if !validIdentifiers(v.Build, false) {
+			return SemVer{}, fmt.Errorf("invalid build metadata in version %q", s)
+		}
+	}
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		v.Prerelease = rest[i+1:]
+		rest = rest[:i]
+		if !validIdentifiers(v.Prerelease, true) {
+			return SemVer{}, fmt.Errorf("invalid prerelease in version %q", s)
+		}
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return SemVer{}, fmt.Errorf("invalid version %q: expected MAJOR.MINOR.PATCH", s)
+	}
+	numbers := make([]uint64, 3)
+	for i, part := range parts {
+		if !isNumeric(part) || (len(part) > 1 && part[0] == '0') {
+			return SemVer{}, fmt.Errorf("invalid version %q: bad number %q", s, part)
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return SemVer{}, fmt.Errorf("invalid version %q: %w", s, err)
+		}
+		numbers[i] = n
+	}
+	v.Major, v.Minor, v.Patch = numbers[0], numbers[1], numbers[2]
+
+	return v, nil
+}
+
+// Compare 比较两个版本
+// 预发布版本低于对应的正式版本，构建元数据不参与比较
+// other: 另一个版本
+// 返回: v小于other时为-1，相等为0，大于为1
+func (v SemVer) Compare(other SemVer) int {
+	if c := compareUint(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+
+	a := strings.Split(v.Prerelease, ".")
+	b := strings.Split(other.Prerelease, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return compareUint(uint64(len(a)), uint64(len(b)))
+}
+
+// String 返回版本的规范字符串（不带"v"前缀）
+// 返回: 版本字符串
+func (v SemVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// compareIdentifier 比较预发布标识中的单个字段
+// 数字字段按数值比较且低于字母数字字段
+// a: 字段a
+// b: 字段b
+// 返回: 比较结果
+func compareIdentifier(a, b string) int {
+	aNum, bNum := isNumeric(a), isNumeric(b)
+	switch {
+	case aNum && bNum:
+		if c := compareUint(uint64(len(a)), uint64(len(b))); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// compareUint 比较两个无符号整数
+// 返回: 比较结果
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// validIdentifiers 校验以"."分隔的预发布或构建标识
+// s: 标识字符串
+// prerelease: 是否为预发布标识（数字字段不允许前导零）
+// 返回: 是否有效
+func validIdentifiers(s string, prerelease bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		for _, r := range id {
+			if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-') {
+				return false
+			}
+		}
+		if prerelease && len(id) > 1 && id[0] == '0' && isNumeric(id) {
+			return false
+		}
+	}
+	return true
+}
+
+// isNumeric 判断字符串是否全部由数字组成
+// 返回: 是否为数字
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
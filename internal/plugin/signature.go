@@ -0,0 +1,301 @@
+package plugin
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// SignatureFileName VKP包签名文件名，签名对象为manifest.json的原始内容
+	SignatureFileName = "manifest.sig"
+	
+	// SignatureAlgorithmEd25519 Ed25519签名算法
+	SignatureAlgorithmEd25519 = "ed25519"
+)
+
+// SignaturePolicy 签名校验策略
+type SignaturePolicy string
+
+const (
+	// SignaturePolicyReject 拒绝未签名或签名无效的包
+	SignaturePolicyReject SignaturePolicy = "reject"
+	
+	// SignaturePolicyWarn 未签名或签名公钥不受信任时仅输出警告，签名无效的包仍被拒绝
+	SignaturePolicyWarn SignaturePolicy = "warn"
+	
+	// SignaturePolicyAllowUnsigned 允许未签名的包，但拒绝签名无效的包
+	SignaturePolicyAllowUnsigned SignaturePolicy = "allow-unsigned"
+)
+
+// SignatureStatus 签名校验结果
+type SignatureStatus string
+
+const (
+	// SignatureVerified 签名有效且来自受信任的公钥
+	SignatureVerified SignatureStatus = "verified"
+	
+	// SignatureUnsigned 包未签名
+	SignatureUnsigned SignatureStatus = "unsigned"
+	
+	// SignatureUntrusted 签名公钥不在受信任列表中
+	SignatureUntrusted SignatureStatus = "untrusted"
+	
+	// SignatureInvalid 签名格式错误或校验失败
+	SignatureInvalid SignatureStatus = "invalid"
+)
+
+// ErrSignatureRejected 签名校验未通过且策略要求拒绝
+var ErrSignatureRejected = errors.New("package signature rejected")
+
+// Signature VKP包签名
+type Signature struct {
+	// Algorithm 签名算法
+	Algorithm string `json:"algorithm"`
+	
+	// KeyID 签名公钥标识
+	KeyID string `json:"key_id"`
+	
+	// Signature 签名值（base64）
+	Signature string `json:"signature"`
+}
+
+// ParseSignaturePolicy 解析签名校验策略
+// s: 策略字符串
+// 返回: 签名校验策略和错误信息
+func ParseSignaturePolicy(s string) (SignaturePolicy, error) {
+	switch policy := SignaturePolicy(s); policy {
+	case SignaturePolicyReject, SignaturePolicyWarn, SignaturePolicyAllowUnsigned:
+		return policy, nil
+	case "":
+		return SignaturePolicyWarn, nil
+	default:
+		return "", fmt.Errorf("unknown signature policy: %s", s)
+	}
+}
+
+// KeyID 计算公钥标识
+// publicKey: Ed25519公钥
+// 返回: 公钥SHA-256的前16个十六进制字符
+func KeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+// SignManifest 对清单原始内容签名
+// manifestData: manifest.json的原始内容
+// privateKey: Ed25519私钥
+// 返回: 签名
+func SignManifest(manifestData []byte, privateKey ed25519.PrivateKey) *Signature {
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	return &Signature{
+		Algorithm: SignatureAlgorithmEd25519,
+		KeyID:     KeyID(publicKey),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, manifestData)),
+	}
+}
+
+// GenerateSigningKey 生成Ed25519签名密钥
+// 返回: base64编码的公钥、PEM编码的私钥和错误信息
+func GenerateSigningKey() (string, []byte, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", nil, err
+	}
+	
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return "", nil, err
+	}
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	
+	return base64.StdEncoding.EncodeToString(publicKey), privatePEM, nil
+}
+
+// ParsePublicKey 解析Ed25519公钥
+// 支持base64编码的32字节原始公钥或PEM编码的PKIX公钥
+// s: 公钥文本
+// 返回: 公钥和错误信息
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	s = strings.TrimSpace(s)
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PEM public key: %w", err)
+		}
+		publicKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("PEM public key is not an Ed25519 key")
+		}
+		return publicKey, nil
+	}
+	
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid Ed25519 public key length %d", len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// LoadPrivateKey 从文件加载Ed25519私钥
+// 支持PEM编码的PKCS#8私钥或base64编码的32字节种子
+// path: 私钥文件路径
+// 返回: 私钥和错误信息
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	
+	if block, _ := pem.Decode(data); block != nil {
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PEM private key: %w", err)
+		}
+		privateKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("PEM private key is not an Ed25519 key")
+		}
+		return privateKey, nil
+	}
+	
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 private key: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid Ed25519 seed length %d", len(seed))
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// SignatureVerifier VKP包签名校验器
+type SignatureVerifier struct {
+	// policy 校验策略
+	policy SignaturePolicy
+	
+	// trustedKeys 受信任的公钥，按公钥标识索引
+	trustedKeys map[string]ed25519.PublicKey
+	
+	// logger 日志记录器
+	logger *zap.Logger
+}
+
+// NewSignatureVerifier 创建签名校验器
+// policy: 校验策略
+// trustedKeys: 受信任的公钥
+// logger: 日志记录器
+// 返回: 签名校验器实例
+func NewSignatureVerifier(policy SignaturePolicy, trustedKeys []ed25519.PublicKey, logger *zap.Logger) *SignatureVerifier {
+	if policy == "" {
+		policy = SignaturePolicyWarn
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	
+	keys := make(map[string]ed25519.PublicKey, len(trustedKeys))
+	for _, key := range trustedKeys {
+		keys[KeyID(key)] = key
+	}
+	
+	return &SignatureVerifier{
+		policy:      policy,
+		trustedKeys: keys,
+		logger:      logger,
+	}
+}
+
+// Policy 返回校验策略
+// 返回: 签名校验策略
+func (v *SignatureVerifier) Policy() SignaturePolicy {
+	return v.policy
+}
+
+// Verify 校验清单签名并按策略决定是否放行
+// source: 包来源（用于日志）
+// manifestData: manifest.json的原始内容，旧版包为nil
+// signatureData: manifest.sig的内容，未签名为nil
+// 返回: 校验结果和错误信息（策略要求拒绝时返回ErrSignatureRejected）
+func (v *SignatureVerifier) Verify(source string, manifestData, signatureData []byte) (SignatureStatus, error) {
+	status, keyID, err := v.check(manifestData, signatureData)
+	
+	switch {
+	case status == SignatureVerified:
+		v.logger.Info("VKP package signature verified",
+			zap.String("source", source),
+			zap.String("key_id", keyID))
+		return status, nil
+	
+	case status == SignatureUnsigned && v.policy == SignaturePolicyAllowUnsigned:
+		v.logger.Info("Accepting unsigned VKP package",
+			zap.String("source", source),
+			zap.String("policy", string(v.policy)))
+		return status, nil
+	
+	case v.policy == SignaturePolicyWarn && status != SignatureInvalid:
+		v.logger.Warn("VKP package signature not verified, accepting due to policy",
+			zap.String("source", source),
+			zap.String("status", string(status)),
+			zap.String("key_id", keyID),
+			zap.String("policy", string(v.policy)),
+			zap.Error(err))
+		return status, nil
+	
+	default:
+		if err == nil {
+			err = fmt.Errorf("package is %s", status)
+		}
+		return status, fmt.Errorf("%w (policy %s): %v", ErrSignatureRejected, v.policy, err)
+	}
+}
+
+// check 校验签名
+// manifestData: manifest.json的原始内容
+// signatureData: manifest.sig的内容
+// 返回: 校验结果、公钥标识和错误信息
+func (v *SignatureVerifier) check(manifestData, signatureData []byte) (SignatureStatus, string, error) {
+	if signatureData == nil {
+		return SignatureUnsigned, "", nil
+	}
+	if manifestData == nil {
+		return SignatureInvalid, "", fmt.Errorf("%s present without %s", SignatureFileName, ManifestFileName)
+	}
+	
+	var signature Signature
+	if err := json.Unmarshal(signatureData, &signature); err != nil {
+		return SignatureInvalid, "", fmt.Errorf("failed to parse %s: %w", SignatureFileName, err)
+	}
+	if signature.Algorithm != SignatureAlgorithmEd25519 {
+		return SignatureInvalid, signature.KeyID, fmt.Errorf("unsupported signature algorithm: %s", signature.Algorithm)
+	}
+	
+	publicKey, ok := v.trustedKeys[signature.KeyID]
+	if !ok {
+		return SignatureUntrusted, signature.KeyID, fmt.Errorf("signing key %s is not trusted", signature.KeyID)
+	}
+	
+	sig, err := base64.StdEncoding.DecodeString(signature.Signature)
+	if err != nil {
+		return SignatureInvalid, signature.KeyID, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if !ed25519.Verify(publicKey, manifestData, sig) {
+		return SignatureInvalid, signature.KeyID, fmt.Errorf("signature does not match manifest")
+	}
+	
+	return SignatureVerified, signature.KeyID, nil
+}
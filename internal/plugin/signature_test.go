@@ -0,0 +1,97 @@
+package plugin
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// newTestSigningKey 生成签名密钥，并经过公钥文本和私钥文件的编码往返
+// 返回: 公钥和私钥
+func newTestSigningKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+
+	publicText, privatePEM, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("GenerateSigningKey() error: %v", err)
+	}
+	publicKey, err := ParsePublicKey(publicText)
+	if err != nil {
+		t.Fatalf("ParsePublicKey() error: %v", err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "signing.key")
+	if err := os.WriteFile(keyPath, privatePEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	privateKey, err := LoadPrivateKey(keyPath)
+	if err != nil {
+		t.Fatalf("LoadPrivateKey() error: %v", err)
+	}
+	if !publicKey.Equal(privateKey.Public()) {
+		t.Fatal("loaded private key does not match the public key")
+	}
+	return publicKey, privateKey
+}
+
+func TestSignatureRoundTrip(t *testing.T) {
+	trustedPublic, trustedPrivate := newTestSigningKey(t)
+	_, otherPrivate := newTestSigningKey(t)
+
+	dir := t.TempDir()
+	signedPath, _ := writeTestPackage(t, dir, "signed", "1.0.0", CurrentPlatform(), trustedPrivate)
+	untrustedPath, _ := writeTestPackage(t, dir, "untrusted", "1.0.0", CurrentPlatform(), otherPrivate)
+	unsignedPath, _ := writeTestPackage(t, dir, "unsigned", "1.0.0", CurrentPlatform(), nil)
+
+	read := func(path string) *packageDescriptor {
+		desc, err := readPackageFile(path)
+		if err != nil {
+			t.Fatalf("readPackageFile(%s) error: %v", path, err)
+		}
+		return desc
+	}
+	signed, untrusted, unsigned := read(signedPath), read(untrustedPath), read(unsignedPath)
+	tampered := append(append([]byte(nil), signed.manifestData...), ' ')
+
+	packages := []struct {
+		name         string
+		manifestData []byte
+		signature    []byte
+		wantStatus   SignatureStatus
+	}{
+		{name: "trusted", manifestData: signed.manifestData, signature: signed.signatureData, wantStatus: SignatureVerified},
+		{name: "untrusted", manifestData: untrusted.manifestData, signature: untrusted.signatureData, wantStatus: SignatureUntrusted},
+		{name: "unsigned", manifestData: unsigned.manifestData, signature: unsigned.signatureData, wantStatus: SignatureUnsigned},
+		{name: "tampered", manifestData: tampered, signature: signed.signatureData, wantStatus: SignatureInvalid},
+		{name: "malformed", manifestData: signed.manifestData, signature: []byte("{"), wantStatus: SignatureInvalid},
+	}
+	accepted := map[SignaturePolicy]map[string]bool{
+		SignaturePolicyReject:        {"trusted": true},
+		SignaturePolicyWarn:          {"trusted": true, "untrusted": true, "unsigned": true},
+		SignaturePolicyAllowUnsigned: {"trusted": true, "unsigned": true},
+	}
+
+	for policy, allowed := range accepted {
+		verifier := NewSignatureVerifier(policy, []ed25519.PublicKey{trustedPublic}, zap.NewNop())
+		for _, pkg := range packages {
+			t.Run(string(policy)+"/"+pkg.name, func(t *testing.T) {
+				status, err := verifier.Verify(pkg.name, pkg.manifestData, pkg.signature)
+				if status != pkg.wantStatus {
+					t.Errorf("Verify() status = %s, want %s", status, pkg.wantStatus)
+				}
+				if allowed[pkg.name] {
+					if err != nil {
+						t.Fatalf("Verify() error: %v", err)
+					}
+					return
+				}
+				if !errors.Is(err, ErrSignatureRejected) {
+					t.Fatalf("Verify() error = %v, want ErrSignatureRejected", err)
+				}
+			})
+		}
+	}
+}
@@ -3,10 +3,11 @@ package plugin
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -25,6 +26,9 @@ type StandaloneRunner struct {
 	
 	// server HTTP服务器
 	server *http.Server
+	
+	// config 传给Plugin.Initialize的配置
+	config interface{}
 }
 
 // NewStandaloneRunner 创建新的独立运行器
@@ -38,18 +42,34 @@ func NewStandaloneRunner(plugin Plugin, logger *zap.Logger) *StandaloneRunner {
 	}
 }
 
+// SetConfig 设置初始化插件时传入的配置
+// config: 配置数据，通常由LoadPluginConfig读取
+func (r *StandaloneRunner) SetConfig(config interface{}) {
+	r.config = config
+}
+
 // Run 运行插件
 // ctx: 上下文
 // port: 监听端口
 // 返回: 错误信息
 func (r *StandaloneRunner) Run(ctx context.Context, port int) error {
+	return r.RunOn(ctx, NetworkTCP, fmt.Sprintf(":%d", port))
+}
+
+// RunOn 在指定网络地址上运行插件
+// 由网关启动时（设置了握手环境变量）会在开始监听后向标准输出写入就绪握手行
+// ctx: 上下文
+// network: 网络类型（tcp 或 unix）
+// address: 监听地址或套接字路径
+// 返回: 错误信息
+func (r *StandaloneRunner) RunOn(ctx context.Context, network, address string) error {
 	// 检查插件是否支持独立运行
 	if !r.plugin.CanRunStandalone() {
 		return fmt.Errorf("plugin '%s' does not support standalone mode", r.plugin.GetName())
 	}
 	
 	// 初始化插件
-	if err := r.plugin.Initialize(ctx, r.logger, nil); err != nil {
+	if err := r.plugin.Initialize(ctx, r.logger, r.config); err != nil {
 		return fmt.Errorf("failed to initialize plugin: %w", err)
 	}
 	
@@ -83,10 +103,21 @@ func (r *StandaloneRunner) Run(ctx context.Context, port int) error {
 	// 添加插件信息路由
 	router.GET("/info", r.infoHandler)
 	
+	// 清理残留的套接字文件
+	if network == NetworkUnix {
+		if err := os.Remove(address); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+	
+	// 先监听再启动服务，确保握手时已可接受连接
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s %s: %w", network, address, err)
+	}
+	
 	// 创建HTTP服务器
-	addr := fmt.Sprintf(":%d", port)
 	r.server = &http.Server{
-		Addr:    addr,
 		Handler: router,
 	}
 	
@@ -94,13 +125,19 @@ func (r *StandaloneRunner) Run(ctx context.Context, port int) error {
 	go func() {
 		r.logger.Info("Starting standalone plugin server", 
 			zap.String("plugin", r.plugin.GetName()),
-			zap.String("addr", addr))
+			zap.String("network", network),
+			zap.String("addr", listener.Addr().String()))
 		
-		if err := r.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := r.server.Serve(listener); err != nil && err != http.ErrServerClosed {
 			r.logger.Error("Failed to start server", zap.Error(err))
 		}
 	}()
 	
+	// 通知网关已就绪
+	if os.Getenv(readyHandshakeEnv) != "" {
+		fmt.Fprintf(os.Stdout, "%s %s %s\n", readyHandshakePrefix, network, listener.Addr().String())
+	}
+	
 	// 等待中断信号
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -171,26 +208,23 @@ func (r *StandaloneRunner) infoHandler(c *gin.Context) {
 }
 
 // RunStandaloneFromArgs 从命令行参数运行独立模式
+// 指定--config时读取网关生成的配置文件（及敏感配置项的环境变量）传给插件
 // plugin: 插件实例
 // logger: 日志记录器
 // args: 命令行参数
 // 返回: 错误信息
 func RunStandaloneFromArgs(plugin Plugin, logger *zap.Logger, args []string) error {
 	// 解析命令行参数
-	port := 8080 // 默认端口
+	network, address := parseListenArgs(args, 8080) // 默认端口8080
 	
-	for i, arg := range args {
+	for _, arg := range args {
 		switch arg {
-		case "--port":
-			if i+1 < len(args) {
-				if p, err := strconv.Atoi(args[i+1]); err == nil {
-					port = p
-				}
-			}
 		case "--help", "-h":
 			fmt.Printf("Usage: %s [options]\n", os.Args[0])
 			fmt.Println("Options:")
 			fmt.Println("  --port <port>    Listen port (default: 8080)")
+			fmt.Println("  --socket <path>  Listen on a Unix domain socket")
+			fmt.Println("  --config <path>  Plugin config file generated by the gateway")
 			fmt.Println("  --help, -h       Show this help message")
 			fmt.Println("  --metadata       Show plugin metadata")
 			return nil
@@ -213,5 +247,31 @@ func RunStandaloneFromArgs(plugin Plugin, logger *zap.Logger, args []string) err
 	
 	// 创建并运行独立运行器
 	runner := NewStandaloneRunner(plugin, logger)
-	return runner.Run(context.Background(), port)
+	if path := parseConfigArg(args); path != "" {
+		config, err := LoadPluginConfig(path)
+		if err != nil {
+			return err
+		}
+		runner.SetConfig(config)
+	}
+	return runner.RunOn(context.Background(), network, address)
+}
+
+// parseConfigArg 从命令行参数解析配置文件路径
+// args: 命令行参数
+// 返回: 配置文件路径，未指定时为空
+func parseConfigArg(args []string) string {
+	var path string
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != pluginConfigFlag {
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			value = args[i+1]
+			i++
+		}
+		path = value
+	}
+	return path
 }
\ No newline at end of file
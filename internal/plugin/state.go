@@ -0,0 +1,245 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PluginState 插件生命周期状态
+type PluginState string
+
+const (
+	// StateInstalled 插件包已安装但未加载
+	StateInstalled PluginState = "installed"
+
+	// StateLoaded 插件已加载（解压并校验），尚未启动
+	StateLoaded PluginState = "loaded"
+
+	// StateInitializing 插件正在初始化，等待就绪
+	StateInitializing PluginState = "initializing"
+
+	// StateRunning 插件运行中
+	StateRunning PluginState = "running"
+
+	// StateDegraded 插件运行中但健康检查失败（如子进程正在重启）
+	StateDegraded PluginState = "degraded"
+
+	// StateStopped 插件已停止
+	StateStopped PluginState = "stopped"
+
+	// StateFailed 插件加载、初始化或停止失败
+	StateFailed PluginState = "failed"
+)
+
+// maxStateHistory 每个插件保留的状态转换记录数
+const maxStateHistory = 20
+
+var (
+	// ErrPluginNotFound 插件未加载也未安装
+	ErrPluginNotFound = errors.New("plugin not found")
+
+	// ErrInvalidStateTransition 当前状态不允许该操作
+	ErrInvalidStateTransition = errors.New("invalid plugin state transition")
+)
+
+// stateTransitions 允许的状态转换
+var stateTransitions = map[PluginState][]PluginState{
+	StateInstalled:    {StateLoaded, StateFailed},
+	StateLoaded:       {StateInitializing, StateStopped, StateFailed},
+	StateInitializing: {StateRunning, StateFailed},
+	StateRunning:      {StateDegraded, StateStopped, StateFailed},
+	StateDegraded:     {StateRunning, StateStopped, StateFailed},
+	StateStopped:      {StateLoaded, StateInitializing, StateFailed},
+	StateFailed:       {StateLoaded, StateInitializing, StateStopped},
+}
+
+// CanTransition 是否允许从当前状态转换到目标状态
+// to: 目标状态
+// 返回: 是否允许
+func (s PluginState) CanTransition(to PluginState) bool {
+	for _, allowed := range stateTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StateTransition 状态转换记录
+type StateTransition struct {
+	// From 转换前的状态
+	From PluginState `json:"from"`
+
+	// To 转换后的状态
+	To PluginState `json:"to"`
+
+	// At 转换时间
+	At time.Time `json:"at"`
+
+	// Error 导致转换的错误
+	Error string `json:"error,omitempty"`
+}
+
+// PluginStatus 插件生命周期状态快照
+type PluginStatus struct {
+	// State 当前状态
+	State PluginState `json:"state"`
+
+	// Since 进入当前状态的时间
+	Since time.Time `json:"since"`
+
+	// LastError 最近一次错误
+	LastError string `json:"last_error,omitempty"`
+
+	// LastErrorAt 最近一次错误的时间
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+
+	// Transitions 最近的状态转换记录，按时间顺序排列
+	Transitions []StateTransition `json:"transitions,omitempty"`
+}
+
+// stateTracker 记录插件的生命周期状态
+// 有独立的锁，持有管理器读锁时也可以更新（如健康检查发现降级）
+type stateTracker struct {
+	// mu 保护records
+	mu sync.Mutex
+
+	// records 插件名称到状态的映射
+	records map[string]*PluginStatus
+}
+
+// newStateTracker 创建状态记录器
+// 返回: 状态记录器实例
+func newStateTracker() *stateTracker {
+	return &stateTracker{records: make(map[string]*PluginStatus)}
+}
+
+// transition 转换插件状态
+// 首次记录的插件直接进入目标状态；状态不变时只更新错误信息
+// name: 插件名称
+// to: 目标状态
+// cause: 导致转换的错误，可为nil
+// 返回: 不允许转换时返回包装了ErrInvalidStateTransition的错误
+func (t *stateTracker) transition(name string, to PluginState, cause error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.transitionLocked(name, to, cause)
+}
+
+// transitionLocked 转换插件状态，调用方需持有mu
+// name: 插件名称
+// to: 目标状态
+// cause: 导致转换的错误，可为nil
+// 返回: 不允许转换时返回包装了ErrInvalidStateTransition的错误
+func (t *stateTracker) transitionLocked(name string, to PluginState, cause error) error {
+	now := time.Now()
+	var from PluginState
+	record, exists := t.records[name]
+	if exists {
+		from = record.State
+		if from != to && !from.CanTransition(to) {
+			return fmt.Errorf("%w: %s -> %s", ErrInvalidStateTransition, from, to)
+		}
+	} else {
+		record = &PluginStatus{}
+		t.records[name] = record
+	}
+	if from != to {
+		record.State, record.Since = to, now
+	}
+
+	entry := StateTransition{From: from, To: to, At: now}
+	if cause != nil {
+		entry.Error = cause.Error()
+		record.LastError = entry.Error
+		record.LastErrorAt = &now
+	}
+	if entry.From != entry.To || cause != nil {
+		record.Transitions = append(record.Transitions, entry)
+		if len(record.Transitions) > maxStateHistory {
+			record.Transitions = record.Transitions[len(record.Transitions)-maxStateHistory:]
+		}
+	}
+	return nil
+}
+
+// state 返回插件的当前状态
+// name: 插件名称
+// 返回: 当前状态和是否有记录
+func (t *stateTracker) state(name string) (PluginState, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, exists := t.records[name]
+	if !exists {
+		return "", false
+	}
+	return record.State, true
+}
+
+// namesIn 列出处于指定状态的插件
+// states: 状态列表
+// 返回: 按名称排序的插件名称列表
+func (t *stateTracker) namesIn(states ...PluginState) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var names []string
+	for name, record := range t.records {
+		for _, state := range states {
+			if record.State == state {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// status 返回插件状态的快照
+// name: 插件名称
+// 返回: 状态快照和是否有记录
+func (t *stateTracker) status(name string) (PluginStatus, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, exists := t.records[name]
+	if !exists {
+		return PluginStatus{}, false
+	}
+	snapshot := *record
+	snapshot.Transitions = append([]StateTransition(nil), record.Transitions...)
+	return snapshot, true
+}
+
+// observeHealth 根据健康检查结果在运行和降级之间切换
+// 读取当前状态和转换在同一次加锁内完成，期间插件被停止或标记为失败时不会被覆盖
+// name: 插件名称
+// healthErr: 健康检查错误
+func (t *stateTracker) observeHealth(name string, healthErr error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, exists := t.records[name]
+	if !exists {
+		return
+	}
+	switch {
+	case record.State == StateRunning && healthErr != nil:
+		t.transitionLocked(name, StateDegraded, healthErr)
+	case record.State == StateDegraded && healthErr == nil:
+		t.transitionLocked(name, StateRunning, nil)
+	}
+}
+
+// remove 删除插件的状态记录
+// name: 插件名称
+func (t *stateTracker) remove(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.records, name)
+}
@@ -0,0 +1,232 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestPluginStateCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to PluginState
+		want     bool
+	}{
+		{StateInstalled, StateLoaded, true},
+		{StateInstalled, StateRunning, false},
+		{StateLoaded, StateInitializing, true},
+		{StateLoaded, StateRunning, false},
+		{StateInitializing, StateRunning, true},
+		{StateInitializing, StateStopped, false},
+		{StateRunning, StateDegraded, true},
+		{StateRunning, StateInitializing, false},
+		{StateDegraded, StateRunning, true},
+		{StateStopped, StateInitializing, true},
+		{StateStopped, StateRunning, false},
+		{StateStopped, StateDegraded, false},
+		{StateFailed, StateLoaded, true},
+		{StateFailed, StateRunning, false},
+		{StateFailed, StateDegraded, false},
+		{"unknown", StateRunning, false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransition(tt.to); got != tt.want {
+			t.Errorf("%s.CanTransition(%s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestStateTrackerTransition(t *testing.T) {
+	tracker := newStateTracker()
+	boom := errors.New("boom")
+
+	steps := []struct {
+		to      PluginState
+		cause   error
+		wantErr bool
+		want    PluginState
+	}{
+		{to: StateLoaded, want: StateLoaded},
+		{to: StateRunning, wantErr: true, want: StateLoaded},
+		{to: StateInitializing, want: StateInitializing},
+		{to: StateRunning, want: StateRunning},
+		{to: StateRunning, cause: boom, want: StateRunning},
+		{to: StateInitializing, wantErr: true, want: StateRunning},
+		{to: StateFailed, cause: boom, want: StateFailed},
+		{to: StateDegraded, wantErr: true, want: StateFailed},
+		{to: StateStopped, want: StateStopped},
+	}
+	for i, step := range steps {
+		err := tracker.transition("demo", step.to, step.cause)
+		if step.wantErr != errors.Is(err, ErrInvalidStateTransition) {
+			t.Fatalf("step %d: transition(%s) error = %v, want invalid %v", i, step.to, err, step.wantErr)
+		}
+		if got, _ := tracker.state("demo"); got != step.want {
+			t.Fatalf("step %d: state = %s, want %s", i, got, step.want)
+		}
+	}
+
+	status, _ := tracker.status("demo")
+	var history []PluginState
+	for _, entry := range status.Transitions {
+		history = append(history, entry.To)
+	}
+	// 被拒绝的转换不记录，状态不变但带错误的转换会记录
+	want := []PluginState{StateLoaded, StateInitializing, StateRunning, StateRunning, StateFailed, StateStopped}
+	if !reflect.DeepEqual(history, want) {
+		t.Fatalf("history = %v, want %v", history, want)
+	}
+	if status.LastError != "boom" || status.LastErrorAt == nil {
+		t.Fatalf("last error = %q at %v", status.LastError, status.LastErrorAt)
+	}
+}
+
+func TestStateTrackerHistoryLimit(t *testing.T) {
+	tracker := newStateTracker()
+	tracker.transition("demo", StateRunning, nil)
+	for i := 0; i < maxStateHistory; i++ {
+		tracker.transition("demo", StateDegraded, nil)
+		tracker.transition("demo", StateRunning, nil)
+	}
+
+	status, _ := tracker.status("demo")
+	if len(status.Transitions) != maxStateHistory {
+		t.Fatalf("kept %d transitions, want %d", len(status.Transitions), maxStateHistory)
+	}
+	if last := status.Transitions[len(status.Transitions)-1]; last.From != StateDegraded || last.To != StateRunning {
+		t.Fatalf("last transition = %+v", last)
+	}
+}
+
+func TestObserveHealth(t *testing.T) {
+	unhealthy := errors.New("unhealthy")
+	tests := []struct {
+		name      string
+		state     PluginState
+		healthErr error
+		want      PluginState
+	}{
+		{name: "running becomes degraded", state: StateRunning, healthErr: unhealthy, want: StateDegraded},
+		{name: "degraded recovers", state: StateDegraded, want: StateRunning},
+		{name: "running stays running", state: StateRunning, want: StateRunning},
+		{name: "degraded stays degraded", state: StateDegraded, healthErr: unhealthy, want: StateDegraded},
+		{name: "stopped is not degraded", state: StateStopped, healthErr: unhealthy, want: StateStopped},
+		{name: "failed does not recover", state: StateFailed, want: StateFailed},
+		{name: "initializing is left alone", state: StateInitializing, healthErr: unhealthy, want: StateInitializing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newStateTracker()
+			tracker.transition("demo", tt.state, nil)
+			tracker.observeHealth("demo", tt.healthErr)
+			if got, _ := tracker.state("demo"); got != tt.want {
+				t.Fatalf("state = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	tracker := newStateTracker()
+	tracker.observeHealth("missing", unhealthy)
+	if _, exists := tracker.state("missing"); exists {
+		t.Fatal("observeHealth created a record for an unknown plugin")
+	}
+}
+
+func TestObserveHealthDoesNotOverrideStop(t *testing.T) {
+	unhealthy := errors.New("unhealthy")
+	for i := 0; i < 50; i++ {
+		tracker := newStateTracker()
+		tracker.transition("demo", StateRunning, nil)
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				tracker.observeHealth("demo", unhealthy)
+				tracker.observeHealth("demo", nil)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := tracker.transition("demo", StateStopped, nil); err != nil {
+				t.Errorf("transition(stopped) error: %v", err)
+			}
+		}()
+		wg.Wait()
+
+		if got, _ := tracker.state("demo"); got != StateStopped {
+			t.Fatalf("state = %s, want stopped", got)
+		}
+		// 停止之后的健康检查结果不产生任何转换记录
+		status, _ := tracker.status("demo")
+		if last := status.Transitions[len(status.Transitions)-1]; last.To != StateStopped {
+			t.Fatalf("last transition = %+v, want stopped", last)
+		}
+	}
+}
+
+// healthTestPlugin 健康检查结果可由测试控制的插件
+type healthTestPlugin struct {
+	depsTestPlugin
+
+	// mu 保护healthErr
+	mu sync.Mutex
+
+	// healthErr Health返回的错误
+	healthErr error
+}
+
+func (p *healthTestPlugin) setHealth(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.healthErr = err
+}
+
+func (p *healthTestPlugin) Health() (map[string]interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return nil, p.healthErr
+}
+
+func TestManagerLifecycleTransitions(t *testing.T) {
+	p := &healthTestPlugin{depsTestPlugin: depsTestPlugin{metadata: PluginMetadata{Name: "demo", Version: "1.0.0"}}}
+	m := NewManager(zap.NewNop(), t.TempDir())
+	m.SetLoader(newTestLoader())
+	if err := m.RegisterPlugin(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.InitializeAll(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	steps := []struct {
+		name    string
+		apply   func() error
+		wantErr error
+		want    PluginState
+	}{
+		{name: "initialized", apply: func() error { return nil }, want: StateRunning},
+		{name: "health fails", apply: func() error { p.setHealth(errors.New("down")); _, err := m.HealthCheckAll(); return err }, want: StateDegraded},
+		{name: "health recovers", apply: func() error { p.setHealth(nil); _, err := m.HealthCheckAll(); return err }, want: StateRunning},
+		{name: "stop", apply: func() error { return m.StopPlugin(ctx, "demo") }, want: StateStopped},
+		{name: "stop again", apply: func() error { return m.StopPlugin(ctx, "demo") }, wantErr: ErrInvalidStateTransition, want: StateStopped},
+		{name: "stopped ignores health", apply: func() error { p.setHealth(errors.New("down")); _, err := m.HealthCheckAll(); return err }, want: StateStopped},
+		{name: "start", apply: func() error { p.setHealth(nil); _, err := m.StartPlugin(ctx, "demo"); return err }, want: StateRunning},
+		{name: "start again", apply: func() error { _, err := m.StartPlugin(ctx, "demo"); return err }, wantErr: ErrInvalidStateTransition, want: StateRunning},
+		{name: "restart", apply: func() error { return m.RestartPlugin(ctx, "demo") }, want: StateRunning},
+		{name: "stop unknown", apply: func() error { return m.StopPlugin(ctx, "missing") }, wantErr: ErrPluginNotFound, want: StateRunning},
+	}
+	for _, step := range steps {
+		if err := step.apply(); !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.wantErr)
+		}
+		if got, _ := m.states.state("demo"); got != step.want {
+			t.Fatalf("%s: state = %s, want %s", step.name, got, step.want)
+		}
+	}
+}
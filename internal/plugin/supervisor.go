@@ -0,0 +1,364 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RestartMode 插件进程重启模式
+type RestartMode string
+
+const (
+	// RestartNever 进程退出后不重启
+	RestartNever RestartMode = "never"
+	
+	// RestartOnFailure 进程以非零状态退出时重启
+	RestartOnFailure RestartMode = "on-failure"
+	
+	// RestartAlways 进程退出后总是重启
+	RestartAlways RestartMode = "always"
+)
+
+const (
+	// defaultMaxRestarts 默认最大连续重启次数
+	defaultMaxRestarts = 5
+	
+	// defaultInitialBackoff 默认初始退避时间
+	defaultInitialBackoff = time.Second
+	
+	// defaultMaxBackoff 默认最大退避时间
+	defaultMaxBackoff = 30 * time.Second
+	
+	// stableRunDuration 进程连续运行超过该时长后视为稳定，重置退避和连续重启计数
+	stableRunDuration = time.Minute
+)
+
+// RestartPolicy 插件进程重启策略
+type RestartPolicy struct {
+	// Mode 重启模式
+	Mode RestartMode
+	
+	// MaxRestarts 最大连续重启次数，小于等于0表示不限制
+	MaxRestarts int
+	
+	// InitialBackoff 首次重启前的等待时间
+	InitialBackoff time.Duration
+	
+	// MaxBackoff 指数退避的上限
+	MaxBackoff time.Duration
+}
+
+// DefaultRestartPolicy 返回默认重启策略
+// 返回: 重启策略
+func DefaultRestartPolicy() RestartPolicy {
+	return RestartPolicy{
+		Mode:           RestartOnFailure,
+		MaxRestarts:    defaultMaxRestarts,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+	}
+}
+
+// ParseRestartMode 解析重启模式
+// s: 重启模式字符串
+// 返回: 重启模式和错误信息
+func ParseRestartMode(s string) (RestartMode, error) {
+	switch mode := RestartMode(s); mode {
+	case RestartNever, RestartOnFailure, RestartAlways:
+		return mode, nil
+	case "":
+		return RestartOnFailure, nil
+	default:
+		return "", fmt.Errorf("unknown restart policy: %s", s)
+	}
+}
+
+// withDefaults 填充未设置的字段
+// 返回: 补全后的重启策略
+func (p RestartPolicy) withDefaults() RestartPolicy {
+	if p.Mode == "" {
+		p.Mode = RestartOnFailure
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultMaxBackoff
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		p.MaxBackoff = p.InitialBackoff
+	}
+	return p
+}
+
+// shouldRestart 判断进程退出后是否需要重启
+// exitCode: 退出码（被信号终止时为-1）
+// attempts: 已连续重启次数
+// 返回: 是否重启
+func (p RestartPolicy) shouldRestart(exitCode, attempts int) bool {
+	if p.MaxRestarts > 0 && attempts >= p.MaxRestarts {
+		return false
+	}
+	
+	switch p.Mode {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return exitCode != 0
+	default:
+		return false
+	}
+}
+
+// backoff 计算第attempt次重启前的等待时间
+// attempt: 连续重启次数（从0开始）
+// 返回: 等待时间
+func (p RestartPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 0; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// SupervisorStatus 监督器状态快照
+type SupervisorStatus struct {
+	// Policy 重启模式
+	Policy RestartMode `json:"policy"`
+	
+	// Restarts 累计重启次数
+	Restarts int `json:"restarts"`
+	
+	// Restarting 是否正在等待或执行重启
+	Restarting bool `json:"restarting"`
+	
+	// GaveUp 是否已放弃重启
+	GaveUp bool `json:"gave_up"`
+	
+	// LastExitCode 最近一次退出码
+	LastExitCode *int `json:"last_exit_code,omitempty"`
+	
+	// LastExitAt 最近一次退出时间
+	LastExitAt *time.Time `json:"last_exit_at,omitempty"`
+	
+	// LastError 最近一次退出或重启失败的错误
+	LastError string `json:"last_error,omitempty"`
+}
+
+// processSupervisor 插件进程监督器
+// 监听子进程退出（cmd.Wait），并按重启策略重新拉起
+type processSupervisor struct {
+	// name 插件名称
+	name string
+	
+	// policy 重启策略
+	policy RestartPolicy
+	
+	// spawn 启动新进程并等待就绪
+	spawn func(ctx context.Context) (*pluginProcess, error)
+	
+	// attach 新进程就绪后回调
+	attach func(proc *pluginProcess)
+	
+	// logger 日志记录器
+	logger *zap.Logger
+	
+	// ctx 监督器生命周期上下文，停止时取消
+	ctx context.Context
+	
+	// cancel 取消函数
+	cancel context.CancelFunc
+	
+	// loopDone 监督循环退出时关闭
+	loopDone chan struct{}
+	
+	// mu 保护状态字段
+	mu sync.Mutex
+	
+	// status 状态快照
+	status SupervisorStatus
+}
+
+// newProcessSupervisor 创建进程监督器
+// name: 插件名称
+// policy: 重启策略
+// spawn: 进程启动函数
+// attach: 进程就绪回调
+// logger: 日志记录器
+// 返回: 进程监督器实例
+func newProcessSupervisor(name string, policy RestartPolicy, spawn func(ctx context.Context) (*pluginProcess, error), attach func(proc *pluginProcess), logger *zap.Logger) *processSupervisor {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy = policy.withDefaults()
+	return &processSupervisor{
+		name:     name,
+		policy:   policy,
+		spawn:    spawn,
+		attach:   attach,
+		logger:   logger,
+		ctx:      ctx,
+		cancel:   cancel,
+		loopDone: make(chan struct{}),
+		status:   SupervisorStatus{Policy: policy.Mode},
+	}
+}
+
+// watch 开始监督指定进程
+// proc: 已就绪的子进程
+func (s *processSupervisor) watch(proc *pluginProcess) {
+	go s.loop(proc)
+}
+
+// loop 监督循环
+// proc: 当前子进程
+func (s *processSupervisor) loop(proc *pluginProcess) {
+	defer close(s.loopDone)
+	
+	attempts := 0
+	for {
+		select {
+		case <-proc.done:
+		case <-s.ctx.Done():
+			return
+		}
+		
+		// 停止过程中的退出由停止方处理
+		if s.ctx.Err() != nil {
+			return
+		}
+		
+		exitCode := proc.exitCode()
+		if time.Since(proc.startedAt) >= stableRunDuration {
+			attempts = 0
+		}
+		s.recordExit(exitCode, proc.waitErr)
+		proc.endpoint.cleanup()
+		
+		s.logger.Warn("Plugin process exited",
+			zap.String("plugin", s.name),
+			zap.Int("pid", proc.pid()),
+			zap.Int("exit_code", exitCode),
+			zap.Error(proc.waitErr))
+		
+		next, ok := s.restart(exitCode, &attempts)
+		if !ok {
+			return
+		}
+		proc = next
+	}
+}
+
+// restart 按策略重启进程，启动失败时继续退避重试
+// exitCode: 上次退出码
+// attempts: 连续重启次数
+// 返回: 新进程和是否成功
+func (s *processSupervisor) restart(exitCode int, attempts *int) (*pluginProcess, bool) {
+	for {
+		if !s.policy.shouldRestart(exitCode, *attempts) {
+			s.mu.Lock()
+			s.status.Restarting = false
+			s.status.GaveUp = s.policy.MaxRestarts > 0 && *attempts >= s.policy.MaxRestarts
+			s.mu.Unlock()
+			
+			s.logger.Error("Plugin process will not be restarted",
+				zap.String("plugin", s.name),
+				zap.String("policy", string(s.policy.Mode)),
+				zap.Int("attempts", *attempts),
+				zap.Int("exit_code", exitCode))
+			return nil, false
+		}
+		
+		delay := s.policy.backoff(*attempts)
+		s.setRestarting(true)
+		s.logger.Info("Restarting plugin process",
+			zap.String("plugin", s.name),
+			zap.Int("attempt", *attempts+1),
+			zap.Duration("backoff", delay))
+		
+		select {
+		case <-time.After(delay):
+		case <-s.ctx.Done():
+			return nil, false
+		}
+		
+		*attempts++
+		proc, err := s.spawn(s.ctx)
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return nil, false
+			}
+			s.mu.Lock()
+			s.status.Restarts++
+			s.status.LastError = err.Error()
+			s.mu.Unlock()
+			
+			s.logger.Error("Failed to restart plugin process",
+				zap.String("plugin", s.name),
+				zap.Error(err))
+			exitCode = -1
+			continue
+		}
+		
+		// 停止请求与重启完成并发时，丢弃新进程
+		if s.ctx.Err() != nil {
+			proc.kill()
+			return nil, false
+		}
+		
+		s.mu.Lock()
+		s.status.Restarts++
+		s.status.Restarting = false
+		s.mu.Unlock()
+		
+		s.attach(proc)
+		s.logger.Info("Plugin process restarted",
+			zap.String("plugin", s.name),
+			zap.Int("pid", proc.pid()))
+		return proc, true
+	}
+}
+
+// recordExit 记录进程退出信息
+// exitCode: 退出码
+// err: 退出错误
+func (s *processSupervisor) recordExit(exitCode int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	now := time.Now()
+	s.status.LastExitCode = &exitCode
+	s.status.LastExitAt = &now
+	s.status.LastError = ""
+	if err != nil {
+		s.status.LastError = err.Error()
+	}
+}
+
+// setRestarting 设置重启中标志
+// restarting: 是否重启中
+func (s *processSupervisor) setRestarting(restarting bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status.Restarting = restarting
+}
+
+// snapshot 返回状态快照
+// 返回: 监督器状态
+func (s *processSupervisor) snapshot() SupervisorStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// stop 停止监督，不再重启进程
+// 阻塞直到监督循环退出
+func (s *processSupervisor) stop() {
+	s.cancel()
+	<-s.loopDone
+}
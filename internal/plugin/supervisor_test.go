@@ -0,0 +1,279 @@
+//go:build unix
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestRestartPolicyShouldRestart(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RestartPolicy
+		exitCode int
+		attempts int
+		want     bool
+	}{
+		{name: "never after failure", policy: RestartPolicy{Mode: RestartNever}, exitCode: 1},
+		{name: "on-failure after success", policy: RestartPolicy{Mode: RestartOnFailure}, exitCode: 0},
+		{name: "on-failure after failure", policy: RestartPolicy{Mode: RestartOnFailure}, exitCode: 1, want: true},
+		{name: "on-failure after signal", policy: RestartPolicy{Mode: RestartOnFailure}, exitCode: -1, want: true},
+		{name: "always after success", policy: RestartPolicy{Mode: RestartAlways}, exitCode: 0, want: true},
+		{name: "below max restarts", policy: RestartPolicy{Mode: RestartAlways, MaxRestarts: 3}, attempts: 2, want: true},
+		{name: "max restarts reached", policy: RestartPolicy{Mode: RestartAlways, MaxRestarts: 3}, attempts: 3},
+		{name: "zero max restarts is unlimited", policy: RestartPolicy{Mode: RestartAlways, MaxRestarts: 0}, attempts: 1000, want: true},
+		{name: "negative max restarts is unlimited", policy: RestartPolicy{Mode: RestartOnFailure, MaxRestarts: -1}, exitCode: 1, attempts: 1000, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.shouldRestart(tt.exitCode, tt.attempts); got != tt.want {
+				t.Fatalf("shouldRestart(%d, %d) = %v, want %v", tt.exitCode, tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestartPolicyBackoff(t *testing.T) {
+	policy := RestartPolicy{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}.withDefaults()
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for attempt, delay := range want {
+		if got := policy.backoff(attempt); got != delay {
+			t.Errorf("backoff(%d) = %s, want %s", attempt, got, delay)
+		}
+	}
+
+	defaults := RestartPolicy{}.withDefaults()
+	if defaults.Mode != RestartOnFailure || defaults.InitialBackoff != defaultInitialBackoff || defaults.MaxBackoff != defaultMaxBackoff {
+		t.Errorf("withDefaults() = %+v", defaults)
+	}
+	if clamped := (RestartPolicy{InitialBackoff: time.Minute, MaxBackoff: time.Second}).withDefaults(); clamped.MaxBackoff != time.Minute {
+		t.Errorf("MaxBackoff below InitialBackoff = %s, want %s", clamped.MaxBackoff, time.Minute)
+	}
+}
+
+func TestParseRestartMode(t *testing.T) {
+	for input, want := range map[string]RestartMode{"": RestartOnFailure, "never": RestartNever, "on-failure": RestartOnFailure, "always": RestartAlways} {
+		if got, err := ParseRestartMode(input); err != nil || got != want {
+			t.Errorf("ParseRestartMode(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseRestartMode("sometimes"); err == nil {
+		t.Error("ParseRestartMode() accepted an unknown mode")
+	}
+}
+
+// supervisorHarness 用模拟插件子进程驱动监督器
+type supervisorHarness struct {
+	// mu 保护spawned和attached
+	mu sync.Mutex
+
+	// spawned 启动子进程的次数（含首次启动）
+	spawned int
+
+	// attached 重启后回调的次数
+	attached int
+}
+
+// newTestSupervisor 创建按exitCodes依次退出的子进程的监督器并开始监督首个子进程
+// exitCodes: 每次启动的子进程的退出码，用完后重复最后一个
+// policy: 重启策略
+// spawnErr: 非nil时重启的启动函数返回该错误
+// 返回: 监督器和调用计数
+func newTestSupervisor(t *testing.T, exitCodes []string, policy RestartPolicy, spawnErr error) (*processSupervisor, *supervisorHarness) {
+	t.Helper()
+
+	h := &supervisorHarness{}
+	spawn := func(ctx context.Context) (*pluginProcess, error) {
+		h.mu.Lock()
+		n := h.spawned
+		h.spawned++
+		h.mu.Unlock()
+		if spawnErr != nil && n > 0 {
+			return nil, spawnErr
+		}
+		proc, _ := startHelperProcess(t, "exit:"+exitCodes[min(n, len(exitCodes)-1)], NetworkTCP)
+		return proc, nil
+	}
+	attach := func(*pluginProcess) {
+		h.mu.Lock()
+		h.attached++
+		h.mu.Unlock()
+	}
+
+	sup := newProcessSupervisor("demo", policy, spawn, attach, zap.NewNop())
+	first, _ := spawn(context.Background())
+	sup.watch(first)
+	t.Cleanup(sup.stop)
+	return sup, h
+}
+
+func TestSupervisorRestarts(t *testing.T) {
+	backoff := func(p RestartPolicy) RestartPolicy {
+		p.InitialBackoff, p.MaxBackoff = time.Millisecond, 4*time.Millisecond
+		return p
+	}
+
+	tests := []struct {
+		name         string
+		exitCodes    []string
+		policy       RestartPolicy
+		spawnErr     error
+		wantRestarts int
+		wantAttached int
+		wantGaveUp   bool
+		wantExitCode int
+		wantError    string
+	}{
+		{
+			name:         "never",
+			exitCodes:    []string{"1"},
+			policy:       RestartPolicy{Mode: RestartNever, MaxRestarts: 5},
+			wantExitCode: 1,
+		},
+		{
+			name:         "on-failure after clean exit",
+			exitCodes:    []string{"0"},
+			policy:       RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 5},
+			wantExitCode: 0,
+		},
+		{
+			name:         "on-failure until clean exit",
+			exitCodes:    []string{"1", "2", "0"},
+			policy:       RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 5},
+			wantRestarts: 2,
+			wantAttached: 2,
+			wantExitCode: 0,
+		},
+		{
+			name:         "on-failure gives up at max restarts",
+			exitCodes:    []string{"1"},
+			policy:       RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 3},
+			wantRestarts: 3,
+			wantAttached: 3,
+			wantGaveUp:   true,
+			wantExitCode: 1,
+		},
+		{
+			name:         "always restarts after clean exit",
+			exitCodes:    []string{"0"},
+			policy:       RestartPolicy{Mode: RestartAlways, MaxRestarts: 2},
+			wantRestarts: 2,
+			wantAttached: 2,
+			wantGaveUp:   true,
+			wantExitCode: 0,
+		},
+		{
+			name:         "failed restarts count towards max restarts",
+			exitCodes:    []string{"1"},
+			policy:       RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 2},
+			spawnErr:     errors.New("spawn failed"),
+			wantRestarts: 2,
+			wantGaveUp:   true,
+			wantExitCode: 1,
+			wantError:    "spawn failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sup, h := newTestSupervisor(t, tt.exitCodes, backoff(tt.policy), tt.spawnErr)
+			select {
+			case <-sup.loopDone:
+			case <-time.After(10 * time.Second):
+				t.Fatal("supervisor did not stop restarting")
+			}
+
+			status := sup.snapshot()
+			if status.Restarts != tt.wantRestarts || status.GaveUp != tt.wantGaveUp || status.Restarting {
+				t.Errorf("status = %+v, want %d restarts, gave up %v", status, tt.wantRestarts, tt.wantGaveUp)
+			}
+			if status.LastExitCode == nil || *status.LastExitCode != tt.wantExitCode {
+				t.Errorf("last exit code = %v, want %d", status.LastExitCode, tt.wantExitCode)
+			}
+			if tt.wantError != "" && status.LastError != tt.wantError {
+				t.Errorf("last error = %q, want %q", status.LastError, tt.wantError)
+			}
+
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if h.spawned != tt.wantRestarts+1 || h.attached != tt.wantAttached {
+				t.Errorf("spawned %d, attached %d; want %d, %d", h.spawned, h.attached, tt.wantRestarts+1, tt.wantAttached)
+			}
+		})
+	}
+}
+
+func TestSupervisorUnlimitedRestarts(t *testing.T) {
+	policy := RestartPolicy{Mode: RestartAlways, MaxRestarts: 0, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	sup, _ := newTestSupervisor(t, []string{"0"}, policy, nil)
+
+	want := defaultMaxRestarts + 2
+	deadline := time.Now().Add(10 * time.Second)
+	for sup.snapshot().Restarts < want {
+		select {
+		case <-sup.loopDone:
+			t.Fatalf("supervisor gave up after %d restarts", sup.snapshot().Restarts)
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d restarts before deadline", sup.snapshot().Restarts)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	sup.stop()
+	if status := sup.snapshot(); status.GaveUp {
+		t.Errorf("status = %+v, want not gave up", status)
+	}
+}
+
+func TestSupervisorBackoffDelaysRestart(t *testing.T) {
+	policy := RestartPolicy{Mode: RestartAlways, MaxRestarts: 2, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	start := time.Now()
+	sup, _ := newTestSupervisor(t, []string{"0"}, policy, nil)
+	select {
+	case <-sup.loopDone:
+	case <-time.After(10 * time.Second):
+		t.Fatal("supervisor did not stop restarting")
+	}
+
+	// 两次重启分别等待100ms和200ms
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Fatalf("restarts finished after %s, want at least 300ms of backoff", elapsed)
+	}
+}
+
+func TestSupervisorStopDuringBackoff(t *testing.T) {
+	policy := RestartPolicy{Mode: RestartAlways, InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+	sup, h := newTestSupervisor(t, []string{"1"}, policy, nil)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !sup.snapshot().Restarting {
+		if time.Now().After(deadline) {
+			t.Fatal("supervisor did not start restarting")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		sup.stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stop() blocked during backoff")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.spawned != 1 {
+		t.Errorf("spawned %d times, want 1", h.spawned)
+	}
+}
@@ -0,0 +1,158 @@
+package plugin
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// writeTestPackage 在dir中写入一个只包含入口文件的VKP包
+// 返回: 包路径和SHA-256
+func writeTestPackage(t *testing.T, dir, name, version string, platform Platform, signer ed25519.PrivateKey) (string, string) {
+	t.Helper()
+
+	return writeTestPackageMetadata(t, dir, PluginMetadata{Name: name, Version: version}, platform, signer)
+}
+
+// writeTestPackageMetadata 在dir中写入一个使用指定元数据的VKP包
+// 返回: 包路径和SHA-256
+func writeTestPackageMetadata(t *testing.T, dir string, metadata PluginMetadata, platform Platform, signer ed25519.PrivateKey) (string, string) {
+	t.Helper()
+
+	name, version := metadata.Name, metadata.Version
+	entry := filepath.Join(t.TempDir(), "plugin")
+	if err := os.WriteFile(entry, []byte("#!/bin/sh\necho "+name+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := []PackageFile{{Source: entry, Path: "plugin"}}
+	manifest, err := BuildManifest(&metadata, "plugin", platform, files)
+	if err != nil {
+		t.Fatalf("BuildManifest() error: %v", err)
+	}
+
+	osName, arch := platform.OS, platform.Arch
+	if osName == "" {
+		osName = "any"
+	}
+	if arch == "" {
+		arch = "any"
+	}
+	path := filepath.Join(dir, name+"_"+osName+"_"+arch+"_v"+version+".vkp")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	if err := WriteVKP(out, manifest, files, signer); err != nil {
+		t.Fatalf("WriteVKP() error: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, sum, err := hashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, sum
+}
+
+// loaderTestPlugin 由testLoader按包内清单创建的测试插件
+type loaderTestPlugin struct {
+	depsTestPlugin
+
+	// initErr Initialize返回的错误
+	initErr error
+}
+
+func (p *loaderTestPlugin) Initialize(ctx context.Context, logger *zap.Logger, config interface{}) error {
+	return p.initErr
+}
+
+// RegisterRoutes 注册返回插件版本的路由 /api/v1/<name>/version
+func (p *loaderTestPlugin) RegisterRoutes(router *gin.Engine) error {
+	router.GET(pluginRoutePrefix+p.GetName()+"/version", func(c *gin.Context) {
+		c.String(http.StatusOK, p.GetVersion())
+	})
+	return nil
+}
+
+// testLoader 按包内清单创建测试插件的加载器，不启动子进程
+type testLoader struct {
+	// mu 保护以下字段
+	mu sync.Mutex
+
+	// failInit 初始化失败的插件，键为 名称@版本
+	failInit map[string]bool
+
+	// failLoad 加载失败的插件，键为 名称@版本
+	failLoad map[string]bool
+
+	// loaded 已加载的插件
+	loaded map[string]Plugin
+}
+
+// newTestLoader 创建测试加载器
+// failInit: 初始化失败的插件（名称@版本）
+// 返回: 测试加载器
+func newTestLoader(failInit ...string) *testLoader {
+	l := &testLoader{failInit: make(map[string]bool), loaded: make(map[string]Plugin)}
+	for _, key := range failInit {
+		l.failInit[key] = true
+	}
+	return l
+}
+
+func (l *testLoader) LoadPlugin(path string) (Plugin, error) {
+	manifest, _, err := ReadManifestFromVKP(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &loaderTestPlugin{depsTestPlugin: depsTestPlugin{metadata: manifest.Plugin}}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.failLoad[p.GetName()+"@"+p.GetVersion()] {
+		return nil, fmt.Errorf("%s %s failed to load", p.GetName(), p.GetVersion())
+	}
+	if l.failInit[p.GetName()+"@"+p.GetVersion()] {
+		p.initErr = fmt.Errorf("%s %s failed to start", p.GetName(), p.GetVersion())
+	}
+	l.loaded[p.GetName()] = p
+	return p, nil
+}
+
+func (l *testLoader) UnloadPlugin(name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, exists := l.loaded[name]; !exists {
+		return fmt.Errorf("plugin '%s' not loaded", name)
+	}
+	delete(l.loaded, name)
+	return nil
+}
+
+func (l *testLoader) ListPlugins() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var names []string
+	for name := range l.loaded {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (l *testLoader) GetPlugin(name string) (Plugin, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	p, exists := l.loaded[name]
+	return p, exists
+}
@@ -0,0 +1,70 @@
+// Package version 提供网关的构建信息
+// Version、Commit、BuildTime 在构建时通过 -ldflags "-X" 注入
+package version
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// PluginAPIVersion 网关实现的插件API版本
+// 插件元数据中的 api_version 主版本号必须与之一致
+const PluginAPIVersion = "v1"
+
+var (
+	// Version 网关版本（语义化版本，如 v1.2.0），未注入时为 dev
+	Version = "dev"
+
+	// Commit 构建时的Git提交
+	Commit = "unknown"
+
+	// BuildTime 构建时间
+	BuildTime = "unknown"
+)
+
+// Info 构建信息
+type Info struct {
+	// Version 网关版本
+	Version string `json:"version"`
+
+	// Commit Git提交
+	Commit string `json:"commit"`
+
+	// BuildTime 构建时间
+	BuildTime string `json:"build_time"`
+
+	// GoVersion 编译所用的Go版本
+	GoVersion string `json:"go_version"`
+
+	// Platform 运行平台（GOOS/GOARCH）
+	Platform string `json:"platform"`
+
+	// PluginAPIVersion 插件API版本
+	PluginAPIVersion string `json:"plugin_api_version"`
+}
+
+// Get 返回当前构建信息
+// 返回: 构建信息
+func Get() Info {
+	return Info{
+		Version:          Version,
+		Commit:           Commit,
+		BuildTime:        BuildTime,
+		GoVersion:        runtime.Version(),
+		Platform:         runtime.GOOS + "/" + runtime.GOARCH,
+		PluginAPIVersion: PluginAPIVersion,
+	}
+}
+
+// IsDevelopment 是否为未注入版本号的开发构建
+// 返回: 是否为开发构建
+func IsDevelopment() bool {
+	return Version == "" || Version == "dev"
+}
+
+// String 返回单行的构建信息
+// 返回: 构建信息字符串
+func (i Info) String() string {
+	return fmt.Sprintf("%s (commit %s, built %s, %s, %s, plugin API %s)",
+		i.Version, i.Commit, i.BuildTime, i.GoVersion, i.Platform, i.PluginAPIVersion)
+}
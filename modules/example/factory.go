@@ -7,6 +7,11 @@ import (
 // ExampleModuleFactory 示例模块工厂
 type ExampleModuleFactory struct{}
 
+// init 向全局模块注册表注册示例模块工厂
+func init() {
+	module.Register(NewExampleModuleFactory())
+}
+
 // NewExampleModuleFactory 创建新的示例模块工厂
 // 返回值: *ExampleModuleFactory 示例模块工厂实例
 func NewExampleModuleFactory() *ExampleModuleFactory {
@@ -0,0 +1,22 @@
+package example
+
+import (
+	"testing"
+
+	"github.com/vera-byte/vgo-gateway/internal/module"
+)
+
+func TestFactoryRegisteredAtInit(t *testing.T) {
+	registry := module.DefaultRegistry()
+	if !registry.HasFactory("example") {
+		t.Fatalf("example factory not registered, have %q", registry.ListFactories())
+	}
+
+	created, err := registry.CreateModule("example")
+	if err != nil {
+		t.Fatalf("CreateModule(example) error: %v", err)
+	}
+	if _, ok := created.(*ExampleModule); !ok || created.Name() != "example" {
+		t.Fatalf("CreateModule(example) = %T named %q", created, created.Name())
+	}
+}
@@ -161,44 +161,40 @@ package_vkp() {
     
     build_module "$os" "$arch" "$binary_path"
     
-    # 创建元数据文件
-    local metadata_file="$temp_dir/metadata.json"
-    jq '.metadata' "$MODULE_DIR/vkp-config.json" > "$metadata_file"
+    # 创建VKP包
+    cd "$temp_dir"
     
-    # 创建插件元数据文件
-    cat > "$temp_dir/plugin.json" << EOF
-{
-    "name": "iam",
-    "version": "1.0.0",
-    "description": "Identity and Access Management module",
-    "author": "VGO Team",
-    "license": "MIT",
-    "api_version": "v1",
-    "min_gateway_version": "1.0.0",
-    "standalone_support": true,
-    "binary_name": "$(basename $binary_path)",
-    "config_schema": {
-        "endpoint": {
-            "type": "string",
-            "default": "localhost:9090",
-            "description": "IAM service endpoint"
-        },
-        "timeout": {
-            "type": "integer",
-            "default": 30,
-            "description": "Connection timeout in seconds"
-        }
-    }
-}
-EOF
+    # 重命名二进制文件为入口文件
+    local entrypoint="plugin"
+    if [ "$os" = "windows" ]; then
+        entrypoint="plugin.exe"
+    fi
+    mv "$(basename "$binary_path")" "$entrypoint"
     
-    # 创建VKP包
-     cd "$temp_dir"
-     
-     # 重命名二进制文件为plugin
-     mv "$(basename "$binary_path")" plugin
-     
-     tar -czf "$MODULE_DIR/$output" plugin metadata.json plugin.json
+    # 生成manifest.json（格式版本、入口、目标平台、文件大小和SHA-256）
+    local size=$(wc -c < "$entrypoint" | tr -d ' ')
+    local sha256
+    if command -v sha256sum > /dev/null 2>&1; then
+        sha256=$(sha256sum "$entrypoint" | cut -d' ' -f1)
+    else
+        sha256=$(shasum -a 256 "$entrypoint" | cut -d' ' -f1)
+    fi
+    jq --arg entrypoint "$entrypoint" \
+        --arg os "$os" \
+        --arg arch "$arch" \
+        --arg sha256 "$sha256" \
+        --argjson size "$size" \
+        --arg created_at "$(date -u +%Y-%m-%dT%H:%M:%SZ)" \
+        '{
+            format_version: 1,
+            plugin: .metadata,
+            entrypoint: $entrypoint,
+            platform: {os: $os, arch: $arch},
+            files: [{path: $entrypoint, size: $size, sha256: $sha256}],
+            created_at: $created_at
+        }' "$MODULE_DIR/vkp-config.json" > manifest.json
+    
+    tar -czf "$MODULE_DIR/$output" manifest.json "$entrypoint"
     
     if [ $? -eq 0 ]; then
         log_success "VKP打包完成: $output"
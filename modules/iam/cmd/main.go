@@ -3,8 +3,8 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
-	"strconv"
 
 	vgokit "github.com/vera-byte/vgo-kit"
 	"go.uber.org/zap"
@@ -20,6 +20,8 @@ func main() {
 	var (
 		mode     = flag.String("mode", "standalone", "运行模式: standalone, gateway, metadata")
 		port     = flag.Int("port", 8080, "监听端口")
+		socket   = flag.String("socket", "", "Unix域套接字路径（网关模式下优先于端口）")
+		config   = flag.String("config", "", "网关生成的插件配置文件路径")
 		help     = flag.Bool("help", false, "显示帮助信息")
 		metadata = flag.Bool("metadata", false, "显示插件元数据")
 	)
@@ -34,34 +36,35 @@ func main() {
 	// 创建IAM模块实例
 	iamModule := iam.NewIAMModule()
 
+	// 处理旧式命令行参数（兼容VKP加载器）
+	if *metadata {
+		showMetadata(iamModule)
+		return
+	}
+
+	// 读取网关传入的配置，未指定时使用模块默认配置
+	var moduleConfig interface{}
+	if *config != "" {
+		loaded, err := plugin.LoadPluginConfig(*config)
+		if err != nil {
+			vgokit.Log.Error("Failed to load config", zap.String("path", *config), zap.Error(err))
+			os.Exit(1)
+		}
+		moduleConfig = loaded
+	}
+
 	// 根据模式执行不同操作
 	switch *mode {
 	case "metadata":
 		showMetadata(iamModule)
 	case "standalone":
-		runStandalone(iamModule, vgokit.Log.Logger, *port)
+		runStandalone(iamModule, vgokit.Log.Logger, *port, moduleConfig)
 	case "gateway":
-		runGatewayMode(iamModule, vgokit.Log.Logger)
+		runGatewayMode(iamModule, vgokit.Log.Logger, *port, *socket, moduleConfig)
 	default:
 		vgokit.Log.Error("Unknown mode", zap.String("mode", *mode))
 		os.Exit(1)
 	}
-
-	// 处理旧式命令行参数（兼容VKP加载器）
-	if *metadata || len(os.Args) > 1 && os.Args[1] == "--metadata" {
-		showMetadata(iamModule)
-		return
-	}
-
-	// 处理端口参数
-	if len(os.Args) > 2 && os.Args[1] == "--port" {
-		if p, err := strconv.Atoi(os.Args[2]); err == nil {
-			*port = p
-		}
-	}
-
-	// 默认独立运行模式
-	runStandalone(iamModule, vgokit.Log.Logger, *port)
 }
 
 // showHelp 显示帮助信息
@@ -71,6 +74,8 @@ func showHelp() {
 	vgokit.Log.Info("Options:")
 	vgokit.Log.Info("  -mode string        运行模式: standalone, gateway, metadata (default \"standalone\")")
 	vgokit.Log.Info("  -port int           监听端口 (default 8080)")
+	vgokit.Log.Info("  -socket string      Unix域套接字路径（网关模式）")
+	vgokit.Log.Info("  -config string      网关生成的插件配置文件路径")
 	vgokit.Log.Info("  -help               显示此帮助信息")
 	vgokit.Log.Info("  -metadata           显示插件元数据")
 	vgokit.Log.Info("Examples:")
@@ -107,31 +112,47 @@ func showMetadata(module *iam.IAMModule) {
 // module: IAM模块实例
 // logger: 日志记录器
 // port: 监听端口
-func runStandalone(module *iam.IAMModule, logger *zap.Logger, port int) {
+// config: 模块配置，为nil时使用默认配置
+func runStandalone(module *iam.IAMModule, logger *zap.Logger, port int, config interface{}) {
 	logger.Info("Starting IAM module in standalone mode",
 		zap.String("name", module.GetName()),
 		zap.String("version", module.GetVersion()),
 		zap.Int("port", port))
 
 	ctx := context.Background()
-	if err := module.RunStandalone(ctx, port); err != nil {
+	runner := plugin.NewStandaloneRunner(module, logger)
+	runner.SetConfig(config)
+	if err := runner.Run(ctx, port); err != nil {
 		logger.Error("Failed to run standalone", zap.Error(err))
 		os.Exit(1)
 	}
 }
 
 // runGatewayMode 网关模式
+// 模块作为网关的子进程运行，仅监听网关分配的回环端口或Unix域套接字
 // module: IAM模块实例
 // logger: 日志记录器
-func runGatewayMode(module *iam.IAMModule, logger *zap.Logger) {
+// port: 网关分配的端口
+// socket: 网关分配的Unix域套接字路径
+// config: 网关传入的模块配置，为nil时使用默认配置
+func runGatewayMode(module *iam.IAMModule, logger *zap.Logger, port int, socket string, config interface{}) {
+	network, address := plugin.NetworkTCP, fmt.Sprintf("127.0.0.1:%d", port)
+	if socket != "" {
+		network, address = plugin.NetworkUnix, socket
+	}
+
 	logger.Info("Starting IAM module in gateway mode",
 		zap.String("name", module.GetName()),
-		zap.String("version", module.GetVersion()))
+		zap.String("version", module.GetVersion()),
+		zap.String("network", network),
+		zap.String("address", address))
 
-	// 在网关模式下，模块作为子进程运行
-	// 这里可以实现与主网关进程的通信逻辑
-	// 目前简化为独立运行模式
-	runStandalone(module, logger, 8080)
+	runner := plugin.NewStandaloneRunner(module, logger)
+	runner.SetConfig(config)
+	if err := runner.RunOn(context.Background(), network, address); err != nil {
+		logger.Error("Failed to run in gateway mode", zap.Error(err))
+		os.Exit(1)
+	}
 }
 
 // NewPlugin 插件工厂函数（用于Go plugin加载）
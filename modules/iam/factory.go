@@ -51,6 +51,11 @@ func (a *IAMModuleAdapter) Shutdown(ctx context.Context) error {
 // IAMModuleFactory IAM模块工厂
 type IAMModuleFactory struct{}
 
+// init 向全局模块注册表注册IAM模块工厂
+func init() {
+	module.Register(NewIAMModuleFactory())
+}
+
 // NewIAMModuleFactory 创建新的IAM模块工厂
 // 返回值: *IAMModuleFactory IAM模块工厂实例
 func NewIAMModuleFactory() *IAMModuleFactory {
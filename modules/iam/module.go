@@ -84,10 +84,12 @@ func (m *IAMModule) InitializeModule(ctx context.Context, config interface{}, lo
 		}
 	}
 
-	if timeout, exists := configMap["timeout"]; exists {
-		if timeoutInt, ok := timeout.(int); ok {
-			m.config.Timeout = timeoutInt
-		}
+	// 从JSON读取的配置（如网关传给子进程的配置文件）中数字为float64
+	switch timeout := configMap["timeout"].(type) {
+	case int:
+		m.config.Timeout = timeout
+	case float64:
+		m.config.Timeout = int(timeout)
 	}
 
 	// 创建IAM客户端